@@ -1,15 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	ossignal "os/signal"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	cli "github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 
 	"github.com/snowzach/golib/conf"
 	"github.com/snowzach/golib/httpserver"
@@ -19,7 +30,15 @@ import (
 	"github.com/snowzach/golib/signal"
 	"github.com/snowzach/golib/version"
 	"github.com/snowzach/gorestapi/embed"
+	"github.com/snowzach/gorestapi/gorestapi/config"
+	"github.com/snowzach/gorestapi/gorestapi/grpcapi"
+	"github.com/snowzach/gorestapi/gorestapi/health"
+	"github.com/snowzach/gorestapi/gorestapi/importer"
+	"github.com/snowzach/gorestapi/gorestapi/lifecycle"
 	"github.com/snowzach/gorestapi/gorestapi/mainrpc"
+	"github.com/snowzach/gorestapi/gorestapi/outbox"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+	"github.com/snowzach/gorestapi/gorestapi/webhook"
 	"github.com/snowzach/gorestapi/store/postgres"
 )
 
@@ -36,8 +55,36 @@ var (
 
 			var err error
 
+			// Everything is stopped in order on shutdown, background workers are stopped together
+			shutdown := lifecycle.New(log.Logger.With("context", "shutdown"))
+			workers := lifecycle.NewGroup()
+
+			// Reload the config on SIGHUP and optionally when the file changes
+			reloader := config.NewReloader(log.Logger.With("context", "config"), conf.C, loadValidConfig)
+			reloader.Handle(setLogLevel, "logger.level")
+			workers.Go(func(ctx context.Context) {
+				hangup := make(chan os.Signal, 1)
+				ossignal.Notify(hangup, syscall.SIGHUP)
+				defer ossignal.Stop(hangup)
+				for {
+					select {
+					case <-hangup:
+						if err := reloader.Reload(); err != nil {
+							log.Errorf("Could not reload config: %v", err)
+						}
+					case <-ctx.Done():
+						return
+					}
+				}
+			})
+			if conf.C.Bool("config.watch") && cfgFile != "" {
+				workers.Go(func(ctx context.Context) {
+					reloader.Watch(cfgFile, conf.C.Duration("config.watch_interval"), ctx.Done())
+				})
+			}
+
 			// Create the router and server config
-			router, err := newRouter()
+			router, err := newRouter(reloader)
 			if err != nil {
 				log.Fatalf("router config error: %v", err)
 			}
@@ -48,11 +95,174 @@ var (
 				log.Fatalf("database config error: %v", err)
 			}
 
+			// Idempotency-Key support for write endpoints
+			if conf.C.Bool("server.idempotency.enabled") {
+				var idempotencyConfig mainrpc.IdempotencyConfig
+				if err := conf.C.Unmarshal(&idempotencyConfig, conf.UnmarshalConf{Path: "server.idempotency"}); err != nil {
+					log.Fatalf("could not parse server.idempotency config: %v", err)
+				}
+				router.Use(mainrpc.IdempotencyMiddleware(db, idempotencyConfig))
+
+				// Periodically purge expired keys
+				every(workers, conf.C.Duration("server.idempotency.purge_interval"), func(ctx context.Context) {
+					if _, err := db.IdempotencyPurgeExpired(ctx); err != nil {
+						log.Errorf("Could not purge idempotency keys: %v", err)
+					}
+				})
+			}
+
 			// Version endpoint
 			router.Get("/version", version.GetVersion())
 
+			// Admin endpoints, the config is never served without a token
+			if conf.C.Bool("admin.enabled") {
+				if token := conf.C.String("admin.token"); token != "" {
+					router.With(bearerAuth(token)).Get("/admin/config", reloader.ConfigHandler())
+				} else {
+					log.Warn("Not serving /admin/config, admin.token is not set")
+				}
+			}
+
+			// Health endpoints, readiness fails first on shutdown so load balancers stop sending traffic
+			if conf.C.Bool("health.enabled") {
+				h, err := setupHealth(router, db)
+				if err != nil {
+					log.Fatalf("health config error: %v", err)
+				}
+				shutdown.Add("readiness", 0, func(ctx context.Context) error {
+					h.Shutdown()
+					return sleep(ctx, conf.C.Duration("health.shutdown_delay"))
+				})
+			}
+
+			// Event stream
+			var mainrpcOptions []mainrpc.Option
+			var eventBroker *mainrpc.EventBroker
+			if conf.C.Bool("events.enabled") {
+				eventBroker = mainrpc.NewEventBroker()
+				mainrpcOptions = append(mainrpcOptions, mainrpc.WithEvents(db, eventBroker, conf.C.Duration("events.keepalive")))
+
+				// WebSocket subscriptions accept the same origins as CORS, following reloads
+				if conf.C.Bool("events.websocket.enabled") {
+					var allowedOrigins atomic.Pointer[[]string]
+					setAllowedOrigins := func(c *conf.Conf) error {
+						var origins []string
+						if c.Bool("server.cors.enabled") {
+							origins = c.Strings("server.cors.allowed_origins")
+						}
+						allowedOrigins.Store(&origins)
+						return nil
+					}
+					_ = setAllowedOrigins(conf.C)
+					reloader.Handle(setAllowedOrigins, "server.cors")
+					mainrpcOptions = append(mainrpcOptions, mainrpc.WithWebSocket(func() []string { return *allowedOrigins.Load() }))
+				}
+
+				// Listen for events from all replicas and publish them to the broker
+				workers.Go(func(ctx context.Context) {
+					since, err := db.EventsLastID(ctx)
+					if err != nil {
+						log.Errorf("Could not get last event: %v", err)
+					}
+					for {
+						since, err = db.EventsListen(ctx, since, eventBroker.Publish)
+						if ctx.Err() != nil {
+							return
+						}
+						log.Errorf("Event listener error: %v", err)
+						if sleep(ctx, 5*time.Second) != nil {
+							return
+						}
+					}
+				})
+			}
+
+			// Webhooks
+			if conf.C.Bool("webhooks.enabled") {
+				var webhookConfig webhook.Config
+				if err := conf.C.Unmarshal(&webhookConfig, conf.UnmarshalConf{Path: "webhooks"}); err != nil {
+					log.Fatalf("could not parse webhooks config: %v", err)
+				}
+				mainrpcOptions = append(mainrpcOptions, mainrpc.WithWebhooks(db))
+
+				// Start the delivery worker
+				worker := webhook.NewWorker(db, webhookConfig)
+				workers.Go(worker.Run)
+
+				// Periodically purge delivered and dead deliveries
+				every(workers, conf.C.Duration("webhooks.purge_interval"), func(ctx context.Context) {
+					if _, err := db.WebhookDeliveriesPurge(ctx, time.Now().Add(-conf.C.Duration("webhooks.retention"))); err != nil {
+						log.Errorf("Could not purge webhook deliveries: %v", err)
+					}
+				})
+			}
+
+			// Outbox relay
+			if conf.C.Bool("outbox.enabled") {
+				var outboxConfig outbox.Config
+				if err := conf.C.Unmarshal(&outboxConfig, conf.UnmarshalConf{Path: "outbox"}); err != nil {
+					log.Fatalf("could not parse outbox config: %v", err)
+				}
+				sinks, closeSinks, err := newOutboxSinks()
+				if err != nil {
+					log.Fatalf("outbox sink config error: %v", err)
+				}
+
+				// Start the relay
+				relay := outbox.NewRelay(db, outboxConfig, sinks...)
+				workers.Go(func(ctx context.Context) {
+					defer closeSinks()
+					relay.Run(ctx)
+				})
+
+				// Periodically purge delivered messages
+				every(workers, conf.C.Duration("outbox.purge_interval"), func(ctx context.Context) {
+					if _, err := db.OutboxPurge(ctx, time.Now().Add(-conf.C.Duration("outbox.retention"))); err != nil {
+						log.Errorf("Could not purge outbox: %v", err)
+					}
+				})
+			}
+
+			// Periodically purge old events, they are recorded for the event stream, webhooks and the outbox
+			if conf.C.Bool("events.enabled") || conf.C.Bool("webhooks.enabled") || conf.C.Bool("outbox.enabled") {
+				every(workers, conf.C.Duration("events.purge_interval"), func(ctx context.Context) {
+					if _, err := db.EventsPurge(ctx, time.Now().Add(-conf.C.Duration("events.retention"))); err != nil {
+						log.Errorf("Could not purge events: %v", err)
+					}
+				})
+			}
+
+			// Import
+			if conf.C.Bool("import.enabled") {
+				var importerConfig importer.Config
+				if err := conf.C.Unmarshal(&importerConfig, conf.UnmarshalConf{Path: "import"}); err != nil {
+					log.Fatalf("could not parse import config: %v", err)
+				}
+				mainrpcOptions = append(mainrpcOptions, mainrpc.WithImport(importer.New(db, db, importerConfig)))
+			}
+
+			// Batch requests
+			if conf.C.Bool("batch.enabled") {
+				mainrpcOptions = append(mainrpcOptions, mainrpc.WithBatch(db, conf.C.Int("batch.max_requests")))
+			}
+
+			// JSON-RPC
+			if conf.C.Bool("jsonrpc.enabled") {
+				mainrpcOptions = append(mainrpcOptions, mainrpc.WithJSONRPC())
+			}
+
+			// GraphQL
+			if conf.C.Bool("graphql.enabled") {
+				mainrpcOptions = append(mainrpcOptions, mainrpc.WithGraphQL())
+			}
+
+			// OpenAPI validation
+			if conf.C.Bool("openapi.validate_requests") {
+				mainrpcOptions = append(mainrpcOptions, mainrpc.WithOpenAPIValidation(conf.C.Bool("openapi.validate_responses")))
+			}
+
 			// MainRPC
-			if err = mainrpc.Setup(router, db); err != nil {
+			if err = mainrpc.Setup(router, db, mainrpcOptions...); err != nil {
 				log.Fatalf("Could not setup mainrpc: %v", err)
 			}
 
@@ -80,30 +290,179 @@ var (
 				htmlFilesServer.ServeHTTP(w, r)
 			}))
 
+			// gRPC
+			var handler http.Handler = router
+			var grpcServer *grpc.Server
+			if conf.C.Bool("grpc.enabled") {
+				grpcServer = grpc.NewServer()
+				grpcapi.RegisterGRStoreServer(grpcServer, grpcapi.NewServer(db))
+				if conf.C.Bool("grpc.reflection") {
+					reflection.Register(grpcServer)
+				}
+
+				if port := conf.C.String("grpc.port"); port != "" {
+					// Serve on a separate port
+					listener, err := net.Listen("tcp", net.JoinHostPort(conf.C.String("grpc.host"), port))
+					if err != nil {
+						log.Fatalf("could not create grpc listener: %v", err)
+					}
+					go func() {
+						if err := grpcServer.Serve(listener); err != nil {
+							log.Errorf("gRPC server error: %v", err)
+							signal.Stop.Stop()
+						}
+					}()
+					log.Infof("gRPC listening on %s", listener.Addr())
+				} else {
+					// Share the API port
+					handler = grpcapi.Handler(grpcServer, router)
+				}
+			}
+
 			// Create a server
-			s, err := newServer(router)
+			s, err := newServer(handler)
 			if err != nil {
 				log.Fatalf("could not create server error: %v", err)
 			}
 
 			// Start the listener and service connections.
 			go func() {
-				if err = s.ListenAndServe(); err != nil {
+				if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					log.Errorf("Server error: %v", err)
 					signal.Stop.Stop()
 				}
 			}()
 			log.Infof("API listening on %s", s.Addr)
 
+			// Stop accepting and drain in-flight requests, event streams are ended as they never finish
+			if eventBroker != nil {
+				s.RegisterOnShutdown(eventBroker.Close)
+			}
+			shutdown.Add("server", conf.C.Duration("shutdown.drain_timeout"), func(ctx context.Context) error {
+				return shutdownServers(ctx, s.Server, grpcServer)
+			})
+			// Stop the background workers once no requests can depend on them
+			shutdown.Add("workers", conf.C.Duration("shutdown.workers_timeout"), workers.Stop)
+			// Close the database last
+			shutdown.Add("database", 0, func(ctx context.Context) error {
+				return db.Close()
+			})
+			shutdown.Add("metrics", conf.C.Duration("shutdown.drain_timeout"), stopMetricsServer)
+
 			// Register signal handler and wait
 			signal.Stop.OnSignal(signal.DefaultStopSignals...)
 			<-signal.Stop.Chan() // Wait until Stop
-			signal.Stop.Wait()   // Wait until everyone cleans up
+			log.Info("Shutting down")
+			if err := shutdown.Shutdown(context.Background()); err != nil {
+				log.Errorf("Shutdown error: %v", err)
+			}
 		},
 	}
 )
 
-func newRouter() (chi.Router, error) {
+// setupHealth serves /healthz and /readyz with the database checks
+func setupHealth(router chi.Router, db *postgres.Client) (*health.Health, error) {
+
+	h := health.New(conf.C.Duration("health.timeout"))
+	h.Register("database", health.CheckerFunc(db.Ping))
+
+	migrationSource, err := embed.MigrationSource()
+	if err != nil {
+		return nil, fmt.Errorf("could not get database migrations: %w", err)
+	}
+	migrationsCheck, err := db.MigrationsCheck(migrationSource)
+	if err != nil {
+		return nil, err
+	}
+	h.Register("migrations", health.CheckerFunc(migrationsCheck))
+
+	router.Get("/healthz", h.Liveness())
+	router.Get("/readyz", h.Readiness())
+
+	return h, nil
+
+}
+
+// bearerAuth requires the Authorization header to have the bearer token
+func bearerAuth(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+				render.Err(w, http.StatusUnauthorized, render.WithStatus("unauthorized"), render.WithError(errors.New("invalid token")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// shutdownServers stops accepting connections and waits for in-flight requests and gRPC calls until ctx is done,
+// then closes whatever is left
+func shutdownServers(ctx context.Context, server *http.Server, grpcServer *grpc.Server) error {
+
+	grpcStopped := make(chan struct{})
+	if grpcServer != nil {
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+	}
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		_ = server.Close()
+	}
+
+	if grpcServer != nil {
+		select {
+		case <-grpcStopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+			err = errors.Join(err, fmt.Errorf("grpc: %w", ctx.Err()))
+		}
+	}
+
+	return err
+
+}
+
+// every runs f at interval in the background until the workers are stopped. An interval <= 0 never runs it.
+func every(workers *lifecycle.Group, interval time.Duration, f func(ctx context.Context)) {
+	if interval <= 0 {
+		return
+	}
+	workers.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				f(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newRouter creates the router with the request logger, CORS and metrics middleware. Their config is applied again
+// when the reloader reloads it.
+func newRouter(reloader *config.Reloader) (chi.Router, error) {
 
 	router := chi.NewRouter()
 	router.Use(
@@ -111,45 +470,82 @@ func newRouter() (chi.Router, error) {
 		middleware.RequestID, // Inject request-id
 	)
 
-	// Request logger
-	if conf.C.Bool("server.log.enabled") {
-		var loggerConfig logger.Config
-		if err := conf.C.Unmarshal(&loggerConfig, conf.UnmarshalConf{Path: "server.log"}); err != nil {
-			return nil, fmt.Errorf("could not parser server.log config: %w", err)
-		}
-		switch conf.C.String("logger.encoding") {
-		default:
-			router.Use(logger.LoggerStandardMiddleware(log.Logger.With("context", "server"), loggerConfig))
+	for _, m := range []struct {
+		path string
+		new  func(c *conf.Conf) (func(http.Handler) http.Handler, error)
+	}{
+		{"server.log", newLoggerMiddleware},
+		{"server.cors", newCORSMiddleware},
+		{"server.metrics", newMetricsMiddleware},
+	} {
+		mw, err := m.new(conf.C)
+		if err != nil {
+			return nil, err
 		}
+		live := config.NewMiddleware(mw)
+		router.Use(live.Handler)
+		newMiddleware := m.new
+		reloader.Handle(func(c *conf.Conf) error {
+			mw, err := newMiddleware(c)
+			if err != nil {
+				return err
+			}
+			live.Set(mw)
+			return nil
+		}, m.path)
 	}
 
-	// CORS handler
-	if conf.C.Bool("server.cors.enabled") {
-		var corsOptions cors.Options
-		if err := conf.C.Unmarshal(&corsOptions, conf.UnmarshalConf{
-			Path: "server.cors",
-			DecoderConfig: conf.DefaultDecoderConfig(
-				conf.WithMatchName(conf.MatchSnakeCaseConfig),
-			),
-		}); err != nil {
-			return nil, fmt.Errorf("could not parser server.cors config: %w", err)
-		}
-		router.Use(cors.New(corsOptions).Handler)
+	return router, nil
+
+}
+
+// newLoggerMiddleware returns the request logger, nil if it is disabled
+func newLoggerMiddleware(c *conf.Conf) (func(http.Handler) http.Handler, error) {
+
+	if !c.Bool("server.log.enabled") {
+		return nil, nil
+	}
+	var loggerConfig logger.Config
+	if err := c.Unmarshal(&loggerConfig, conf.UnmarshalConf{Path: "server.log"}); err != nil {
+		return nil, fmt.Errorf("could not parser server.log config: %w", err)
 	}
+	return logger.LoggerStandardMiddleware(log.Logger.With("context", "server"), loggerConfig), nil
 
-	// If we have server metrics enabled, enable the middleware to collect them on the server.
-	if conf.C.Bool("server.metrics.enabled") {
-		var metricsConfig metrics.Config
-		if err := conf.C.Unmarshal(&metricsConfig, conf.UnmarshalConf{
-			Path:          "server.metrics",
-			DecoderConfig: conf.DefaultDecoderConfig(),
-		}); err != nil {
-			return nil, fmt.Errorf("could not parser server.metrics config: %w", err)
-		}
-		router.Use(metrics.MetricsMiddleware(metricsConfig))
+}
+
+// newCORSMiddleware returns the CORS handler, nil if it is disabled
+func newCORSMiddleware(c *conf.Conf) (func(http.Handler) http.Handler, error) {
+
+	if !c.Bool("server.cors.enabled") {
+		return nil, nil
 	}
+	var corsOptions cors.Options
+	if err := c.Unmarshal(&corsOptions, conf.UnmarshalConf{
+		Path: "server.cors",
+		DecoderConfig: conf.DefaultDecoderConfig(
+			conf.WithMatchName(conf.MatchSnakeCaseConfig),
+		),
+	}); err != nil {
+		return nil, fmt.Errorf("could not parser server.cors config: %w", err)
+	}
+	return cors.New(corsOptions).Handler, nil
 
-	return router, nil
+}
+
+// newMetricsMiddleware returns the middleware collecting metrics on the server, nil if it is disabled
+func newMetricsMiddleware(c *conf.Conf) (func(http.Handler) http.Handler, error) {
+
+	if !c.Bool("server.metrics.enabled") {
+		return nil, nil
+	}
+	var metricsConfig metrics.Config
+	if err := c.Unmarshal(&metricsConfig, conf.UnmarshalConf{
+		Path:          "server.metrics",
+		DecoderConfig: conf.DefaultDecoderConfig(),
+	}); err != nil {
+		return nil, fmt.Errorf("could not parser server.metrics config: %w", err)
+	}
+	return metrics.MetricsMiddleware(metricsConfig), nil
 
 }
 
@@ -171,8 +567,79 @@ func newServer(handler http.Handler) (*httpserver.Server, error) {
 
 }
 
+// newOutboxSinks creates the configured outbox sinks and a function to close them
+func newOutboxSinks() ([]outbox.Sink, func(), error) {
+
+	var sinks []outbox.Sink
+	var closers []func() error
+	closeSinks := func() {
+		for _, closer := range closers {
+			_ = closer()
+		}
+	}
+
+	for _, name := range conf.C.Strings("outbox.sinks") {
+		switch name {
+		case "log":
+			sinks = append(sinks, outbox.NewLogSink(log.Logger.With("context", "outbox.sink")))
+		case "http":
+			var httpSinkConfig outbox.HTTPSinkConfig
+			if err := conf.C.Unmarshal(&httpSinkConfig, conf.UnmarshalConf{Path: "outbox.http"}); err != nil {
+				closeSinks()
+				return nil, nil, fmt.Errorf("could not parse outbox.http config: %w", err)
+			}
+			sink, err := outbox.NewHTTPSink(httpSinkConfig)
+			if err != nil {
+				closeSinks()
+				return nil, nil, fmt.Errorf("could not create http sink: %w", err)
+			}
+			sinks = append(sinks, sink)
+		case "file":
+			sink, err := outbox.NewFileSink(conf.C.String("outbox.file.path"))
+			if err != nil {
+				closeSinks()
+				return nil, nil, fmt.Errorf("could not create file sink: %w", err)
+			}
+			sinks = append(sinks, sink)
+			closers = append(closers, sink.Close)
+		default:
+			closeSinks()
+			return nil, nil, fmt.Errorf("unknown outbox sink: %s", name)
+		}
+	}
+	if len(sinks) == 0 {
+		return nil, nil, fmt.Errorf("no outbox sinks configured")
+	}
+
+	return sinks, closeSinks, nil
+
+}
+
 func newDatabase() (*postgres.Client, error) {
 
+	postgresConfig, err := newDatabaseConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Migrations are run on start unless they are run separately with the migrate command
+	if !conf.C.Bool("database.auto_migrate") {
+		postgresConfig.MigrationSource = nil
+	}
+
+	// Create database
+	db, err := postgres.New(postgresConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not create database client: %w", err)
+	}
+
+	return db, nil
+
+}
+
+// newDatabaseConfig returns the database config with the embedded migrations
+func newDatabaseConfig() (*postgres.Config, error) {
+
 	var err error
 
 	// Database config
@@ -181,6 +648,12 @@ func newDatabase() (*postgres.Client, error) {
 		return nil, fmt.Errorf("could not parse database config: %v", err)
 	}
 
+	// Record events in commit order for the event stream, in the outbox for the relay and as webhook deliveries for
+	// the worker when they are enabled
+	postgresConfig.Events = conf.C.Bool("events.enabled")
+	postgresConfig.Outbox = conf.C.Bool("outbox.enabled")
+	postgresConfig.Webhooks = conf.C.Bool("webhooks.enabled")
+
 	// Loggers
 	postgresConfig.Logger = log.NewWrapper(log.Logger.With("context", "database.postgres"), slog.LevelInfo)
 	if conf.C.Bool("database.log_queries") {
@@ -193,12 +666,6 @@ func newDatabase() (*postgres.Client, error) {
 		return nil, fmt.Errorf("could not get database migrations error: %w", err)
 	}
 
-	// Create database
-	db, err := postgres.New(postgresConfig)
-	if err != nil {
-		return nil, fmt.Errorf("could not create database client: %w", err)
-	}
-
-	return db, nil
+	return postgresConfig, nil
 
 }
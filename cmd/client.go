@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cli "github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/snowzach/golib/conf"
+	"github.com/snowzach/golib/log"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/client"
+)
+
+func init() {
+	for _, cmd := range []*cli.Command{thingsCmd, widgetsCmd} {
+		cmd.PersistentFlags().StringP("output", "o", "table", "output format (table, json, yaml)")
+		cmd.PersistentFlags().String("url", "", "url of the server, overrides client.url")
+		rootCmd.AddCommand(cmd)
+	}
+
+	thingsCmd.AddCommand(
+		clientListCmd("things", thingColumns, func(c *client.Client, qp *queryp.QueryParameters) ([]*gorestapi.Thing, *int64, error) {
+			return c.ThingsFind(context.Background(), qp)
+		}),
+		clientGetCmd("thing", thingColumns, func(c *client.Client, id string) (*gorestapi.Thing, error) {
+			return c.ThingGetByID(context.Background(), id)
+		}),
+		clientSaveCmd("thing", thingColumns, thingFlags, func(c *client.Client, thing *gorestapi.Thing) error {
+			return c.ThingSave(context.Background(), thing)
+		}),
+		clientDeleteCmd("thing", func(c *client.Client, id string) error {
+			return c.ThingDeleteByID(context.Background(), id)
+		}),
+	)
+
+	widgetsCmd.AddCommand(
+		clientListCmd("widgets", widgetColumns, func(c *client.Client, qp *queryp.QueryParameters) ([]*gorestapi.Widget, *int64, error) {
+			return c.WidgetsFind(context.Background(), qp)
+		}),
+		clientGetCmd("widget", widgetColumns, func(c *client.Client, id string) (*gorestapi.Widget, error) {
+			return c.WidgetGetByID(context.Background(), id)
+		}),
+		clientSaveCmd("widget", widgetColumns, widgetFlags, func(c *client.Client, widget *gorestapi.Widget) error {
+			return c.WidgetSave(context.Background(), widget)
+		}),
+		clientDeleteCmd("widget", func(c *client.Client, id string) error {
+			return c.WidgetDeleteByID(context.Background(), id)
+		}),
+	)
+}
+
+var (
+	thingsCmd = &cli.Command{
+		Use:   "things",
+		Short: "Manage things on a running server",
+		Long:  `Manage things on a running server over HTTP. The server and credentials are read from the client config.`,
+	}
+
+	widgetsCmd = &cli.Command{
+		Use:   "widgets",
+		Short: "Manage widgets on a running server",
+		Long:  `Manage widgets on a running server over HTTP. The server and credentials are read from the client config.`,
+	}
+)
+
+// column is a column of the table output
+type column[T any] struct {
+	name  string
+	value func(*T) string
+}
+
+var thingColumns = []column[gorestapi.Thing]{
+	{"ID", func(t *gorestapi.Thing) string { return t.ID }},
+	{"NAME", func(t *gorestapi.Thing) string { return t.Name }},
+	{"DESCRIPTION", func(t *gorestapi.Thing) string { return t.Description }},
+	{"UPDATED", func(t *gorestapi.Thing) string { return formatTime(t.Updated) }},
+}
+
+var widgetColumns = []column[gorestapi.Widget]{
+	{"ID", func(w *gorestapi.Widget) string { return w.ID }},
+	{"NAME", func(w *gorestapi.Widget) string { return w.Name }},
+	{"DESCRIPTION", func(w *gorestapi.Widget) string { return w.Description }},
+	{"THING", func(w *gorestapi.Widget) string {
+		if w.Thing != nil {
+			return w.Thing.Name
+		} else if w.ThingID != nil {
+			return *w.ThingID
+		}
+		return ""
+	}},
+	{"UPDATED", func(w *gorestapi.Widget) string { return formatTime(w.Updated) }},
+}
+
+// thingFlags adds the flags to set fields of a thing
+func thingFlags(cmd *cli.Command) func(thing *gorestapi.Thing) {
+	id := cmd.Flags().String("id", "", "id of the thing, replaces the thing if it exists")
+	name := cmd.Flags().String("name", "", "name of the thing")
+	description := cmd.Flags().String("description", "", "description of the thing")
+	return func(thing *gorestapi.Thing) {
+		if cmd.Flags().Changed("id") {
+			thing.ID = *id
+		}
+		if cmd.Flags().Changed("name") {
+			thing.Name = *name
+		}
+		if cmd.Flags().Changed("description") {
+			thing.Description = *description
+		}
+	}
+}
+
+// widgetFlags adds the flags to set fields of a widget
+func widgetFlags(cmd *cli.Command) func(widget *gorestapi.Widget) {
+	id := cmd.Flags().String("id", "", "id of the widget, replaces the widget if it exists")
+	name := cmd.Flags().String("name", "", "name of the widget")
+	description := cmd.Flags().String("description", "", "description of the widget")
+	thingID := cmd.Flags().String("thing-id", "", "id of the thing of the widget")
+	return func(widget *gorestapi.Widget) {
+		if cmd.Flags().Changed("id") {
+			widget.ID = *id
+		}
+		if cmd.Flags().Changed("name") {
+			widget.Name = *name
+		}
+		if cmd.Flags().Changed("description") {
+			widget.Description = *description
+		}
+		if cmd.Flags().Changed("thing-id") {
+			widget.ThingID = thingID
+		}
+	}
+}
+
+func clientListCmd[T any](resource string, columns []column[T], find func(c *client.Client, qp *queryp.QueryParameters) ([]*T, *int64, error)) *cli.Command {
+	field := strings.TrimSuffix(resource, "s") + ".name"
+	cmd := &cli.Command{
+		Use:   "list [FILTER...]",
+		Short: "List " + resource,
+		Long: fmt.Sprintf(`List %s matching every filter. Filters use the query syntax of the API from
+https://github.com/snowzach/queryp, ie. '%s=~foo%%' or '(%s=a|%s=b)'.`, resource, field, field, field),
+		Run: func(cmd *cli.Command, args []string) {
+
+			terms := args
+			if sort, _ := cmd.Flags().GetString("sort"); sort != "" {
+				terms = append(terms, "sort="+sort)
+			}
+			for _, flag := range []string{"limit", "offset"} {
+				if value, _ := cmd.Flags().GetInt64(flag); value > 0 {
+					terms = append(terms, flag+"="+strconv.FormatInt(value, 10))
+				}
+			}
+			qp, err := queryp.ParseQuery(strings.Join(terms, "&"))
+			if err != nil {
+				log.Fatalf("invalid filter: %v", err)
+			}
+
+			records, count, err := find(newClient(cmd), qp)
+			if err != nil {
+				log.Fatalf("could not list %s: %v", resource, err)
+			}
+			output(cmd, columns, records, records)
+			if count != nil && outputFormat(cmd) == "table" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%d of %d %s\n", len(records), *count, resource)
+			}
+
+		},
+	}
+	cmd.Flags().String("sort", "", "fields to sort by separated by commas, prefix with - for descending")
+	cmd.Flags().Int64("limit", 0, "maximum number of "+resource)
+	cmd.Flags().Int64("offset", 0, "number of "+resource+" to skip")
+	return cmd
+}
+
+func clientGetCmd[T any](resource string, columns []column[T], get func(c *client.Client, id string) (*T, error)) *cli.Command {
+	return &cli.Command{
+		Use:   "get ID",
+		Short: "Get a " + resource,
+		Args:  cli.ExactArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+			record, err := get(newClient(cmd), args[0])
+			if err != nil {
+				log.Fatalf("could not get %s: %v", resource, err)
+			}
+			output(cmd, columns, []*T{record}, record)
+		},
+	}
+}
+
+func clientSaveCmd[T any](resource string, columns []column[T], flags func(cmd *cli.Command) func(*T), save func(c *client.Client, record *T) error) *cli.Command {
+	var set func(*T)
+	cmd := &cli.Command{
+		Use:   "save [FILE]",
+		Short: "Create or replace a " + resource,
+		Long: `Create or replace a ` + resource + ` read as JSON or YAML from the file, - for stdin, and the flags. The flags override
+the fields of the file.`,
+		Args: cli.MaximumNArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+
+			record := new(T)
+			if len(args) == 1 {
+				var input io.Reader = os.Stdin
+				if args[0] != "-" {
+					file, err := os.Open(args[0])
+					if err != nil {
+						log.Fatalf("could not open %s: %v", args[0], err)
+					}
+					defer file.Close()
+					input = file
+				}
+				b, err := io.ReadAll(input)
+				if err != nil {
+					log.Fatalf("could not read %s: %v", resource, err)
+				}
+				if err := yaml.UnmarshalStrict(b, record); err != nil {
+					log.Fatalf("could not parse %s: %v", resource, err)
+				}
+			}
+			set(record)
+
+			if err := save(newClient(cmd), record); err != nil {
+				log.Fatalf("could not save %s: %v", resource, err)
+			}
+			output(cmd, columns, []*T{record}, record)
+
+		},
+	}
+	set = flags(cmd)
+	return cmd
+}
+
+func clientDeleteCmd(resource string, del func(c *client.Client, id string) error) *cli.Command {
+	return &cli.Command{
+		Use:   "delete ID...",
+		Short: "Delete " + resource + "s",
+		Args:  cli.MinimumNArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+			c := newClient(cmd)
+			for _, id := range args {
+				if err := del(c, id); err != nil {
+					log.Fatalf("could not delete %s %s: %v", resource, id, err)
+				}
+			}
+		},
+	}
+}
+
+// newClient creates a client from the client config and the flags
+func newClient(cmd *cli.Command) *client.Client {
+
+	var clientConfig client.Config
+	if err := conf.C.Unmarshal(&clientConfig, conf.UnmarshalConf{Path: "client"}); err != nil {
+		log.Fatalf("could not parse client config: %v", err)
+	}
+	if url, _ := cmd.Flags().GetString("url"); url != "" {
+		clientConfig.URL = url
+	}
+	c, err := client.New(clientConfig)
+	if err != nil {
+		log.Fatalf("could not create client: %v", err)
+	}
+	return c
+
+}
+
+// outputFormat returns the output flag
+func outputFormat(cmd *cli.Command) string {
+	format, _ := cmd.Flags().GetString("output")
+	return format
+}
+
+// output writes the records as a table or the value as json or yaml
+func output[T any](cmd *cli.Command, columns []column[T], records []*T, value any) {
+
+	w := cmd.OutOrStdout()
+	switch format := outputFormat(cmd); format {
+	case "json":
+		b, _ := json.MarshalIndent(value, "", "  ")
+		fmt.Fprintln(w, string(b))
+	case "yaml":
+		b, err := yaml.Marshal(value)
+		if err != nil {
+			log.Fatalf("could not encode yaml: %v", err)
+		}
+		fmt.Fprint(w, string(b))
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for i, column := range columns {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, column.name)
+		}
+		fmt.Fprintln(tw)
+		for _, record := range records {
+			for i, column := range columns {
+				if i > 0 {
+					fmt.Fprint(tw, "\t")
+				}
+				fmt.Fprint(tw, strings.ReplaceAll(column.value(record), "\t", " "))
+			}
+			fmt.Fprintln(tw)
+		}
+		tw.Flush()
+	default:
+		log.Fatalf("unknown output format %q, use table, json or yaml", format)
+	}
+
+}
+
+// formatTime formats the time for tables
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.RFC3339)
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+
+	cli "github.com/spf13/cobra"
+
+	"github.com/snowzach/golib/conf"
+	"github.com/snowzach/golib/log"
+
+	"github.com/snowzach/gorestapi/gorestapi/config"
+)
+
+func init() {
+	configPrintCmd.Flags().StringP("output", "o", "table", "output format (table, json, yaml)")
+	configCheckCmd.Flags().Bool("strict", false, "fail on warnings like unknown keys, overrides config.strict")
+	configCmd.AddCommand(configPrintCmd, configCheckCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var (
+	configCmd = &cli.Command{
+		Use:   "config",
+		Short: "Print and check the config",
+		Long:  `Print and check the config merged from the defaults, the config file and the environment.`,
+		// Only load the config, problems are reported by the subcommands
+		PersistentPreRunE: func(cmd *cli.Command, args []string) error {
+			if err := conf.C.Parse(configParsers()...); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "could not load config: %v\n", err)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+
+	configPrintCmd = &cli.Command{
+		Use:   "print",
+		Short: "Print the effective config and the source of each value",
+		Long:  `Print the effective config and whether each value is from the defaults, the config file or the environment. Secrets are redacted.`,
+		Args:  cli.NoArgs,
+		Run: func(cmd *cli.Command, args []string) {
+			file := conf.New()
+			if err := file.Parse(conf.WithFile(cfgFile)); err != nil {
+				log.Fatalf("could not load config file: %v", err)
+			}
+			values := config.Describe(conf.C, file)
+			records := make([]*config.Value, len(values))
+			for i := range values {
+				records[i] = &values[i]
+			}
+			output(cmd, configValueColumns, records, values)
+		},
+	}
+
+	configCheckCmd = &cli.Command{
+		Use:   "check",
+		Short: "Check the config for unknown keys, invalid values and invalid combinations",
+		Long:  `Check the config for unknown keys, invalid values and invalid combinations. Exits 1 if there are errors, or warnings with --strict.`,
+		Args:  cli.NoArgs,
+		Run: func(cmd *cli.Command, args []string) {
+			strict := conf.C.Bool("config.strict")
+			if cmd.Flags().Changed("strict") {
+				strict, _ = cmd.Flags().GetBool("strict")
+			}
+			problems := validateConfig(conf.C)
+			for _, problem := range problems {
+				severity := "error"
+				if problem.Warning {
+					severity = "warning"
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", severity, problem.Error())
+			}
+			if config.Failed(problems, strict) {
+				os.Exit(1)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "config ok")
+		},
+	}
+)
+
+var configValueColumns = []column[config.Value]{
+	{"KEY", func(v *config.Value) string { return v.Key }},
+	{"VALUE", func(v *config.Value) string {
+		if s, ok := v.Value.(string); ok {
+			return s
+		}
+		b, _ := json.Marshal(v.Value)
+		return string(b)
+	}},
+	{"SOURCE", func(v *config.Value) string { return v.Source }},
+}
+
+// validateConfig checks the config against the defaults and the rules of the app
+func validateConfig(c *conf.Conf) []config.Problem {
+	defaultConfig := conf.New()
+	_ = defaultConfig.Parse(conf.WithMap(defaults()))
+	return config.Validate(c, defaultConfig, configRules...)
+}
+
+// configRules check keys that depend on each other
+var configRules = []config.Rule{
+	// Logger
+	func(c *conf.Conf) []config.Problem {
+		var problems []config.Problem
+		if _, err := log.ParseLogLevel(c.String("logger.level")); err != nil {
+			problems = append(problems, config.Problem{Key: "logger.level", Message: "must be debug, info, warn or error"})
+		}
+		if encoding := c.String("logger.encoding"); !slices.Contains([]string{"console", log.EncodingText, log.EncodingJSON}, encoding) {
+			problems = append(problems, config.Problem{Key: "logger.encoding", Message: "must be console, text or json"})
+		}
+		return problems
+	},
+	// TLS needs a certificate unless it generates one
+	func(c *conf.Conf) []config.Problem {
+		if !c.Bool("server.tls") || c.Bool("server.devcert") {
+			return nil
+		}
+		var problems []config.Problem
+		for _, key := range []string{"server.certfile", "server.keyfile"} {
+			if _, err := os.Stat(c.String(key)); err != nil {
+				problems = append(problems, config.Problem{Key: key, Message: fmt.Sprintf("server.tls needs the file: %v", err)})
+			}
+		}
+		return problems
+	},
+	// Ports
+	func(c *conf.Conf) []config.Problem {
+		var problems []config.Problem
+		port := c.String("server.port")
+		if c.Bool("grpc.enabled") && c.String("grpc.port") == port {
+			problems = append(problems, config.Problem{Key: "grpc.port", Message: "is the same as server.port, leave it empty to share the port"})
+		}
+		if c.Bool("grpc.enabled") {
+			problems = append(problems, config.Problem{Key: "grpc.enabled", Message: "gRPC requests are not logged or counted in the request metrics", Warning: true})
+		}
+		if c.Bool("metrics.enabled") && c.String("metrics.port") == port {
+			problems = append(problems, config.Problem{Key: "metrics.port", Message: "is the same as server.port"})
+		}
+		return problems
+	},
+	// Intervals, purges are disabled by 0 but polling can't be
+	func(c *conf.Conf) []config.Problem {
+		var problems []config.Problem
+		for _, key := range []string{"webhooks.poll_interval", "outbox.poll_interval"} {
+			if c.Duration(key) <= 0 {
+				problems = append(problems, config.Problem{Key: key, Message: "must be positive"})
+			}
+		}
+		for _, key := range []string{"server.idempotency.purge_interval", "events.purge_interval", "webhooks.purge_interval", "outbox.purge_interval"} {
+			if c.Duration(key) < 0 {
+				problems = append(problems, config.Problem{Key: key, Message: "must be positive or 0 to disable purging"})
+			}
+		}
+		return problems
+	},
+	// Outbox sinks
+	func(c *conf.Conf) []config.Problem {
+		if !c.Bool("outbox.enabled") {
+			return nil
+		}
+		var problems []config.Problem
+		sinks := c.Strings("outbox.sinks")
+		if len(sinks) == 0 {
+			problems = append(problems, config.Problem{Key: "outbox.sinks", Message: "outbox.enabled needs at least one sink"})
+		}
+		for _, sink := range sinks {
+			switch sink {
+			case "log":
+			case "http":
+				if c.String("outbox.http.url") == "" {
+					problems = append(problems, config.Problem{Key: "outbox.http.url", Message: "the http sink needs a url"})
+				}
+			case "file":
+				if c.String("outbox.file.path") == "" {
+					problems = append(problems, config.Problem{Key: "outbox.file.path", Message: "the file sink needs a path"})
+				}
+			default:
+				problems = append(problems, config.Problem{Key: "outbox.sinks", Message: fmt.Sprintf("unknown sink %q, must be log, http or file", sink)})
+			}
+		}
+		return problems
+	},
+	// Webhook networks
+	func(c *conf.Conf) []config.Problem {
+		var problems []config.Problem
+		for _, network := range c.Strings("webhooks.allowed_networks") {
+			if _, err := netip.ParsePrefix(network); err != nil {
+				problems = append(problems, config.Problem{Key: "webhooks.allowed_networks", Message: fmt.Sprintf("%q is not a network, ie. 10.0.0.0/8", network)})
+			}
+		}
+		return problems
+	},
+	// Database
+	func(c *conf.Conf) []config.Problem {
+		if sslmode := c.String("database.sslmode"); !slices.Contains([]string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}, sslmode) {
+			return []config.Problem{{Key: "database.sslmode", Message: "must be disable, allow, prefer, require, verify-ca or verify-full"}}
+		}
+		return nil
+	},
+	// Admin endpoints
+	func(c *conf.Conf) []config.Problem {
+		if c.Bool("admin.enabled") && c.String("admin.token") == "" {
+			return []config.Problem{{Key: "admin.token", Message: "admin.enabled needs a token, /admin/config is not served without one", Warning: true}}
+		}
+		return nil
+	},
+}
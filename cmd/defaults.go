@@ -30,17 +30,109 @@ func defaults() map[string]interface{} {
 		"server.log.level":         "info",
 		"server.log.request_body":  false,
 		"server.log.response_body": false,
-		"server.log.ignore_paths":  []string{"/version"},
+		"server.log.ignore_paths":  []string{"/version", "/healthz", "/readyz"},
 		// Server CORS
 		"server.cors.enabled":           true,
 		"server.cors.allowed_origins":   []string{"*"},
 		"server.cors.allowed_methods":   []string{http.MethodHead, http.MethodOptions, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch},
 		"server.cors.allowed_headers":   []string{"*"},
+		"server.cors.exposed_headers":   []string{"ETag", "Last-Modified"},
 		"server.cors.allow_credentials": false,
 		"server.cors.max_age":           300,
 		// Server Metrics
 		"server.metrics.enabled":      true,
-		"server.metrics.ignore_paths": []string{"/version"},
+		"server.metrics.ignore_paths": []string{"/version", "/healthz", "/readyz"},
+		// Server Idempotency
+		"server.idempotency.enabled":        true,
+		"server.idempotency.ttl":            "24h",
+		"server.idempotency.purge_interval": "1h",
+		"server.idempotency.max_body_size":  1048576,
+
+		// Config validation and reload
+		"config.strict":         false,
+		"config.watch":          false,
+		"config.watch_interval": "10s",
+
+		// Admin endpoints
+		"admin.enabled": false,
+		"admin.token":   "",
+
+		// Health
+		"health.enabled":        true,
+		"health.timeout":        "5s",
+		"health.shutdown_delay": "5s",
+
+		// Shutdown
+		"shutdown.drain_timeout":   "30s",
+		"shutdown.workers_timeout": "10s",
+
+		// gRPC
+		"grpc.enabled":    false,
+		"grpc.host":       "",
+		"grpc.port":       "",
+		"grpc.reflection": true,
+
+		// Import
+		"import.enabled":    true,
+		"import.batch_size": 500,
+
+		// Batch
+		"batch.enabled":      true,
+		"batch.max_requests": 100,
+
+		// JSON-RPC
+		"jsonrpc.enabled": true,
+
+		// GraphQL
+		"graphql.enabled": true,
+
+		// OpenAPI
+		"openapi.validate_requests":  false,
+		"openapi.validate_responses": false,
+
+		// Events
+		"events.enabled":           true,
+		"events.keepalive":         "15s",
+		"events.retention":         "168h",
+		"events.purge_interval":    "1h",
+		"events.websocket.enabled": true,
+
+		// Webhooks
+		"webhooks.enabled":          false,
+		"webhooks.poll_interval":    "5s",
+		"webhooks.batch_size":       20,
+		"webhooks.timeout":          "10s",
+		"webhooks.max_attempts":     10,
+		"webhooks.backoff_initial":  "30s",
+		"webhooks.backoff_max":      "6h",
+		"webhooks.allowed_networks": []string{},
+		"webhooks.retention":        "168h",
+		"webhooks.purge_interval":   "1h",
+
+		// Outbox
+		"outbox.enabled":         false,
+		"outbox.sinks":           []string{"log"},
+		"outbox.poll_interval":   "1s",
+		"outbox.batch_size":      100,
+		"outbox.lease":           "5m",
+		"outbox.backoff_initial": "5s",
+		"outbox.backoff_max":     "10m",
+		"outbox.retention":       "24h",
+		"outbox.purge_interval":  "1h",
+		"outbox.http.url":        "",
+		"outbox.http.timeout":    "10s",
+		"outbox.http.headers":    map[string]string{},
+		"outbox.file.path":       "outbox.ndjson",
+
+		// Client of the things and widgets commands
+		"client.url":             "http://localhost:8080",
+		"client.token":           "",
+		"client.username":        "",
+		"client.password":        "",
+		"client.timeout":         "30s",
+		"client.retries":         3,
+		"client.backoff_initial": "200ms",
+		"client.backoff_max":     "5s",
 
 		// Database Settings
 		"database.username":              "postgres",
@@ -60,5 +152,6 @@ func defaults() map[string]interface{} {
 		"database.max_connections":       40,
 		"database.log_queries":           false,
 		"database.wipe_confirm":          false,
+		"database.auto_migrate":          true,
 	}
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cli "github.com/spf13/cobra"
+
+	"github.com/snowzach/golib/log"
+
+	"github.com/snowzach/gorestapi/gorestapi/archive"
+)
+
+func init() {
+	exportCmd.Flags().Bool("gzip", false, "compress the archive, the default for .gz and .tgz files")
+	exportCmd.Flags().Int("chunk-size", archive.DefaultChunkSize, "number of records per file in the archive")
+	rootCmd.AddCommand(exportCmd)
+}
+
+var (
+	exportCmd = &cli.Command{
+		Use:   "export FILE",
+		Short: "Export every thing and widget to an archive",
+		Long: `Export every thing and widget with their timestamps to a versioned tar archive of NDJSON files that can be
+restored with restore. Records are streamed from the database so it works on any size of dataset. Use - to write
+to stdout.`,
+		Args: cli.ExactArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+
+			filename := args[0]
+			compress, _ := cmd.Flags().GetBool("gzip")
+			chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+			if strings.HasSuffix(filename, ".gz") || strings.HasSuffix(filename, ".tgz") {
+				compress = true
+			}
+
+			// Create the database
+			db, err := newDatabase()
+			if err != nil {
+				log.Fatalf("database config error: %v", err)
+			}
+
+			var output io.Writer = os.Stdout
+			var summary io.Writer = os.Stdout
+			if filename == "-" {
+				summary = os.Stderr
+			} else {
+				file, err := os.Create(filename)
+				if err != nil {
+					log.Fatalf("could not create %s: %v", filename, err)
+				}
+				defer file.Close()
+				output = file
+			}
+			fail := func(format string, args ...any) {
+				if filename != "-" {
+					os.Remove(filename)
+				}
+				log.Fatalf(format, args...)
+			}
+
+			var gw *gzip.Writer
+			if compress {
+				gw = gzip.NewWriter(output)
+				output = gw
+			}
+
+			result, err := archive.Export(context.Background(), db, db, output, chunkSize)
+			if err != nil {
+				fail("export error: %v", err)
+			}
+			if gw != nil {
+				if err := gw.Close(); err != nil {
+					fail("export error: %v", err)
+				}
+			}
+
+			b, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Fprintln(summary, string(b))
+
+		},
+	}
+)
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	cli "github.com/spf13/cobra"
+
+	"github.com/snowzach/golib/conf"
+	"github.com/snowzach/golib/log"
+
+	"github.com/snowzach/gorestapi/gorestapi/importer"
+)
+
+func init() {
+	importCmd.Flags().Bool("dry-run", false, "report what would change without saving")
+	importCmd.Flags().String("format", "", "format of the file (csv, ndjson), by default from the file extension")
+	importCmd.Flags().String("report", "", "write the per-row report as csv to this file")
+	rootCmd.AddCommand(importCmd)
+}
+
+var (
+	importCmd = &cli.Command{
+		Use:   "import things|widgets FILE",
+		Short: "Import things or widgets",
+		Long: `Import things or widgets from a CSV or NDJSON file directly into the database. Use - to read from stdin.
+Use restore for archives created with export.`,
+		Args:      cli.ExactArgs(2),
+		ValidArgs: []string{"things", "widgets"},
+		Run: func(cmd *cli.Command, args []string) {
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			format, _ := cmd.Flags().GetString("format")
+			reportFile, _ := cmd.Flags().GetString("report")
+
+			resource, filename := args[0], args[1]
+			if format == "" {
+				format = importer.FormatForFilename(filename)
+			}
+			if format == "" {
+				log.Fatalf("could not determine format of %s, use --format", filename)
+			}
+
+			var input io.Reader = os.Stdin
+			if filename != "-" {
+				file, err := os.Open(filename)
+				if err != nil {
+					log.Fatalf("could not open %s: %v", filename, err)
+				}
+				defer file.Close()
+				input = file
+			}
+
+			// Create the database
+			db, err := newDatabase()
+			if err != nil {
+				log.Fatalf("database config error: %v", err)
+			}
+
+			var importerConfig importer.Config
+			if err := conf.C.Unmarshal(&importerConfig, conf.UnmarshalConf{Path: "import"}); err != nil {
+				log.Fatalf("could not parse import config: %v", err)
+			}
+			imp := importer.New(db, db, importerConfig)
+
+			var result *importer.Result
+			switch resource {
+			case "things":
+				result, err = imp.Things(context.Background(), format, input, dryRun)
+			case "widgets":
+				result, err = imp.Widgets(context.Background(), format, input, dryRun)
+			default:
+				log.Fatalf("unknown resource %s, use things or widgets", resource)
+			}
+			if err != nil {
+				log.Fatalf("import error: %v", err)
+			}
+
+			if reportFile != "" {
+				file, err := os.Create(reportFile)
+				if err != nil {
+					log.Fatalf("could not create report: %v", err)
+				}
+				if err := result.WriteCSV(file); err != nil {
+					log.Fatalf("could not write report: %v", err)
+				}
+				file.Close()
+				result.Report = nil
+			}
+
+			b, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(b))
+
+			if result.Failed > 0 {
+				os.Exit(1)
+			}
+
+		},
+	}
+)
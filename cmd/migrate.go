@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	cli "github.com/spf13/cobra"
+
+	"github.com/snowzach/golib/log"
+	"github.com/snowzach/gorestapi/store/postgres"
+)
+
+func init() {
+	migrateCmd.PersistentFlags().Bool("dry-run", false, "print the SQL of the migrations that would run without running them")
+	migrateCreateCmd.Flags().String("dir", "embed/postgres_migrations", "directory of the migrations")
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateGotoCmd, migrateStatusCmd, migrateForceCmd, migrateCreateCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+var (
+	migrateCmd = &cli.Command{
+		Use:   "migrate",
+		Short: "Manage the database schema",
+		Long:  `Manage the database schema with the embedded migrations. Set database.auto_migrate=false to only migrate with this command.`,
+	}
+
+	migrateUpCmd = &cli.Command{
+		Use:   "up",
+		Short: "Apply all migrations that haven't been applied",
+		Args:  cli.NoArgs,
+		Run: func(cmd *cli.Command, args []string) {
+			runMigrate(cmd, (*postgres.Migrator).PlanUp, (*postgres.Migrator).Up)
+		},
+	}
+
+	migrateDownCmd = &cli.Command{
+		Use:   "down N",
+		Short: "Revert the last N migrations",
+		Args:  cli.ExactArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				log.Fatalf("invalid number of migrations %q", args[0])
+			}
+			runMigrate(cmd,
+				func(m *postgres.Migrator) ([]*postgres.Migration, error) { return m.PlanDown(n) },
+				func(m *postgres.Migrator) error { return m.Down(n) },
+			)
+		},
+	}
+
+	migrateGotoCmd = &cli.Command{
+		Use:   "goto V",
+		Short: "Migrate up or down to version V",
+		Args:  cli.ExactArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+			version, err := strconv.ParseUint(args[0], 10, 0)
+			if err != nil {
+				log.Fatalf("invalid version %q", args[0])
+			}
+			runMigrate(cmd,
+				func(m *postgres.Migrator) ([]*postgres.Migration, error) { return m.PlanGoto(uint(version)) },
+				func(m *postgres.Migrator) error { return m.Goto(uint(version)) },
+			)
+		},
+	}
+
+	migrateStatusCmd = &cli.Command{
+		Use:   "status",
+		Short: "Show the migrations and which have been applied",
+		Args:  cli.NoArgs,
+		Run: func(cmd *cli.Command, args []string) {
+			withMigrator(func(m *postgres.Migrator) error {
+				version, dirty, err := m.Version()
+				if err != nil {
+					return err
+				}
+				migrations, err := m.Status()
+				if err != nil {
+					return err
+				}
+
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+				for _, migration := range migrations {
+					fmt.Fprintf(w, "%d\t%s\t%t\n", migration.Version, migration.Name, migration.Applied)
+				}
+				w.Flush()
+				fmt.Fprintf(cmd.OutOrStdout(), "\nCurrent version: %d", version)
+				if dirty {
+					fmt.Fprint(cmd.OutOrStdout(), " (dirty, fix the schema and use migrate force)")
+				}
+				fmt.Fprintln(cmd.OutOrStdout())
+				return nil
+			})
+		},
+	}
+
+	migrateForceCmd = &cli.Command{
+		Use:   "force V",
+		Short: "Set the version to V without running migrations and clear the dirty flag, -1 for no version",
+		Args:  cli.ExactArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+			version, err := strconv.Atoi(args[0])
+			if err != nil || version < -1 {
+				log.Fatalf("invalid version %q", args[0])
+			}
+			if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+				fmt.Fprintf(cmd.OutOrStdout(), "-- would force version %d\n", version)
+				return
+			}
+			withMigrator(func(m *postgres.Migrator) error {
+				return m.Force(version)
+			})
+		},
+	}
+
+	migrateCreateCmd = &cli.Command{
+		Use:   "create NAME",
+		Short: "Create empty up and down migrations with the next version",
+		Args:  cli.ExactArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+			dir, _ := cmd.Flags().GetString("dir")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			if err := createMigration(cmd.OutOrStdout(), dir, args[0], dryRun); err != nil {
+				log.Fatalf("could not create migration: %v", err)
+			}
+		},
+	}
+)
+
+// withMigrator connects to the database without migrating it and calls fn with a migrator
+func withMigrator(fn func(m *postgres.Migrator) error) {
+
+	postgresConfig, err := newDatabaseConfig()
+	if err != nil {
+		log.Fatalf("database config error: %v", err)
+	}
+	migrationSource := postgresConfig.MigrationSource
+	postgresConfig.MigrationSource = nil
+	db, err := postgres.New(postgresConfig)
+	if err != nil {
+		log.Fatalf("could not create database client: %v", err)
+	}
+
+	postgresConfig.MigrationSource = migrationSource
+	m, err := db.Migrator(postgresConfig)
+	if err != nil {
+		log.Fatalf("could not create migrator: %v", err)
+	}
+	defer m.Close()
+
+	if err := fn(m); err != nil {
+		m.Close()
+		log.Fatalf("migrate error: %v", err)
+	}
+
+}
+
+// runMigrate prints the plan with --dry-run or migrates
+func runMigrate(cmd *cli.Command, plan func(m *postgres.Migrator) ([]*postgres.Migration, error), run func(m *postgres.Migrator) error) {
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	withMigrator(func(m *postgres.Migrator) error {
+		if !dryRun {
+			return run(m)
+		}
+		migrations, err := plan(m)
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "-- no migrations to run")
+		}
+		for _, migration := range migrations {
+			fmt.Fprintf(cmd.OutOrStdout(), "-- %d %s (%s)\n%s\n", migration.Version, migration.Name, migration.Direction, strings.TrimSpace(migration.SQL))
+		}
+		return nil
+	})
+
+}
+
+var (
+	// migrationFileRegexp matches the version of migration files
+	migrationFileRegexp = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+	// migrationNameRegexp matches the characters that are replaced in migration names
+	migrationNameRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// createMigration creates the up and down files of a migration with the version after the last in the directory
+func createMigration(w io.Writer, dir string, name string, dryRun bool) error {
+
+	name = strings.Trim(migrationNameRegexp.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return fmt.Errorf("invalid name")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var last uint64
+	for _, entry := range entries {
+		if match := migrationFileRegexp.FindStringSubmatch(entry.Name()); match != nil {
+			if version, _ := strconv.ParseUint(match[1], 10, 64); version > last {
+				last = version
+			}
+		}
+	}
+
+	for _, direction := range []string{postgres.MigrationUp, postgres.MigrationDown} {
+		filename := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", last+1, name, direction))
+		if !dryRun {
+			if err := os.WriteFile(filename, nil, 0644); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(w, filename)
+	}
+	return nil
+
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	cli "github.com/spf13/cobra"
+
+	"github.com/snowzach/golib/log"
+
+	"github.com/snowzach/gorestapi/gorestapi/archive"
+)
+
+func init() {
+	restoreCmd.Flags().Bool("dry-run", false, "check the archive and count the records without saving")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+var (
+	restoreCmd = &cli.Command{
+		Use:   "restore ARCHIVE",
+		Short: "Restore an archive created with export",
+		Long: `Restore every thing and widget of an archive created with export into the database, creating or replacing
+records by id. Use - to read from stdin.`,
+		Args: cli.ExactArgs(1),
+		Run: func(cmd *cli.Command, args []string) {
+
+			filename := args[0]
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			var input io.Reader = os.Stdin
+			if filename != "-" {
+				file, err := os.Open(filename)
+				if err != nil {
+					log.Fatalf("could not open %s: %v", filename, err)
+				}
+				defer file.Close()
+				input = file
+			}
+
+			// Create the database
+			db, err := newDatabase()
+			if err != nil {
+				log.Fatalf("database config error: %v", err)
+			}
+
+			result, err := archive.Restore(context.Background(), db, db, input, dryRun)
+			if err != nil {
+				log.Fatalf("restore error: %v", err)
+			}
+
+			b, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(b))
+
+		},
+	}
+)
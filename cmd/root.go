@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/http/pprof"
@@ -13,6 +16,8 @@ import (
 	"github.com/snowzach/golib/conf"
 	"github.com/snowzach/golib/log"
 	"github.com/snowzach/golib/version"
+
+	"github.com/snowzach/gorestapi/gorestapi/config"
 )
 
 func init() {
@@ -22,8 +27,10 @@ func init() {
 var (
 
 	// Config and global logger
-	pidFile string
-	cfgFile string
+	pidFile       string
+	cfgFile       string
+	logLevel      = new(slog.LevelVar)
+	metricsServer *http.Server
 
 	// The Root Cli Handler
 	rootCmd = &cli.Command{
@@ -32,11 +39,7 @@ var (
 		PersistentPreRunE: func(cmd *cli.Command, args []string) error {
 
 			// Parse defaults, config file and environment.
-			if err := conf.C.Parse(
-				conf.WithMap(defaults()),
-				conf.WithFile(cfgFile),
-				conf.WithEnv(),
-			); err != nil {
+			if err := conf.C.Parse(configParsers()...); err != nil {
 				fmt.Printf("could not load config: %v", err)
 				os.Exit(1)
 			}
@@ -46,10 +49,32 @@ var (
 				fmt.Printf("could not parse logger config: %v", err)
 				os.Exit(1)
 			}
+			// The level is filtered by logLevel so it can be changed on reload and secrets are redacted from every log
+			if err := setLogLevel(conf.C); err != nil {
+				fmt.Printf("could not configure logger: %v", err)
+				os.Exit(1)
+			}
+			loggerConfig.Level = "debug"
 			if err := log.InitLogger(&loggerConfig); err != nil {
 				fmt.Printf("could not configure logger: %v", err)
 				os.Exit(1)
 			}
+			log.Logger = slog.New(config.NewRedactHandler(config.NewLevelHandler(log.Logger.Handler(), logLevel), config.Secrets))
+			slog.SetDefault(log.Logger)
+
+			// Check the config, unknown keys are only fatal in strict mode
+			strict := conf.C.Bool("config.strict")
+			problems := validateConfig(conf.C)
+			for _, problem := range problems {
+				if problem.Warning && !strict {
+					log.Warn("Config problem", "key", problem.Key, "problem", problem.Message)
+				} else {
+					log.Error("Config problem", "key", problem.Key, "problem", problem.Message)
+				}
+			}
+			if config.Failed(problems, strict) {
+				log.Fatal("Invalid config, see the problems above or run the config check command")
+			}
 
 			// Load the metrics server
 			if conf.C.Bool("metrics.enabled") {
@@ -64,8 +89,9 @@ var (
 					r.HandleFunc("/debug/pprof/trace", pprof.Trace)
 					log.Info("Profiler enabled", "profiler_path", fmt.Sprintf("http://%s/debug/pprof/", hostPort))
 				}
+				metricsServer = &http.Server{Addr: hostPort, Handler: r}
 				go func() {
-					if err := http.ListenAndServe(hostPort, r); err != nil {
+					if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 						log.Errorf("Metrics server error: %v", err)
 					}
 				}()
@@ -88,6 +114,9 @@ var (
 			return nil
 		},
 		PersistentPostRun: func(cmd *cli.Command, args []string) {
+			// Stop the metrics server if the command did not
+			_ = stopMetricsServer(context.Background())
+
 			// Remove Pid file
 			if pidFile != "" {
 				os.Remove(pidFile)
@@ -96,6 +125,64 @@ var (
 	}
 )
 
+// configParsers returns the sources of the config in order of precedence, lowest first, and then resolves secrets
+func configParsers() []conf.ParserFunc {
+	return []conf.ParserFunc{
+		conf.WithMap(defaults()),
+		conf.WithFile(cfgFile),
+		conf.WithEnv(),
+		config.WithSecrets(config.Secrets),
+	}
+}
+
+// loadConfig loads a new config from the same sources as the global config
+func loadConfig() (*conf.Conf, error) {
+	c := conf.New()
+	if err := c.Parse(configParsers()...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadValidConfig loads a new config and fails if it has problems
+func loadValidConfig() (*conf.Conf, error) {
+	c, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	problems := validateConfig(c)
+	if config.Failed(problems, c.Bool("config.strict")) {
+		errs := make([]error, len(problems))
+		for i := range problems {
+			errs[i] = problems[i]
+		}
+		return nil, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+// setLogLevel sets the level of the logger from logger.level
+func setLogLevel(c *conf.Conf) error {
+	level, err := log.ParseLogLevel(c.String("logger.level"))
+	if err != nil {
+		return fmt.Errorf("invalid logger.level %q: %w", c.String("logger.level"), err)
+	}
+	logLevel.Set(level)
+	return nil
+}
+
+// stopMetricsServer stops the metrics server, waiting for in-flight scrapes until ctx is done
+func stopMetricsServer(ctx context.Context) error {
+	if metricsServer == nil {
+		return nil
+	}
+	if err := metricsServer.Shutdown(ctx); err != nil {
+		_ = metricsServer.Close()
+		return err
+	}
+	return nil
+}
+
 // Execute starts the program
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
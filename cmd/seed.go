@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	cli "github.com/spf13/cobra"
+
+	"github.com/snowzach/golib/log"
+
+	"github.com/snowzach/gorestapi/embed"
+	"github.com/snowzach/gorestapi/gorestapi/seed"
+)
+
+func init() {
+	seedCmd.Flags().Bool("demo", false, "load the built-in demo dataset before the files")
+	rootCmd.AddCommand(seedCmd)
+}
+
+var (
+	seedCmd = &cli.Command{
+		Use:   "seed [FILE|DIR...]",
+		Short: "Load fixtures of things and widgets",
+		Long: `Load things and widgets from YAML or JSON fixture files directly into the database. Directories load every
+.yaml, .yml and .json file in them in name order. Use - to read from stdin. Widgets can reference things of the same
+or an earlier file by fixture name. Each file is loaded in a single transaction.`,
+		Run: func(cmd *cli.Command, args []string) {
+
+			demo, _ := cmd.Flags().GetBool("demo")
+			if !demo && len(args) == 0 {
+				log.Fatalf("nothing to seed, pass fixture files or --demo")
+			}
+
+			filenames, err := seedFilenames(args)
+			if err != nil {
+				log.Fatalf("could not list fixtures: %v", err)
+			}
+
+			// Create the database
+			db, err := newDatabase()
+			if err != nil {
+				log.Fatalf("database config error: %v", err)
+			}
+
+			s := seed.New(db, db)
+			total := new(seed.Result)
+			load := func(name string, r io.Reader) {
+				fixtures, err := seed.Parse(r)
+				if err != nil {
+					log.Fatalf("%s: %v", name, err)
+				}
+				result, err := s.Load(context.Background(), fixtures)
+				if err != nil {
+					log.Fatalf("%s: seed error: %v", name, err)
+				}
+				total.Things += result.Things
+				total.Widgets += result.Widgets
+			}
+
+			if demo {
+				file, err := embed.DemoSeedFixtures()
+				if err != nil {
+					log.Fatalf("could not open demo fixtures: %v", err)
+				}
+				load("demo", file)
+				file.Close()
+			}
+
+			for _, filename := range filenames {
+				if filename == "-" {
+					load("stdin", os.Stdin)
+					continue
+				}
+				file, err := os.Open(filename)
+				if err != nil {
+					log.Fatalf("could not open %s: %v", filename, err)
+				}
+				load(filename, file)
+				file.Close()
+			}
+
+			b, _ := json.MarshalIndent(total, "", "  ")
+			fmt.Println(string(b))
+
+		},
+	}
+)
+
+// seedFilenames expands directories to the fixture files in them
+func seedFilenames(args []string) ([]string, error) {
+
+	var filenames []string
+	for _, arg := range args {
+		if arg == "-" {
+			filenames = append(filenames, arg)
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			filenames = append(filenames, arg)
+			continue
+		}
+		entries, err := os.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && seed.IsFixtureFile(entry.Name()) {
+				filenames = append(filenames, filepath.Join(arg, entry.Name()))
+			}
+		}
+	}
+	return filenames, nil
+
+}
@@ -22,3 +22,10 @@ func PublicHTMLFS() fs.FS {
 	publicHTMLfs, _ := fs.Sub(publicHTML, "public_html")
 	return publicHTMLfs
 }
+
+//go:embed seed
+var seedFixtures embed.FS
+
+func DemoSeedFixtures() (fs.File, error) {
+	return seedFixtures.Open("seed/demo.yaml")
+}
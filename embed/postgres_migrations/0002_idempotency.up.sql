@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS idempotency (
+  key TEXT PRIMARY KEY NOT NULL,
+  created timestamp with time zone default NOW(),
+  expires timestamp with time zone NOT NULL,
+  fingerprint TEXT NOT NULL,
+  completed BOOLEAN NOT NULL default false,
+  status_code INTEGER NOT NULL default 0,
+  content_type TEXT NOT NULL default '',
+  body BYTEA
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_expires ON idempotency (expires);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS event (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  created timestamp with time zone default NOW(),
+  type TEXT NOT NULL,
+  resource TEXT NOT NULL,
+  resource_id TEXT NOT NULL,
+  data JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_created ON event (created);
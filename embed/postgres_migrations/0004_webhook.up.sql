@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS webhook (
+  id TEXT PRIMARY KEY NOT NULL,
+  created timestamp with time zone default NOW(),
+  updated timestamp with time zone default NOW(),
+  url TEXT NOT NULL,
+  events JSONB NOT NULL default '[]',
+  secret TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  created timestamp with time zone default NOW(),
+  updated timestamp with time zone default NOW(),
+  webhook_id TEXT NOT NULL,
+  event_id BIGINT NOT NULL,
+  event TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  status TEXT NOT NULL default 'pending',
+  attempts INTEGER NOT NULL default 0,
+  next_attempt timestamp with time zone default NOW(),
+  last_status_code INTEGER NOT NULL default 0,
+  last_error TEXT NOT NULL default ''
+);
+
+ALTER TABLE ONLY webhook_delivery ADD CONSTRAINT fkey_webhook_delivery_webhook_id FOREIGN KEY (webhook_id) REFERENCES public.webhook(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_webhook_id ON webhook_delivery (webhook_id, created);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_pending ON webhook_delivery (next_attempt) WHERE status = 'pending';
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS outbox (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  created timestamp with time zone default NOW(),
+  updated timestamp with time zone default NOW(),
+  event_id BIGINT NOT NULL,
+  event TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  status TEXT NOT NULL default 'pending',
+  attempts INTEGER NOT NULL default 0,
+  next_attempt timestamp with time zone default NOW(),
+  last_error TEXT NOT NULL default ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (next_attempt) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_updated ON outbox (updated) WHERE status = 'delivered';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_webhook_delivery_updated;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_updated ON webhook_delivery (updated) WHERE status IN ('delivered', 'dead');
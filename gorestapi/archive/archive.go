@@ -0,0 +1,296 @@
+// Package archive exports and restores every thing and widget as a versioned tar archive of NDJSON files.
+//
+// The archive starts with manifest.json followed by things/NNNNNN.ndjson and then widgets/NNNNNN.ndjson. Records
+// are split into files of at most the chunk size so neither side holds more than one file in memory.
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+const (
+	// Format identifies gorestapi archives in the manifest
+	Format = "gorestapi"
+	// Version is the version of the archive layout written by Export
+	Version = 1
+	// DefaultChunkSize is the default number of records per file
+	DefaultChunkSize = 10000
+
+	manifestName = "manifest.json"
+	thingsDir    = "things"
+	widgetsDir   = "widgets"
+)
+
+// Manifest describes the archive
+type Manifest struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// Result is the number of records exported or restored
+type Result struct {
+	Things  int64 `json:"things"`
+	Widgets int64 `json:"widgets"`
+}
+
+// Export writes every thing and widget of the store to w as they are read. Widgets are written without their
+// embedded thing. If the transaction store is not nil both are read in one read only transaction so the archive is a
+// consistent snapshot, otherwise widgets may reference things saved after they were exported.
+func Export(ctx context.Context, grStore gorestapi.GRStore, txStore gorestapi.TxStore, w io.Writer, chunkSize int) (*Result, error) {
+
+	if txStore == nil {
+		return export(ctx, grStore, w, chunkSize)
+	}
+	var result *Result
+	err := txStore.WithTransaction(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, func(ctx context.Context) error {
+		var err error
+		result, err = export(ctx, grStore, w, chunkSize)
+		return err
+	})
+	return result, err
+
+}
+
+// export writes the archive with the store calls of ctx
+func export(ctx context.Context, grStore gorestapi.GRStore, w io.Writer, chunkSize int) (*Result, error) {
+
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	created := time.Now().UTC()
+	tw := tar.NewWriter(w)
+	manifest, _ := json.Marshal(&Manifest{Format: Format, Version: Version, Created: created})
+	if err := writeFile(tw, manifestName, created, manifest); err != nil {
+		return nil, err
+	}
+
+	result := new(Result)
+	things := newChunkWriter(tw, thingsDir, created, chunkSize)
+	if err := grStore.ThingsStream(ctx, sortByID("thing.id"), func(thing *gorestapi.Thing) error {
+		result.Things++
+		return things.write(thing)
+	}); err != nil {
+		return nil, fmt.Errorf("could not export things: %w", err)
+	}
+	if err := things.flush(); err != nil {
+		return nil, err
+	}
+
+	widgets := newChunkWriter(tw, widgetsDir, created, chunkSize)
+	if err := grStore.WidgetsStream(ctx, sortByID("widget.id"), func(widget *gorestapi.Widget) error {
+		widget.Thing = nil
+		result.Widgets++
+		return widgets.write(widget)
+	}); err != nil {
+		return nil, fmt.Errorf("could not export widgets: %w", err)
+	}
+	if err := widgets.flush(); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return result, nil
+
+}
+
+// sortByID sorts by the id field so exports are stable
+func sortByID(field string) *queryp.QueryParameters {
+	return &queryp.QueryParameters{Sort: queryp.Sort{&queryp.SortTerm{Field: field}}}
+}
+
+// chunkWriter writes records as numbered NDJSON files of at most size records
+type chunkWriter struct {
+	tw      *tar.Writer
+	dir     string
+	modTime time.Time
+	size    int
+	count   int
+	files   int
+	buf     bytes.Buffer
+}
+
+func newChunkWriter(tw *tar.Writer, dir string, modTime time.Time, size int) *chunkWriter {
+	return &chunkWriter{tw: tw, dir: dir, modTime: modTime, size: size}
+}
+
+func (c *chunkWriter) write(record any) error {
+	if err := json.NewEncoder(&c.buf).Encode(record); err != nil {
+		return err
+	}
+	if c.count++; c.count >= c.size {
+		return c.flush()
+	}
+	return nil
+}
+
+func (c *chunkWriter) flush() error {
+	if c.count == 0 {
+		return nil
+	}
+	c.files++
+	if err := writeFile(c.tw, fmt.Sprintf("%s/%06d.ndjson", c.dir, c.files), c.modTime, c.buf.Bytes()); err != nil {
+		return err
+	}
+	c.buf.Reset()
+	c.count = 0
+	return nil
+}
+
+func writeFile(tw *tar.Writer, name string, modTime time.Time, b []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(b)),
+		ModTime:  modTime,
+	}); err != nil {
+		return fmt.Errorf("could not write %s: %w", name, err)
+	}
+	if _, err := tw.Write(b); err != nil {
+		return fmt.Errorf("could not write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Restore reads an archive from r, gzip compressed or not, and creates or replaces the records in the store. If the
+// restore store is not nil records are saved in batches with their timestamps, otherwise they are saved one at a
+// time with the store and get new timestamps. With dryRun the archive is only read and counted.
+func Restore(ctx context.Context, grStore gorestapi.GRStore, restoreStore gorestapi.RestoreStore, r io.Reader, dryRun bool) (*Result, error) {
+
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not read archive: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	} else {
+		r = br
+	}
+	tr := tar.NewReader(r)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != manifestName {
+		return nil, errors.New("not an archive, missing manifest")
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("could not read manifest: %w", err)
+	}
+	if manifest.Format != Format {
+		return nil, fmt.Errorf("unknown archive format %q", manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > Version {
+		return nil, fmt.Errorf("unsupported archive version %d, up to %d is supported", manifest.Version, Version)
+	}
+
+	result := new(Result)
+	widgetsStarted := false
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("could not read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch dir, _ := path.Split(header.Name); strings.TrimSuffix(dir, "/") {
+		case thingsDir:
+			// Widgets reference things so every thing must come first
+			if widgetsStarted {
+				return nil, fmt.Errorf("%s: things after widgets", header.Name)
+			}
+			err = restoreFile(tr, func(things []*gorestapi.Thing) error {
+				result.Things += int64(len(things))
+				if dryRun {
+					return nil
+				}
+				if restoreStore != nil {
+					return restoreStore.ThingsRestore(ctx, things)
+				}
+				for _, thing := range things {
+					if err := grStore.ThingSave(ctx, thing); err != nil {
+						return fmt.Errorf("thing %s: %w", thing.ID, err)
+					}
+				}
+				return nil
+			})
+		case widgetsDir:
+			widgetsStarted = true
+			err = restoreFile(tr, func(widgets []*gorestapi.Widget) error {
+				result.Widgets += int64(len(widgets))
+				if dryRun {
+					return nil
+				}
+				if restoreStore != nil {
+					return restoreStore.WidgetsRestore(ctx, widgets)
+				}
+				for _, widget := range widgets {
+					if err := grStore.WidgetSave(ctx, widget); err != nil {
+						return fmt.Errorf("widget %s: %w", widget.ID, err)
+					}
+				}
+				return nil
+			})
+		default:
+			return nil, fmt.Errorf("%s: unknown file", header.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", header.Name, err)
+		}
+	}
+
+	return result, nil
+
+}
+
+// restoreFile decodes the NDJSON records of a file and saves them in batches of at most DefaultChunkSize
+func restoreFile[T any](r io.Reader, save func([]*T) error) error {
+
+	decoder := json.NewDecoder(r)
+	var batch []*T
+	for line := 1; ; line++ {
+		record := new(T)
+		if err := decoder.Decode(record); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("record %d: %w", line, err)
+		}
+		batch = append(batch, record)
+		if len(batch) >= DefaultChunkSize {
+			if err := save(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		return save(batch)
+	}
+	return nil
+
+}
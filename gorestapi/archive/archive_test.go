@@ -0,0 +1,140 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/snowzach/queryp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+// txKey marks the context of the test transaction
+type txKey struct{}
+
+func TestExportRestore(t *testing.T) {
+
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	thingID := "t1"
+	things := []*gorestapi.Thing{
+		{ID: "t1", Created: created, Updated: updated, Name: "one"},
+		{ID: "t2", Created: created, Updated: updated, Name: "two"},
+		{ID: "t3", Created: created, Updated: updated, Name: "three"},
+	}
+	widgets := []*gorestapi.Widget{
+		{ID: "w1", Created: created, Updated: updated, Name: "a", ThingID: &thingID, Thing: things[0]},
+		{ID: "w2", Created: created, Updated: updated, Name: "b"},
+	}
+
+	// Both streams read the same snapshot
+	txs := new(mocks.TxStore)
+	txs.On("WithTransaction", mock.Anything, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, mock.Anything).Once().Return(func(ctx context.Context, opts *sql.TxOptions, fn func(context.Context) error) error {
+		return fn(context.WithValue(ctx, txKey{}, true))
+	})
+	inTx := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(txKey{}) != nil })
+
+	grs := new(mocks.GRStore)
+	grs.On("ThingsStream", inTx, mock.Anything, mock.Anything).Once().Return(func(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Thing) error) error {
+		assert.Equal(t, "thing.id", qp.Sort[0].Field)
+		for _, thing := range things {
+			record := *thing
+			if err := fn(&record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	grs.On("WidgetsStream", inTx, mock.Anything, mock.Anything).Once().Return(func(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Widget) error) error {
+		for _, widget := range widgets {
+			record := *widget
+			if err := fn(&record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	var b bytes.Buffer
+	result, err := Export(context.Background(), grs, txs, &b, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{Things: 3, Widgets: 2}, result)
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(b.Bytes()))
+	for header, err := tr.Next(); err == nil; header, err = tr.Next() {
+		names = append(names, header.Name)
+	}
+	assert.Equal(t, []string{"manifest.json", "things/000001.ndjson", "things/000002.ndjson", "widgets/000001.ndjson"}, names)
+
+	// Restore with timestamps, gzipped
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(b.Bytes())
+	gw.Close()
+
+	rs := new(mocks.RestoreStore)
+	rs.On("ThingsRestore", mock.Anything, things[:2]).Once().Return(nil)
+	rs.On("ThingsRestore", mock.Anything, things[2:]).Once().Return(nil)
+	rs.On("WidgetsRestore", mock.Anything, []*gorestapi.Widget{
+		{ID: "w1", Created: created, Updated: updated, Name: "a", ThingID: &thingID},
+		{ID: "w2", Created: created, Updated: updated, Name: "b"},
+	}).Once().Return(nil)
+
+	result, err = Restore(context.Background(), nil, rs, &gz, false)
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{Things: 3, Widgets: 2}, result)
+	rs.AssertExpectations(t)
+
+	// Restore through the store
+	grs.On("ThingSave", mock.Anything, mock.Anything).Times(3).Return(nil)
+	grs.On("WidgetSave", mock.Anything, mock.Anything).Times(2).Return(nil)
+	result, err = Restore(context.Background(), grs, nil, bytes.NewReader(b.Bytes()), false)
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{Things: 3, Widgets: 2}, result)
+	grs.AssertExpectations(t)
+	txs.AssertExpectations(t)
+
+	// A dry run only counts
+	result, err = Restore(context.Background(), nil, nil, bytes.NewReader(b.Bytes()), true)
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{Things: 3, Widgets: 2}, result)
+
+}
+
+func TestRestoreInvalid(t *testing.T) {
+
+	archive := func(files ...string) io.Reader {
+		var b bytes.Buffer
+		tw := tar.NewWriter(&b)
+		for i := 0; i < len(files); i += 2 {
+			assert.Nil(t, writeFile(tw, files[i], time.Now(), []byte(files[i+1])))
+		}
+		tw.Close()
+		return &b
+	}
+	manifest := `{"format":"gorestapi","version":1}`
+
+	_, err := Restore(context.Background(), nil, nil, bytes.NewBufferString("not an archive"), true)
+	assert.EqualError(t, err, "not an archive, missing manifest")
+	_, err = Restore(context.Background(), nil, nil, archive("manifest.json", `{"format":"other","version":1}`), true)
+	assert.EqualError(t, err, `unknown archive format "other"`)
+	_, err = Restore(context.Background(), nil, nil, archive("manifest.json", `{"format":"gorestapi","version":2}`), true)
+	assert.EqualError(t, err, "unsupported archive version 2, up to 1 is supported")
+	_, err = Restore(context.Background(), nil, nil, archive("manifest.json", manifest, "widgets/000001.ndjson", `{"id":"w1"}`, "things/000001.ndjson", `{"id":"t1"}`), true)
+	assert.EqualError(t, err, "things/000001.ndjson: things after widgets")
+	_, err = Restore(context.Background(), nil, nil, archive("manifest.json", manifest, "other/000001.ndjson", `{}`), true)
+	assert.EqualError(t, err, "other/000001.ndjson: unknown file")
+	_, err = Restore(context.Background(), nil, nil, archive("manifest.json", manifest, "things/000001.ndjson", "{\"id\":\"t1\"}\n{"), true)
+	assert.EqualError(t, err, "things/000001.ndjson: record 2: unexpected EOF")
+
+}
@@ -0,0 +1,370 @@
+// Package client calls the REST API of a gorestapi server. Client implements gorestapi.GRStore so it can be used
+// anywhere a store can.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/snowzach/golib/httpserver/render"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// Config configures the client
+type Config struct {
+	// URL of the server, the API is under /api
+	URL string `conf:"url"`
+	// Token is sent as a bearer token
+	Token string `conf:"token"`
+	// Username and Password are sent with basic auth if there is no token
+	Username string `conf:"username"`
+	Password string `conf:"password"`
+	// Timeout of each request, streams are only limited by their context
+	Timeout time.Duration `conf:"timeout"`
+	// Retries of requests that failed to connect or were rejected because the server is busy or unavailable
+	Retries int `conf:"retries"`
+	// Delay before the first retry, doubling on each retry up to BackoffMax
+	BackoffInitial time.Duration `conf:"backoff_initial"`
+	BackoffMax     time.Duration `conf:"backoff_max"`
+}
+
+// Error is an error response from the server that isn't a store error
+type Error struct {
+	StatusCode int
+	render.ErrResponse
+}
+
+func (e *Error) Error() string {
+	message := e.ErrResponse.Error
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	if e.ErrorID != "" {
+		return fmt.Sprintf("%d %s (error_id: %s)", e.StatusCode, message, e.ErrorID)
+	}
+	return fmt.Sprintf("%d %s", e.StatusCode, message)
+}
+
+// Results is a page of results of a find
+type Results[T any] struct {
+	Count   *int64 `json:"count,omitempty"`
+	Results []T    `json:"results"`
+}
+
+// Client calls the API of a server
+type Client struct {
+	config  Config
+	baseURL string
+	client  *http.Client
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// New creates a new client
+func New(config Config) (*Client, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid url %q, must be http or https", config.URL)
+	}
+	if config.BackoffInitial <= 0 {
+		config.BackoffInitial = 100 * time.Millisecond
+	}
+	if config.BackoffMax < config.BackoffInitial {
+		config.BackoffMax = config.BackoffInitial
+	}
+	return &Client{
+		config:  config,
+		baseURL: strings.TrimSuffix(u.String(), "/") + "/api",
+		client:  &http.Client{},
+		sleep:   sleep,
+	}, nil
+}
+
+// ThingGetByID returns the thing
+func (c *Client) ThingGetByID(ctx context.Context, id string) (*gorestapi.Thing, error) {
+	thing := new(gorestapi.Thing)
+	if err := c.do(ctx, http.MethodGet, "/things/"+url.PathEscape(id), nil, nil, thing); err != nil {
+		return nil, err
+	}
+	return thing, nil
+}
+
+// ThingSave creates or replaces the thing and updates it with the saved thing
+func (c *Client) ThingSave(ctx context.Context, thing *gorestapi.Thing) error {
+	return c.do(ctx, http.MethodPost, "/things", nil, thing, thing)
+}
+
+// ThingDeleteByID deletes the thing
+func (c *Client) ThingDeleteByID(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/things/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ThingsFind returns the things matching the query parameters and the count if known
+func (c *Client) ThingsFind(ctx context.Context, qp *queryp.QueryParameters) ([]*gorestapi.Thing, *int64, error) {
+	var results Results[*gorestapi.Thing]
+	if err := c.do(ctx, http.MethodGet, "/things", qp, nil, &results); err != nil {
+		return nil, nil, err
+	}
+	return results.Results, results.Count, nil
+}
+
+// ThingsStream calls fn for every thing matching the query parameters as it is read from the server
+func (c *Client) ThingsStream(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Thing) error) error {
+	return stream(ctx, c, "/things", qp, fn)
+}
+
+// WidgetGetByID returns the widget
+func (c *Client) WidgetGetByID(ctx context.Context, id string) (*gorestapi.Widget, error) {
+	widget := new(gorestapi.Widget)
+	if err := c.do(ctx, http.MethodGet, "/widgets/"+url.PathEscape(id), nil, nil, widget); err != nil {
+		return nil, err
+	}
+	return widget, nil
+}
+
+// WidgetSave creates or replaces the widget and updates it with the saved widget
+func (c *Client) WidgetSave(ctx context.Context, widget *gorestapi.Widget) error {
+	return c.do(ctx, http.MethodPost, "/widgets", nil, widget, widget)
+}
+
+// WidgetDeleteByID deletes the widget
+func (c *Client) WidgetDeleteByID(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/widgets/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// WidgetsFind returns the widgets matching the query parameters and the count if known
+func (c *Client) WidgetsFind(ctx context.Context, qp *queryp.QueryParameters) ([]*gorestapi.Widget, *int64, error) {
+	var results Results[*gorestapi.Widget]
+	if err := c.do(ctx, http.MethodGet, "/widgets", qp, nil, &results); err != nil {
+		return nil, nil, err
+	}
+	return results.Results, results.Count, nil
+}
+
+// WidgetsStream calls fn for every widget matching the query parameters as it is read from the server
+func (c *Client) WidgetsStream(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Widget) error) error {
+	return stream(ctx, c, "/widgets", qp, fn)
+}
+
+// do sends the request with the body as JSON and decodes the JSON response into out if it is not nil
+func (c *Client) do(ctx context.Context, method string, path string, qp *queryp.QueryParameters, in any, out any) error {
+
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+
+	resp, err := c.send(ctx, method, path, qp, in, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("could not decode response: %w", err)
+	}
+	return nil
+
+}
+
+// stream reads the records of the export of the path as NDJSON
+func stream[T any](ctx context.Context, c *Client, path string, qp *queryp.QueryParameters, fn func(*T) error) error {
+
+	resp, err := c.send(ctx, http.MethodGet, path, qp, nil, "application/x-ndjson")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		record := new(T)
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return fmt.Errorf("could not decode response: %w", err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+
+}
+
+// send sends the request, retrying while it can, and returns the response if it succeeded or the error
+func (c *Client) send(ctx context.Context, method string, path string, qp *queryp.QueryParameters, in any, accept string) (*http.Response, error) {
+
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, fmt.Errorf("could not encode request: %w", err)
+		}
+	}
+
+	u := c.baseURL + path
+	if qp != nil {
+		// The server unescapes the whole query before parsing it
+		if query := EncodeQuery(qp); query != "" {
+			u += "?" + url.PathEscape(query)
+		}
+	}
+
+	// Write requests are retried with the same key so they are only applied once
+	var idempotencyKey string
+	if method != http.MethodGet && c.config.Retries > 0 {
+		idempotencyKey = xid.New().String()
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", accept)
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		if c.config.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.config.Token)
+		} else if c.config.Username != "" || c.config.Password != "" {
+			req.SetBasicAuth(c.config.Username, c.config.Password)
+		}
+
+		resp, err := c.client.Do(req)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		var delay time.Duration
+		if err == nil {
+			if !retryable(resp.StatusCode, idempotencyKey != "") || attempt >= c.config.Retries {
+				defer resp.Body.Close()
+				return nil, decodeError(resp)
+			}
+			delay = retryAfter(resp)
+			resp.Body.Close()
+		} else if ctx.Err() != nil || attempt >= c.config.Retries {
+			return nil, err
+		}
+
+		if backoff := c.backoff(attempt + 1); delay < backoff {
+			delay = backoff
+		} else if delay > c.config.BackoffMax {
+			delay = c.config.BackoffMax
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+
+	}
+
+}
+
+// retryable returns if a request that failed with the status should be retried
+func retryable(status int, idempotent bool) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		// Another attempt with the same key is still in progress
+		return idempotent
+	}
+	return false
+}
+
+// retryAfter returns the delay of the Retry-After header in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// backoff returns the delay before the retry
+func (c *Client) backoff(retry int) time.Duration {
+	delay := c.config.BackoffInitial
+	for i := 1; i < retry && delay < c.config.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > c.config.BackoffMax {
+		delay = c.config.BackoffMax
+	}
+	return delay
+}
+
+// sleep waits for the duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// storeErrorPrefixes are the prefixes store.Error.ErrorForOp adds to the message by type
+var storeErrorPrefixes = []struct {
+	prefix    string
+	errorType store.ErrorType
+}{
+	{"missing data: ", store.ErrorTypeIncomplete},
+	{"foreign key: ", store.ErrorTypeForeignKey},
+	{"duplicate: ", store.ErrorTypeDuplicate},
+	{"invalid data: ", store.ErrorTypeInvalid},
+}
+
+// decodeError returns store.ErrNotFound for not found responses, a *store.Error for invalid requests and an *Error
+// for anything else so callers can handle errors the same as from any other store
+func decodeError(resp *http.Response) error {
+
+	var errResponse render.ErrResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResponse); err != nil && !errors.Is(err, io.EOF) {
+		errResponse.Error = http.StatusText(resp.StatusCode)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return store.ErrNotFound
+	case http.StatusBadRequest:
+		for _, p := range storeErrorPrefixes {
+			if message, ok := strings.CutPrefix(errResponse.Error, p.prefix); ok {
+				return &store.Error{Type: p.errorType, Err: errors.New(message)}
+			}
+		}
+		// Query errors are passed through as they are
+		return &store.Error{Type: store.ErrorTypeQuery, Err: errors.New(errResponse.Error)}
+	}
+	return &Error{StatusCode: resp.StatusCode, ErrResponse: errResponse}
+
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/mainrpc"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+var _ gorestapi.GRStore = (*Client)(nil)
+
+// newTestClient returns a client of a server with a mock store
+func newTestClient(t *testing.T, config Config) (*Client, *mocks.GRStore) {
+
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	grs := new(mocks.GRStore)
+	assert.Nil(t, mainrpc.Setup(r, grs))
+
+	config.URL = server.URL
+	c, err := New(config)
+	assert.Nil(t, err)
+	return c, grs
+
+}
+
+func TestClient(t *testing.T) {
+
+	c, grs := newTestClient(t, Config{})
+	ctx := context.Background()
+
+	thing := &gorestapi.Thing{ID: "id", Name: "name"}
+	grs.On("ThingGetByID", mock.Anything, "id").Once().Return(thing, nil)
+	got, err := c.ThingGetByID(ctx, "id")
+	assert.Nil(t, err)
+	assert.Equal(t, thing, got)
+
+	grs.On("ThingSave", mock.Anything, &gorestapi.Thing{Name: "new"}).Once().Run(func(args mock.Arguments) {
+		args.Get(1).(*gorestapi.Thing).ID = "generated"
+	}).Return(nil)
+	save := &gorestapi.Thing{Name: "new"}
+	assert.Nil(t, c.ThingSave(ctx, save))
+	assert.Equal(t, "generated", save.ID)
+
+	grs.On("ThingDeleteByID", mock.Anything, "id").Once().Return(nil)
+	assert.Nil(t, c.ThingDeleteByID(ctx, "id"))
+
+	count := int64(3)
+	grs.On("WidgetsFind", mock.Anything, mock.MatchedBy(func(qp *queryp.QueryParameters) bool {
+		return qp.String() == "widget.name=~a%&limit=2&sort=-widget.name"
+	})).Once().Return([]*gorestapi.Widget{{ID: "w1"}, {ID: "w2"}}, &count, nil)
+	qp, err := queryp.ParseQuery("widget.name=~a%&limit=2&sort=-widget.name")
+	assert.Nil(t, err)
+	widgets, total, err := c.WidgetsFind(ctx, qp)
+	assert.Nil(t, err)
+	assert.Equal(t, []*gorestapi.Widget{{ID: "w1"}, {ID: "w2"}}, widgets)
+	assert.Equal(t, &count, total)
+
+	grs.On("WidgetGetByID", mock.Anything, "missing").Once().Return(nil, store.ErrNotFound)
+	_, err = c.WidgetGetByID(ctx, "missing")
+	assert.Equal(t, store.ErrNotFound, err)
+
+	grs.On("WidgetSave", mock.Anything, mock.Anything).Once().Return(&store.Error{Type: store.ErrorTypeForeignKey, Err: errors.New("thing missing")})
+	err = c.WidgetSave(ctx, &gorestapi.Widget{Name: "a"})
+	assert.Equal(t, &store.Error{Type: store.ErrorTypeForeignKey, Err: errors.New("thing missing")}, err)
+
+	grs.On("ThingGetByID", mock.Anything, "broken").Once().Return(nil, errors.New("broken"))
+	_, err = c.ThingGetByID(ctx, "broken")
+	var apiErr *Error
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+
+	grs.On("WidgetsStream", mock.Anything, mock.MatchedBy(func(qp *queryp.QueryParameters) bool {
+		return qp.String() == "widget.name=a"
+	}), mock.Anything).Once().Return(func(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Widget) error) error {
+		for _, id := range []string{"w1", "w2", "w3"} {
+			if err := fn(&gorestapi.Widget{ID: id}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var streamed []string
+	err = c.WidgetsStream(ctx, NewQuery().Where("widget.name", queryp.FilterOpEquals, "a").Params(), func(widget *gorestapi.Widget) error {
+		streamed = append(streamed, widget.ID)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"w1", "w2", "w3"}, streamed)
+
+	grs.AssertExpectations(t)
+
+}
+
+func TestClientAuth(t *testing.T) {
+
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"status":"unauthorized","error":"bad token"}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{URL: server.URL, Token: "token"})
+	assert.Nil(t, err)
+	_, err = c.ThingGetByID(context.Background(), "id")
+	assert.EqualError(t, err, "401 bad token")
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+
+	c, err = New(Config{URL: server.URL, Username: "user", Password: "pass"})
+	assert.Nil(t, err)
+	_ = c.ThingDeleteByID(context.Background(), "id")
+	assert.Equal(t, "Basic dXNlcjpwYXNz", header.Get("Authorization"))
+
+	_, err = New(Config{URL: "localhost:8080"})
+	assert.NotNil(t, err)
+
+}
+
+func TestClientRetry(t *testing.T) {
+
+	var attempts int
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		switch attempts {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write([]byte(`{"id":"id","name":"name"}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{URL: server.URL, Retries: 2, BackoffInitial: time.Second, BackoffMax: 5 * time.Second})
+	assert.Nil(t, err)
+	var delays []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	thing := &gorestapi.Thing{Name: "name"}
+	assert.Nil(t, c.ThingSave(context.Background(), thing))
+	assert.Equal(t, "id", thing.ID)
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second}, delays, "Retry-After and then the backoff")
+	assert.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[2], "retries use the same idempotency key")
+
+	// Out of retries
+	attempts = 0
+	c.config.Retries = 1
+	_, err = c.ThingGetByID(context.Background(), "id")
+	assert.EqualError(t, err, "429 Too Many Requests")
+
+}
+
+func TestQuery(t *testing.T) {
+
+	q := NewQuery().
+		Where("thing.name", queryp.FilterOpLike, "a%").
+		OrGroup(func(sub *Query) {
+			sub.Where("thing.description", queryp.FilterOpEquals, "x&y|(z)").
+				Where("thing.id", queryp.FilterOpEquals, []string{"t1", "t,2"}).
+				Where("thing.description", queryp.FilterOpNotEquals, nil)
+		}).
+		Where("thing.created", queryp.FilterOpGreaterThan, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)).
+		Sort("thing.name", true).
+		Sort("thing.id", false).
+		Limit(10).
+		Offset(20)
+
+	encoded := q.String()
+	assert.Equal(t, `thing.name=~a%|(thing.description="x&y|(z)"&thing.id=(t1,"t,2")&thing.description!=null)&thing.created>2023-01-02T03:04:05Z&limit=10&offset=20&sort=-thing.name,thing.id`, encoded)
+
+	parsed, err := queryp.ParseQuery(encoded)
+	assert.Nil(t, err)
+	assert.Equal(t, encoded, EncodeQuery(parsed), "the encoded query parses back the same")
+	assert.Equal(t, "x&y|(z)", parsed.Filter[1].SubFilter[0].Value)
+	assert.Equal(t, []any{"t1", "t,2"}, parsed.Filter[1].SubFilter[1].Value)
+	assert.Nil(t, parsed.Filter[1].SubFilter[2].Value)
+
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snowzach/queryp"
+)
+
+// Query builds query parameters for the find and stream methods. Filters are joined with AND unless added with an
+// Or method, ie. NewQuery().Where("thing.name", queryp.FilterOpLike, "a%").OrWhere("thing.name", queryp.FilterOpEquals, "b").
+type Query struct {
+	qp queryp.QueryParameters
+}
+
+// NewQuery creates an empty query
+func NewQuery() *Query {
+	return &Query{qp: queryp.QueryParameters{Options: make(queryp.Options)}}
+}
+
+// Where adds a filter joined with AND. A slice value matches any of its values.
+func (q *Query) Where(field string, op queryp.FilterOp, value any) *Query {
+	(*queryp.Filter)(&q.qp.Filter).Append(queryp.FilterLogicAnd, field, op, value)
+	return q
+}
+
+// OrWhere adds a filter joined with OR
+func (q *Query) OrWhere(field string, op queryp.FilterOp, value any) *Query {
+	(*queryp.Filter)(&q.qp.Filter).Append(queryp.FilterLogicOr, field, op, value)
+	return q
+}
+
+// Group adds the filters of the sub query in parenthesis joined with AND
+func (q *Query) Group(fn func(sub *Query)) *Query {
+	return q.group(queryp.FilterLogicAnd, fn)
+}
+
+// OrGroup adds the filters of the sub query in parenthesis joined with OR
+func (q *Query) OrGroup(fn func(sub *Query)) *Query {
+	return q.group(queryp.FilterLogicOr, fn)
+}
+
+func (q *Query) group(logic queryp.FilterLogic, fn func(sub *Query)) *Query {
+	sub := NewQuery()
+	fn(sub)
+	if len(sub.qp.Filter) > 0 {
+		(*queryp.Filter)(&q.qp.Filter).SubFilter(logic, (*queryp.Filter)(&sub.qp.Filter))
+	}
+	return q
+}
+
+// Sort adds a field to sort by
+func (q *Query) Sort(field string, desc bool) *Query {
+	q.qp.Sort.Append(field, desc)
+	return q
+}
+
+// Limit sets the maximum number of results
+func (q *Query) Limit(limit int64) *Query {
+	q.qp.Limit = limit
+	return q
+}
+
+// Offset sets the number of results to skip
+func (q *Query) Offset(offset int64) *Query {
+	q.qp.Offset = offset
+	return q
+}
+
+// Option sets an option
+func (q *Query) Option(option string, value string) *Query {
+	q.qp.Options.Set(option, value)
+	return q
+}
+
+// Params returns the query parameters
+func (q *Query) Params() *queryp.QueryParameters {
+	return &q.qp
+}
+
+// String returns the query in the syntax of the API
+func (q *Query) String() string {
+	return EncodeQuery(&q.qp)
+}
+
+// EncodeQuery returns the query parameters in the syntax of the API, quoting values so they parse back the same
+func EncodeQuery(qp *queryp.QueryParameters) string {
+
+	var parts []string
+	if len(qp.Filter) > 0 {
+		var sb strings.Builder
+		encodeFilter(&sb, qp.Filter)
+		parts = append(parts, sb.String())
+	}
+	if qp.Limit > 0 {
+		parts = append(parts, "limit="+strconv.FormatInt(qp.Limit, 10))
+	}
+	if qp.Offset > 0 {
+		parts = append(parts, "offset="+strconv.FormatInt(qp.Offset, 10))
+	}
+	if options := qp.Options.String(); options != "" {
+		parts = append(parts, options)
+	}
+	if len(qp.Sort) > 0 {
+		terms := make([]string, 0, len(qp.Sort))
+		for _, term := range qp.Sort {
+			terms = append(terms, term.String())
+		}
+		parts = append(parts, "sort="+strings.Join(terms, ","))
+	}
+	return strings.Join(parts, "&")
+
+}
+
+func encodeFilter(sb *strings.Builder, filter queryp.Filter) {
+	for i, term := range filter {
+		if i > 0 {
+			sb.WriteString(term.Logic.String())
+		}
+		if len(term.SubFilter) > 0 {
+			sb.WriteString("(")
+			encodeFilter(sb, term.SubFilter)
+			sb.WriteString(")")
+			continue
+		}
+		sb.WriteString(queryp.SafeField(term.Field))
+		sb.WriteString(term.Op.String())
+		encodeValue(sb, term.Value)
+	}
+}
+
+func encodeValue(sb *strings.Builder, value any) {
+	switch v := value.(type) {
+	case nil:
+		sb.WriteString(queryp.NullValue)
+	case []any:
+		sb.WriteString("(")
+		for i, item := range v {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			encodeValue(sb, item)
+		}
+		sb.WriteString(")")
+	case []string:
+		items := make([]any, len(v))
+		for i := range v {
+			items[i] = v[i]
+		}
+		encodeValue(sb, items)
+	case time.Time:
+		sb.WriteString(v.Format(time.RFC3339Nano))
+	case string:
+		// Quote anything the parser would read as syntax or null
+		if v == "" || v == queryp.NullValue || v[0] == '(' || v[0] == '"' || strings.ContainsAny(v, queryp.ValueNeedsQuote+",") {
+			sb.WriteString(`"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`)
+		} else {
+			sb.WriteString(v)
+		}
+	default:
+		encodeValue(sb, queryp.ValueString(v))
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// Middleware is a middleware that can be replaced while serving requests
+type Middleware struct {
+	current atomic.Pointer[func(http.Handler) http.Handler]
+}
+
+// NewMiddleware creates a Middleware of mw, nil for none
+func NewMiddleware(mw func(http.Handler) http.Handler) *Middleware {
+	m := new(Middleware)
+	m.Set(mw)
+	return m
+}
+
+// Set replaces the middleware for the following requests, nil for none
+func (m *Middleware) Set(mw func(http.Handler) http.Handler) {
+	m.current.Store(&mw)
+}
+
+// Handler is the middleware to add to the router
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mw := *m.current.Load(); mw != nil {
+			mw(next).ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LevelHandler is a slog.Handler that drops records below a level that can be changed while running. The handler
+// it wraps should accept every level.
+type LevelHandler struct {
+	level   *slog.LevelVar
+	handler slog.Handler
+}
+
+// NewLevelHandler creates a LevelHandler of handler filtered by level
+func NewLevelHandler(handler slog.Handler, level *slog.LevelVar) *LevelHandler {
+	return &LevelHandler{level: level, handler: handler}
+}
+
+// Enabled returns true if the level is enabled by both handlers
+func (h *LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+// Handle handles the record with the wrapped handler
+func (h *LevelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a LevelHandler of the wrapped handler with attrs
+func (h *LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewLevelHandler(h.handler.WithAttrs(attrs), h.level)
+}
+
+// WithGroup returns a LevelHandler of the wrapped handler with the group
+func (h *LevelHandler) WithGroup(name string) slog.Handler {
+	return NewLevelHandler(h.handler.WithGroup(name), h.level)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+
+	header := func(value string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Test", value)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	m := NewMiddleware(header("a"))
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	get := func() string {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Header().Get("X-Test")
+	}
+
+	assert.Equal(t, "a", get())
+	m.Set(header("b"))
+	assert.Equal(t, "b", get())
+	m.Set(nil)
+	assert.Equal(t, "", get())
+
+}
+
+func TestLevelHandler(t *testing.T) {
+
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	logger := slog.New(NewLevelHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), level)).With("context", "test")
+
+	logger.Debug("hidden")
+	assert.Empty(t, buf.String())
+
+	level.Set(slog.LevelDebug)
+	logger.Debug("shown")
+	assert.Contains(t, buf.String(), "msg=shown context=test")
+
+}
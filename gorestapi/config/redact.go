@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+)
+
+// Redacted replaces the value of secrets
+const Redacted = "[REDACTED]"
+
+// secretNames are the parts of key names that hold secrets
+var secretNames = []string{"password", "token", "secret", "authorization", "api_key", "apikey"}
+
+// IsSecret returns true if the key holds a secret, ie. database.password or client.token
+func IsSecret(key string) bool {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, secret := range secretNames {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of the flattened config with the values of secrets replaced, by key name and by the values
+// in Secrets. Empty secrets are kept so it is clear they are not set.
+func Redact(values map[string]any) map[string]any {
+	redacted := make(map[string]any, len(values))
+	for key, value := range values {
+		if IsSecret(key) && value != nil && value != "" {
+			value = Redacted
+		} else if s, ok := value.(string); ok {
+			value = Secrets.Redact(s)
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
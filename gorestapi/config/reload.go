@@ -0,0 +1,187 @@
+// Package config reloads the configuration of a running server and redacts secrets from it.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snowzach/golib/conf"
+
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// ApplyFunc applies the config of the keys it was registered for
+type ApplyFunc func(c *conf.Conf) error
+
+type handler struct {
+	prefixes []string
+	apply    ApplyFunc
+}
+
+// Reloader loads the config again and applies the changes that can be made without a restart
+type Reloader struct {
+	logger   *slog.Logger
+	load     func() (*conf.Conf, error)
+	mu       sync.Mutex
+	current  *conf.Conf
+	handlers []handler
+}
+
+// NewReloader creates a Reloader of the current config that loads the new config with load
+func NewReloader(logger *slog.Logger, current *conf.Conf, load func() (*conf.Conf, error)) *Reloader {
+	return &Reloader{
+		logger:  logger,
+		load:    load,
+		current: current,
+	}
+}
+
+// Handle registers apply to be called with the new config when a key matching any of the prefixes changes. A
+// prefix matches the key itself and every key below it, ie. server.cors matches server.cors.allowed_origins.
+func (r *Reloader) Handle(apply ApplyFunc, prefixes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, handler{prefixes: prefixes, apply: apply})
+}
+
+// Reload loads the config and applies the changed keys that have a handler. Changes to any other key require a
+// restart, they are logged and left out of the effective config.
+func (r *Reloader) Reload() error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return fmt.Errorf("could not load config: %w", err)
+	}
+
+	// The effective config only has the changes that are applied
+	effective := &conf.Conf{Koanf: r.current.Koanf.Copy(), Opts: r.current.Opts}
+	nextValues := next.All()
+	applied := make([]bool, len(r.handlers))
+	var restart []string
+	for _, key := range Changed(r.current.All(), nextValues) {
+		live := false
+		for i, h := range r.handlers {
+			if matchPrefix(h.prefixes, key) {
+				applied[i] = true
+				live = true
+			}
+		}
+		if !live {
+			restart = append(restart, key)
+			continue
+		}
+		if value, ok := nextValues[key]; ok {
+			if err := effective.Set(key, value); err != nil {
+				return fmt.Errorf("could not set %s: %w", key, err)
+			}
+		} else {
+			effective.Delete(key)
+		}
+	}
+
+	if len(restart) > 0 {
+		r.logger.Warn("Config changes require a restart and were not applied", "keys", strings.Join(restart, ","))
+	}
+
+	var errs []error
+	for i, h := range r.handlers {
+		if !applied[i] {
+			continue
+		}
+		if err := h.apply(effective); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", strings.Join(h.prefixes, ","), err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	r.current = effective
+	r.logger.Info("Config reloaded")
+	return nil
+
+}
+
+// Config returns the effective config as flattened keys with the secrets redacted
+func (r *Reloader) Config() map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Redact(r.current.All())
+}
+
+// Watch reloads the config when the file changes, checking every interval until stop is closed
+func (r *Reloader) Watch(filename string, interval time.Duration, stop <-chan struct{}) {
+
+	modified := func() time.Time {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+
+	last := modified()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if m := modified(); !m.Equal(last) {
+				last = m
+				r.logger.Info("Config file changed", "filename", filename)
+				if err := r.Reload(); err != nil {
+					r.logger.Error("Could not reload config", "error", err)
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+
+}
+
+// Changed returns the sorted keys that were added, removed or changed between the flattened configs. Values are
+// compared as printed so the same list from defaults and a file is not a change.
+func Changed(current, next map[string]any) []string {
+	var keys []string
+	for key, value := range current {
+		if nextValue, ok := next[key]; !ok || fmt.Sprint(value) != fmt.Sprint(nextValue) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range next {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// matchPrefix returns true if the key is one of the prefixes or below one
+func matchPrefix(prefixes []string, key string) bool {
+	for _, prefix := range prefixes {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigHandler returns the handler that responds with the effective config with the secrets redacted
+func (r *Reloader) ConfigHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		render.Encode(w, http.StatusOK, r.Config())
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/snowzach/golib/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReload(t *testing.T) {
+
+	load := func(values map[string]any) *conf.Conf {
+		c := conf.New()
+		assert.Nil(t, c.Parse(conf.WithMap(values)))
+		return c
+	}
+
+	current := load(map[string]any{
+		"logger.level":                "info",
+		"server.port":                 "8080",
+		"server.cors.allowed_origins": []string{"*"},
+		"database.password":           "postgres",
+	})
+	var next *conf.Conf
+	var loadErr error
+	r := NewReloader(slog.New(slog.NewTextHandler(io.Discard, nil)), current, func() (*conf.Conf, error) { return next, loadErr })
+
+	var levels, origins []string
+	r.Handle(func(c *conf.Conf) error {
+		levels = append(levels, c.String("logger.level"))
+		return nil
+	}, "logger.level")
+	r.Handle(func(c *conf.Conf) error {
+		origins = c.Strings("server.cors.allowed_origins")
+		return nil
+	}, "server.cors")
+
+	// Only the handlers of changed keys are called and keys that need a restart are not applied
+	next = load(map[string]any{
+		"logger.level":                "debug",
+		"server.port":                 "9090",
+		"server.cors.allowed_origins": []string{"*"},
+		"database.password":           "changed",
+	})
+	assert.Nil(t, r.Reload())
+	assert.Equal(t, []string{"debug"}, levels)
+	assert.Nil(t, origins)
+	assert.Equal(t, map[string]any{
+		"logger.level":                "debug",
+		"server.port":                 "8080",
+		"server.cors.allowed_origins": []string{"*"},
+		"database.password":           Redacted,
+	}, r.Config())
+
+	// A prefix matches the keys below it
+	next = load(map[string]any{
+		"logger.level":                "debug",
+		"server.cors.allowed_origins": []string{"https://example.com"},
+	})
+	assert.Nil(t, r.Reload())
+	assert.Equal(t, []string{"debug"}, levels)
+	assert.Equal(t, []string{"https://example.com"}, origins)
+
+	// Errors keep the effective config
+	loadErr = errors.New("bad file")
+	assert.EqualError(t, r.Reload(), "could not load config: bad file")
+	loadErr = nil
+	r.Handle(func(c *conf.Conf) error { return errors.New("invalid level") }, "logger.level")
+	next = load(map[string]any{"logger.level": "loud"})
+	assert.EqualError(t, r.Reload(), "logger.level: invalid level")
+	assert.Equal(t, "debug", r.Config()["logger.level"])
+
+}
+
+func TestWatch(t *testing.T) {
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, os.WriteFile(filename, []byte("logger:\n  level: info\n"), 0644))
+
+	load := func() (*conf.Conf, error) {
+		c := conf.New()
+		return c, c.Parse(conf.WithFile(filename))
+	}
+	current, err := load()
+	assert.Nil(t, err)
+	r := NewReloader(slog.New(slog.NewTextHandler(io.Discard, nil)), current, load)
+	levels := make(chan string, 1)
+	r.Handle(func(c *conf.Conf) error {
+		levels <- c.String("logger.level")
+		return nil
+	}, "logger")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.Watch(filename, 10*time.Millisecond, stop)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Nil(t, os.WriteFile(filename, []byte("logger:\n  level: debug\n"), 0644))
+	assert.Nil(t, os.Chtimes(filename, time.Now(), time.Now().Add(time.Minute)))
+	select {
+	case level := <-levels:
+		assert.Equal(t, "debug", level)
+	case <-time.After(time.Second):
+		t.Fatal("config was not reloaded")
+	}
+
+}
+
+func TestChanged(t *testing.T) {
+	assert.Equal(t, []string{"a", "c", "d"}, Changed(
+		map[string]any{"a": 1, "b": []string{"x"}, "c": "removed"},
+		map[string]any{"a": 2, "b": []any{"x"}, "d": "added"},
+	))
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, map[string]any{
+		"database.password":                 Redacted,
+		"database.username":                 "postgres",
+		"client.token":                      "",
+		"outbox.http.headers.Authorization": Redacted,
+		"server.keyfile":                    "server.key",
+	}, Redact(map[string]any{
+		"database.password":                 "postgres",
+		"database.username":                 "postgres",
+		"client.token":                      "",
+		"outbox.http.headers.Authorization": "Bearer x",
+		"server.keyfile":                    "server.key",
+	}))
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/snowzach/golib/conf"
+)
+
+// FileSuffix is the suffix of keys, and environment variables, that read the value of the key from a file, ie.
+// database.password_file or DATABASE_PASSWORD_FILE set database.password.
+const FileSuffix = "_file"
+
+// Secrets are the values resolved from files and references. They are redacted from logs and the printed config.
+var Secrets = new(SecretSet)
+
+// SecretSet is a set of secret values
+type SecretSet struct {
+	mu      sync.RWMutex
+	values  map[string]struct{}
+	pattern *regexp.Regexp
+}
+
+// Add adds secret values, empty values are ignored
+func (s *SecretSet) Add(values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[string]struct{})
+	}
+	for _, value := range values {
+		if value != "" {
+			s.values[value] = struct{}{}
+		}
+	}
+	if len(s.values) == 0 {
+		return
+	}
+	// Match the longest first so a secret containing another is redacted whole
+	sorted := make([]string, 0, len(s.values))
+	for value := range s.values {
+		sorted = append(sorted, regexp.QuoteMeta(value))
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	s.pattern = regexp.MustCompile(strings.Join(sorted, "|"))
+}
+
+// Redact replaces the secrets in text. Only whole tokens are replaced so a secret like "postgres" doesn't redact
+// "database.postgres" or "postgresql".
+func (s *SecretSet) Redact(text string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.pattern == nil {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, loc := range s.pattern.FindAllStringIndex(text, -1) {
+		if isTokenChar(text, loc[0]-1) || isTokenChar(text, loc[1]) {
+			continue
+		}
+		sb.WriteString(text[last:loc[0]])
+		sb.WriteString(Redacted)
+		last = loc[1]
+	}
+	if last == 0 {
+		return text
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// isTokenChar returns true if the byte of text at i continues a token, ie. a name like database.postgres
+func isTokenChar(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.' || c >= utf8.RuneSelf
+}
+
+// Empty returns true if there are no secrets
+func (s *SecretSet) Empty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.values) == 0
+}
+
+// reference matches ${env:VAR} and ${file:/path}
+var reference = regexp.MustCompile(`\$\{(\w+):([^}]*)\}`)
+
+// WithSecrets is a ParserFunc that resolves secrets after the other sources are parsed. See ResolveSecrets.
+func WithSecrets(secrets *SecretSet) conf.ParserFunc {
+	return func(c *conf.Conf) error {
+		return ResolveSecrets(c, secrets)
+	}
+}
+
+// ResolveSecrets sets keys from the files of keys and environment variables with FileSuffix and replaces
+// ${env:VAR} and ${file:/path} references in values. Files are read without the trailing newline. The values read
+// are added to secrets. Values set in plain text, including the defaults, are not, keys named like secrets are redacted
+// by name instead.
+func ResolveSecrets(c *conf.Conf, secrets *SecretSet) error {
+
+	// Keys with the file suffix
+	for _, key := range c.Keys() {
+		if !strings.HasSuffix(key, FileSuffix) {
+			continue
+		}
+		filename := c.String(key)
+		c.Delete(key)
+		if filename == "" {
+			continue
+		}
+		value, err := readSecretFile(filename)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if err := c.Set(strings.TrimSuffix(key, FileSuffix), value); err != nil {
+			return err
+		}
+		secrets.Add(value)
+	}
+
+	// Environment variables with the file suffix override existing keys like other environment variables
+	for _, key := range c.Keys() {
+		filename := os.Getenv(EnvName(key + FileSuffix))
+		if filename == "" {
+			continue
+		}
+		value, err := readSecretFile(filename)
+		if err != nil {
+			return fmt.Errorf("%s: %w", EnvName(key+FileSuffix), err)
+		}
+		if err := c.Set(key, value); err != nil {
+			return err
+		}
+		secrets.Add(value)
+	}
+
+	// References
+	for _, key := range c.Keys() {
+		switch value := c.Get(key).(type) {
+		case string:
+			resolved, err := resolveReferences(value, secrets)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if resolved != value {
+				if err := c.Set(key, resolved); err != nil {
+					return err
+				}
+			}
+		case []any, []string:
+			values := c.Strings(key)
+			changed := false
+			for i := range values {
+				resolved, err := resolveReferences(values[i], secrets)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				changed = changed || resolved != values[i]
+				values[i] = resolved
+			}
+			if changed {
+				// Set merges slices by replacing them
+				if err := c.Set(key, values); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+
+}
+
+// resolveReferences replaces the references in value
+func resolveReferences(value string, secrets *SecretSet) (string, error) {
+	var err error
+	resolved := reference.ReplaceAllStringFunc(value, func(match string) string {
+		parts := reference.FindStringSubmatch(match)
+		var secret string
+		switch parts[1] {
+		case "env":
+			var ok bool
+			if secret, ok = os.LookupEnv(parts[2]); !ok {
+				err = fmt.Errorf("environment variable %s is not set", parts[2])
+			}
+		case "file":
+			var ferr error
+			if secret, ferr = readSecretFile(parts[2]); ferr != nil {
+				err = ferr
+			}
+		default:
+			err = fmt.Errorf("unknown reference %s, use ${env:VAR} or ${file:/path}", match)
+		}
+		secrets.Add(secret)
+		return secret
+	})
+	return resolved, err
+}
+
+// readSecretFile reads the file without the trailing newline
+func readSecretFile(filename string) (string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("could not read secret: %w", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// RedactHandler is a slog.Handler that redacts secrets from the message and attributes of records
+type RedactHandler struct {
+	secrets *SecretSet
+	handler slog.Handler
+}
+
+// NewRedactHandler creates a RedactHandler of handler
+func NewRedactHandler(handler slog.Handler, secrets *SecretSet) *RedactHandler {
+	return &RedactHandler{secrets: secrets, handler: handler}
+}
+
+// Enabled returns true if the wrapped handler is enabled
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle redacts the record and handles it with the wrapped handler
+func (h *RedactHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.secrets.Empty() {
+		return h.handler.Handle(ctx, record)
+	}
+	redacted := slog.NewRecord(record.Time, record.Level, h.secrets.Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+// WithAttrs returns a RedactHandler of the wrapped handler with the redacted attrs
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i := range attrs {
+		redacted[i] = h.redactAttr(attrs[i])
+	}
+	return NewRedactHandler(h.handler.WithAttrs(redacted), h.secrets)
+}
+
+// WithGroup returns a RedactHandler of the wrapped handler with the group
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return NewRedactHandler(h.handler.WithGroup(name), h.secrets)
+}
+
+func (h *RedactHandler) redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.secrets.Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i := range group {
+			redacted[i] = h.redactAttr(group[i])
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		// Errors and other values are redacted as they would be printed
+		if text := fmt.Sprint(value.Any()); h.secrets.Redact(text) != text {
+			return slog.String(attr.Key, h.secrets.Redact(text))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
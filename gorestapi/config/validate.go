@@ -0,0 +1,230 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snowzach/golib/conf"
+)
+
+// Problem is a problem with a config key
+type Problem struct {
+	Key     string
+	Message string
+	// Warnings are only failures in strict mode
+	Warning bool
+}
+
+// Error returns the key and the message
+func (p Problem) Error() string {
+	if p.Key == "" {
+		return p.Message
+	}
+	return p.Key + ": " + p.Message
+}
+
+// Rule checks keys that depend on each other, ie. server.tls needs the certificate files
+type Rule func(c *conf.Conf) []Problem
+
+// Validate checks every key of the config is one of the defaults with a value of the same type, and then the rules.
+// Keys below a default that is a map, ie. outbox.http.headers, are not checked.
+func Validate(c *conf.Conf, defaults *conf.Conf, rules ...Rule) []Problem {
+
+	var problems []Problem
+	defaultValues := defaults.All()
+	values := c.All()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		defaultValue, ok := defaultValues[key]
+		if !ok {
+			if !belowMap(defaultValues, key) {
+				problems = append(problems, Problem{Key: key, Message: "unknown key" + suggest(defaultValues, key), Warning: true})
+			}
+			continue
+		}
+		if err := checkType(defaultValue, values[key]); err != nil {
+			problems = append(problems, Problem{Key: key, Message: err.Error()})
+		}
+	}
+
+	for _, rule := range rules {
+		problems = append(problems, rule(c)...)
+	}
+	return problems
+
+}
+
+// Failed returns true if there are errors, or warnings in strict mode
+func Failed(problems []Problem, strict bool) bool {
+	for _, problem := range problems {
+		if !problem.Warning || strict {
+			return true
+		}
+	}
+	return false
+}
+
+// belowMap returns true if the key is below a default that is a map
+func belowMap(defaultValues map[string]any, key string) bool {
+	for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+		if value, ok := defaultValues[key[:i]]; ok {
+			switch value.(type) {
+			case map[string]any, map[string]string:
+				return true
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// suggest returns a hint of a default key with the same name in another place or the same place with another name
+func suggest(defaultValues map[string]any, key string) string {
+	prefix, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		prefix, name = key[:i+1], key[i+1:]
+	}
+	var candidates []string
+	for candidate := range defaultValues {
+		if strings.HasSuffix(candidate, "."+name) || (strings.HasPrefix(candidate, prefix) && !strings.Contains(candidate[len(prefix):], ".") && distance(normalize(candidate[len(prefix):]), normalize(name)) <= 2) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Strings(candidates)
+	return ", did you mean " + strings.Join(candidates, " or ")
+}
+
+// normalize lower cases the name and removes underscores so max_age and maxAge are the same
+func normalize(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// distance returns the Levenshtein distance between a and b
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// checkType returns an error if the value can not be decoded as the type of the default value
+func checkType(defaultValue, value any) error {
+
+	switch d := defaultValue.(type) {
+	case bool:
+		switch v := value.(type) {
+		case bool:
+			return nil
+		case string:
+			if _, err := strconv.ParseBool(v); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected a bool, got %v", value)
+	case int, int64, float64:
+		switch v := value.(type) {
+		case int, int64, float64:
+			return nil
+		case string:
+			if _, err := strconv.ParseFloat(v, 64); err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected a number, got %v", value)
+	case []string, []any:
+		switch value.(type) {
+		case []string, []any, string:
+			return nil
+		}
+		return fmt.Errorf("expected a list, got %v", value)
+	case map[string]any, map[string]string:
+		switch value.(type) {
+		case map[string]any, map[string]string:
+			return nil
+		}
+		return fmt.Errorf("expected a map, got %v", value)
+	case string:
+		switch v := value.(type) {
+		case []string, []any, map[string]any:
+			return fmt.Errorf("expected a string, got %v", value)
+		case string:
+			// Durations must stay durations
+			if _, err := time.ParseDuration(d); err == nil && v != "" {
+				if _, err := time.ParseDuration(v); err != nil {
+					return fmt.Errorf("expected a duration like %s, got %s", d, v)
+				}
+			}
+		}
+	}
+	return nil
+
+}
+
+// Sources of config values
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// Value is a config value and where it came from
+type Value struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// Describe returns the values of the config sorted by key with the secrets redacted. Values set by an environment
+// variable, or read from the file of one, are from env, otherwise values in file are from file and the rest are
+// defaults.
+func Describe(c *conf.Conf, file *conf.Conf) []Value {
+
+	fileValues := file.All()
+	redacted := Redact(c.All())
+	values := make([]Value, 0, len(redacted))
+	for _, key := range c.Keys() {
+		value := Value{Key: key, Value: redacted[key], Source: SourceDefault}
+		if _, ok := os.LookupEnv(EnvName(key + FileSuffix)); ok {
+			value.Source = SourceEnv
+		} else if _, ok := os.LookupEnv(EnvName(key)); ok {
+			value.Source = SourceEnv
+		} else if _, ok := fileValues[key]; ok {
+			value.Source = SourceFile
+		} else if _, ok := fileValues[key+FileSuffix]; ok {
+			value.Source = SourceFile
+		}
+		values = append(values, value)
+	}
+	return values
+
+}
+
+// EnvName returns the environment variable that overrides the key
+func EnvName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
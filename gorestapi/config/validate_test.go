@@ -0,0 +1,82 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/snowzach/golib/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+
+	defaults := conf.New()
+	assert.Nil(t, defaults.Parse(conf.WithMap(map[string]any{
+		"server.port":         "8080",
+		"server.tls":          false,
+		"server.cors.max_age": 300,
+		"client.timeout":      "30s",
+		"outbox.sinks":        []string{"log"},
+		"outbox.http.headers": map[string]string{},
+	})))
+
+	c := conf.New()
+	assert.Nil(t, c.Parse(conf.WithMap(defaults.All()), conf.WithBytes([]byte(`
+server:
+  tls: "yes please"
+  cors:
+    max_age: "5m"
+    maxage: 10
+client:
+  timeout: 1m
+outbox:
+  sinks: http
+  http:
+    headers:
+      Authorization: Bearer token
+  sink: log
+`), "yaml")))
+
+	problems := Validate(c, defaults, func(c *conf.Conf) []Problem {
+		if c.String("outbox.sinks") == "http" {
+			return []Problem{{Key: "outbox.http.url", Message: "required by the http sink"}}
+		}
+		return nil
+	})
+	assert.Equal(t, []Problem{
+		{Key: "outbox.sink", Message: "unknown key, did you mean outbox.sinks", Warning: true},
+		{Key: "server.cors.max_age", Message: "expected a number, got 5m"},
+		{Key: "server.cors.maxage", Message: "unknown key, did you mean server.cors.max_age", Warning: true},
+		{Key: "server.tls", Message: "expected a bool, got yes please"},
+		{Key: "outbox.http.url", Message: "required by the http sink"},
+	}, problems)
+	assert.True(t, Failed(problems, false))
+	assert.False(t, Failed(problems[:1], false))
+	assert.True(t, Failed(problems[:1], true))
+	assert.EqualError(t, problems[0], "outbox.sink: unknown key, did you mean outbox.sinks")
+
+	// Durations
+	assert.Nil(t, checkType("30s", "1m"))
+	assert.EqualError(t, checkType("30s", "soon"), "expected a duration like 30s, got soon")
+	assert.Nil(t, checkType("8080", "9090"))
+
+}
+
+func TestDescribe(t *testing.T) {
+
+	t.Setenv("DATABASE_HOST", "db")
+	c := conf.New()
+	assert.Nil(t, c.Parse(conf.WithMap(map[string]any{
+		"database.host":     "postgres",
+		"database.password": "postgres",
+		"server.port":       "8080",
+	}), conf.WithBytes([]byte(`{"database":{"password":"secret"}}`), "json"), conf.WithEnv()))
+	file := conf.New()
+	assert.Nil(t, file.Parse(conf.WithBytes([]byte(`{"database":{"password":"secret"}}`), "json")))
+
+	assert.Equal(t, []Value{
+		{Key: "database.host", Value: "db", Source: SourceEnv},
+		{Key: "database.password", Value: Redacted, Source: SourceFile},
+		{Key: "server.port", Value: "8080", Source: SourceDefault},
+	}, Describe(c, file))
+
+}
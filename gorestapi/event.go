@@ -0,0 +1,53 @@
+package gorestapi
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Event Types
+const (
+	EventTypeCreated = "created"
+	EventTypeUpdated = "updated"
+	EventTypeDeleted = "deleted"
+)
+
+// Event Resources
+const (
+	EventResourceThing  = "thing"
+	EventResourceWidget = "widget"
+)
+
+// Event is a change to a resource
+type Event struct {
+	// ID (Auto-Generated, increasing)
+	ID int64 `json:"id"`
+	// Created Timestamp
+	Created time.Time `json:"created"`
+	// Type is created, updated or deleted
+	Type string `json:"type"`
+	// Resource is the type of resource that changed
+	Resource string `json:"resource"`
+	// ResourceID is the ID of the resource that changed
+	ResourceID string `json:"resource_id" db:"resource_id"`
+	// Data is the resource after the change (empty for deletes)
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Name returns the name of the event, ie. thing.created
+func (e *Event) Name() string {
+	return e.Resource + "." + e.Type
+}
+
+// String is the stringer method
+func (e *Event) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// EventStore is the persistent store of events
+type EventStore interface {
+	// EventsSince returns up to limit events with an ID greater than id in ID order.
+	EventsSince(ctx context.Context, id int64, limit int) ([]*Event, error)
+}
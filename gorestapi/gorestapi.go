@@ -12,9 +12,11 @@ type GRStore interface {
 	ThingSave(ctx context.Context, thing *Thing) error
 	ThingDeleteByID(ctx context.Context, id string) error
 	ThingsFind(ctx context.Context, qp *queryp.QueryParameters) ([]*Thing, *int64, error)
+	ThingsStream(ctx context.Context, qp *queryp.QueryParameters, fn func(*Thing) error) error
 
 	WidgetGetByID(ctx context.Context, id string) (*Widget, error)
 	WidgetSave(ctx context.Context, thing *Widget) error
 	WidgetDeleteByID(ctx context.Context, id string) error
 	WidgetsFind(ctx context.Context, qp *queryp.QueryParameters) ([]*Widget, *int64, error)
+	WidgetsStream(ctx context.Context, qp *queryp.QueryParameters, fn func(*Widget) error) error
 }
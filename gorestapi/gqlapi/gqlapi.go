@@ -0,0 +1,178 @@
+package gqlapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/snowzach/golib/log"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+// Schema executes GraphQL requests against a store
+type Schema struct {
+	logger  *slog.Logger
+	grStore gorestapi.GRStore
+	schema  *graphql.Schema
+}
+
+// NewSchema creates the GraphQL schema backed by the store
+func NewSchema(grStore gorestapi.GRStore) (*Schema, error) {
+
+	s := &Schema{
+		logger:  log.Logger.With("context", "gqlapi"),
+		grStore: grStore,
+	}
+
+	var err error
+	s.schema, err = graphql.ParseSchema(schemaString, &resolver{s}, graphql.MaxDepth(10))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse schema: %w", err)
+	}
+
+	return s, nil
+
+}
+
+// Exec executes a GraphQL request. Related records are loaded in batches for the duration of the request.
+func (s *Schema) Exec(ctx context.Context, query string, operationName string, variables map[string]any) *graphql.Response {
+	return s.schema.Exec(context.WithValue(ctx, loadersKey{}, s.newLoaders()), query, operationName, variables)
+}
+
+// loadersKey is the context key of the request loaders
+type loadersKey struct{}
+
+// loaders batch loading related records during a request
+type loaders struct {
+	thing          *dataloader.Loader[string, *gorestapi.Thing]
+	widgetsByThing *dataloader.Loader[string, []*gorestapi.Widget]
+}
+
+// newLoaders creates the loaders for a request
+func (s *Schema) newLoaders() *loaders {
+	return &loaders{
+		thing: dataloader.NewBatchedLoader(func(ctx context.Context, ids []string) []*dataloader.Result[*gorestapi.Thing] {
+			results := make([]*dataloader.Result[*gorestapi.Thing], len(ids))
+			things, _, err := s.grStore.ThingsFind(ctx, &queryp.QueryParameters{
+				Filter: queryp.Filter{{Field: "thing.id", Op: queryp.FilterOpEquals, Value: anySlice(ids)}},
+				Limit:  int64(len(ids)),
+			})
+			byID := make(map[string]*gorestapi.Thing, len(things))
+			for _, thing := range things {
+				byID[thing.ID] = thing
+			}
+			for i, id := range ids {
+				results[i] = &dataloader.Result[*gorestapi.Thing]{Data: byID[id], Error: err}
+			}
+			return results
+		}),
+		widgetsByThing: dataloader.NewBatchedLoader(func(ctx context.Context, thingIDs []string) []*dataloader.Result[[]*gorestapi.Widget] {
+			results := make([]*dataloader.Result[[]*gorestapi.Widget], len(thingIDs))
+			widgets, _, err := s.grStore.WidgetsFind(ctx, &queryp.QueryParameters{
+				Filter: queryp.Filter{{Field: "widget.thing_id", Op: queryp.FilterOpEquals, Value: anySlice(thingIDs)}},
+				Sort:   queryp.Sort{{Field: "widget.name"}},
+			})
+			byThingID := make(map[string][]*gorestapi.Widget, len(thingIDs))
+			for _, widget := range widgets {
+				if widget.ThingID != nil {
+					byThingID[*widget.ThingID] = append(byThingID[*widget.ThingID], widget)
+				}
+			}
+			for i, thingID := range thingIDs {
+				results[i] = &dataloader.Result[[]*gorestapi.Widget]{Data: byThingID[thingID], Error: err}
+			}
+			return results
+		}),
+	}
+}
+
+// getLoaders returns the loaders for the request
+func getLoaders(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// anySlice converts values to the slice type used by queryp for lists
+func anySlice(values []string) []any {
+	ret := make([]any, len(values))
+	for i, value := range values {
+		ret[i] = value
+	}
+	return ret
+}
+
+// findArgs are the arguments of the find queries
+type findArgs struct {
+	Filter *string
+	Sort   *[]string
+	Limit  *int32
+	Offset *int32
+}
+
+// QueryParameters translates the arguments to query parameters
+func (args findArgs) QueryParameters() (*queryp.QueryParameters, error) {
+
+	qp := new(queryp.QueryParameters)
+	if args.Filter != nil {
+		var err error
+		if qp, err = queryp.ParseQuery(*args.Filter); err != nil {
+			return nil, err
+		}
+		// Only the filter is used, the other arguments are explicit
+		qp.Sort, qp.Limit, qp.Offset, qp.Options = nil, 0, 0, nil
+	}
+	if args.Sort != nil {
+		for _, field := range *args.Sort {
+			qp.Sort.Append(strings.TrimPrefix(field, "-"), strings.HasPrefix(field, "-"))
+		}
+	}
+	if args.Limit != nil {
+		qp.Limit = int64(*args.Limit)
+	}
+	if args.Offset != nil {
+		qp.Offset = int64(*args.Offset)
+	}
+	return qp, nil
+
+}
+
+// Error is a GraphQL error with a code in the extensions
+type Error struct {
+	Code    string
+	Message string
+}
+
+// Error Codes
+const (
+	ErrorCodeInvalidArgument = "INVALID_ARGUMENT"
+	ErrorCodeNotFound        = "NOT_FOUND"
+	ErrorCodeInternal        = "INTERNAL"
+)
+
+func (e *Error) Error() string { return e.Message }
+
+// Extensions returns the code of the error
+func (e *Error) Extensions() map[string]any {
+	return map[string]any{"code": e.Code}
+}
+
+// error maps store errors to GraphQL errors the same way the REST handlers map them to HTTP status codes
+func (s *Schema) error(ctx context.Context, method string, op store.ErrorOp, resource string, err error) error {
+	if err == store.ErrNotFound {
+		return &Error{Code: ErrorCodeNotFound, Message: resource + " not found"}
+	} else if serr, ok := err.(*store.Error); ok {
+		return &Error{Code: ErrorCodeInvalidArgument, Message: serr.ErrorForOp(op).Error()}
+	}
+	s.logger.Error(method+" error", "error", err, "request_id", middleware.GetReqID(ctx))
+	return &Error{Code: ErrorCodeInternal, Message: "internal error"}
+}
@@ -0,0 +1,236 @@
+package gqlapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/snowzach/golib/store"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// resolver is the root query and mutation resolver
+type resolver struct {
+	*Schema
+}
+
+// Thing gets a thing, returning null if it does not exist
+func (r *resolver) Thing(ctx context.Context, args struct{ ID graphql.ID }) (*thingResolver, error) {
+	thing, err := r.grStore.ThingGetByID(ctx, string(args.ID))
+	if err == store.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, r.error(ctx, "ThingGetByID", store.ErrorOpGet, "thing", err)
+	}
+	return &thingResolver{thing}, nil
+}
+
+// Things finds things
+func (r *resolver) Things(ctx context.Context, args findArgs) (*thingListResolver, error) {
+	qp, err := args.QueryParameters()
+	if err != nil {
+		return nil, &Error{Code: ErrorCodeInvalidArgument, Message: err.Error()}
+	}
+	things, count, err := r.grStore.ThingsFind(ctx, qp)
+	if err != nil {
+		return nil, r.error(ctx, "ThingsFind", store.ErrorOpFind, "thing", err)
+	}
+	return &thingListResolver{things: things, count: count}, nil
+}
+
+// Widget gets a widget, returning null if it does not exist
+func (r *resolver) Widget(ctx context.Context, args struct{ ID graphql.ID }) (*widgetResolver, error) {
+	widget, err := r.grStore.WidgetGetByID(ctx, string(args.ID))
+	if err == store.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, r.error(ctx, "WidgetGetByID", store.ErrorOpGet, "widget", err)
+	}
+	return &widgetResolver{widget}, nil
+}
+
+// Widgets finds widgets
+func (r *resolver) Widgets(ctx context.Context, args findArgs) (*widgetListResolver, error) {
+	qp, err := args.QueryParameters()
+	if err != nil {
+		return nil, &Error{Code: ErrorCodeInvalidArgument, Message: err.Error()}
+	}
+	widgets, count, err := r.grStore.WidgetsFind(ctx, qp)
+	if err != nil {
+		return nil, r.error(ctx, "WidgetsFind", store.ErrorOpFind, "widget", err)
+	}
+	return &widgetListResolver{widgets: widgets, count: count}, nil
+}
+
+// ThingSave saves a thing
+func (r *resolver) ThingSave(ctx context.Context, args struct{ Input thingInput }) (*thingResolver, error) {
+	thing := &gorestapi.Thing{
+		Name: args.Input.Name,
+	}
+	if args.Input.ID != nil {
+		thing.ID = string(*args.Input.ID)
+	}
+	if args.Input.Description != nil {
+		thing.Description = *args.Input.Description
+	}
+	if err := r.grStore.ThingSave(ctx, thing); err != nil {
+		return nil, r.error(ctx, "ThingSave", store.ErrorOpSave, "thing", err)
+	}
+	return &thingResolver{thing}, nil
+}
+
+// ThingDelete deletes a thing
+func (r *resolver) ThingDelete(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	if err := r.grStore.ThingDeleteByID(ctx, string(args.ID)); err != nil {
+		return false, r.error(ctx, "ThingDeleteByID", store.ErrorOpDelete, "thing", err)
+	}
+	return true, nil
+}
+
+// WidgetSave saves a widget
+func (r *resolver) WidgetSave(ctx context.Context, args struct{ Input widgetInput }) (*widgetResolver, error) {
+	widget := &gorestapi.Widget{
+		Name: args.Input.Name,
+	}
+	if args.Input.ID != nil {
+		widget.ID = string(*args.Input.ID)
+	}
+	if args.Input.Description != nil {
+		widget.Description = *args.Input.Description
+	}
+	if args.Input.ThingID != nil {
+		thingID := string(*args.Input.ThingID)
+		widget.ThingID = &thingID
+	}
+	if err := r.grStore.WidgetSave(ctx, widget); err != nil {
+		return nil, r.error(ctx, "WidgetSave", store.ErrorOpSave, "widget", err)
+	}
+	return &widgetResolver{widget}, nil
+}
+
+// WidgetDelete deletes a widget
+func (r *resolver) WidgetDelete(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	if err := r.grStore.WidgetDeleteByID(ctx, string(args.ID)); err != nil {
+		return false, r.error(ctx, "WidgetDeleteByID", store.ErrorOpDelete, "widget", err)
+	}
+	return true, nil
+}
+
+type thingInput struct {
+	ID          *graphql.ID
+	Name        string
+	Description *string
+}
+
+type widgetInput struct {
+	ID          *graphql.ID
+	Name        string
+	Description *string
+	ThingID     *graphql.ID
+}
+
+// thingResolver resolves the fields of a thing
+type thingResolver struct {
+	thing *gorestapi.Thing
+}
+
+func (r *thingResolver) ID() graphql.ID         { return graphql.ID(r.thing.ID) }
+func (r *thingResolver) Created() *graphql.Time { return timePtr(r.thing.Created) }
+func (r *thingResolver) Updated() *graphql.Time { return timePtr(r.thing.Updated) }
+func (r *thingResolver) Name() string           { return r.thing.Name }
+func (r *thingResolver) Description() string    { return r.thing.Description }
+
+// Widgets loads the widgets of the thing in a batch with the widgets of other things in the request
+func (r *thingResolver) Widgets(ctx context.Context) ([]*widgetResolver, error) {
+	widgets, err := getLoaders(ctx).widgetsByThing.Load(ctx, r.thing.ID)()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*widgetResolver, 0, len(widgets))
+	for _, widget := range widgets {
+		ret = append(ret, &widgetResolver{widget})
+	}
+	return ret, nil
+}
+
+// thingListResolver resolves a list of things
+type thingListResolver struct {
+	things []*gorestapi.Thing
+	count  *int64
+}
+
+func (r *thingListResolver) Results() []*thingResolver {
+	ret := make([]*thingResolver, 0, len(r.things))
+	for _, thing := range r.things {
+		ret = append(ret, &thingResolver{thing})
+	}
+	return ret
+}
+
+func (r *thingListResolver) Count() *int32 { return countPtr(r.count) }
+
+// widgetResolver resolves the fields of a widget
+type widgetResolver struct {
+	widget *gorestapi.Widget
+}
+
+func (r *widgetResolver) ID() graphql.ID         { return graphql.ID(r.widget.ID) }
+func (r *widgetResolver) Created() *graphql.Time { return timePtr(r.widget.Created) }
+func (r *widgetResolver) Updated() *graphql.Time { return timePtr(r.widget.Updated) }
+func (r *widgetResolver) Name() string           { return r.widget.Name }
+func (r *widgetResolver) Description() string    { return r.widget.Description }
+
+func (r *widgetResolver) ThingID() *graphql.ID {
+	if r.widget.ThingID == nil {
+		return nil
+	}
+	id := graphql.ID(*r.widget.ThingID)
+	return &id
+}
+
+// Thing returns the thing loaded with the widget or loads it in a batch with the other things in the request
+func (r *widgetResolver) Thing(ctx context.Context) (*thingResolver, error) {
+	if r.widget.Thing != nil {
+		return &thingResolver{r.widget.Thing}, nil
+	}
+	if r.widget.ThingID == nil {
+		return nil, nil
+	}
+	thing, err := getLoaders(ctx).thing.Load(ctx, *r.widget.ThingID)()
+	if err != nil || thing == nil {
+		return nil, err
+	}
+	return &thingResolver{thing}, nil
+}
+
+// widgetListResolver resolves a list of widgets
+type widgetListResolver struct {
+	widgets []*gorestapi.Widget
+	count   *int64
+}
+
+func (r *widgetListResolver) Results() []*widgetResolver {
+	ret := make([]*widgetResolver, 0, len(r.widgets))
+	for _, widget := range r.widgets {
+		ret = append(ret, &widgetResolver{widget})
+	}
+	return ret
+}
+
+func (r *widgetListResolver) Count() *int32 { return countPtr(r.count) }
+
+func timePtr(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
+
+func countPtr(count *int64) *int32 {
+	if count == nil {
+		return nil
+	}
+	c := int32(*count)
+	return &c
+}
@@ -0,0 +1,733 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: gorestapi/grpcapi/gorestapi.proto
+
+package grpcapi
+
+import (
+	qppb "github.com/snowzach/queryp/qppb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Thing struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// ID (Auto-Generated)
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Created Timestamp
+	Created *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created,proto3" json:"created,omitempty"`
+	// Updated Timestamp
+	Updated *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated,proto3" json:"updated,omitempty"`
+	// Name
+	Name string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	// Description
+	Description string `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+}
+
+func (x *Thing) Reset() {
+	*x = Thing{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Thing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Thing) ProtoMessage() {}
+
+func (x *Thing) ProtoReflect() protoreflect.Message {
+	mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Thing.ProtoReflect.Descriptor instead.
+func (*Thing) Descriptor() ([]byte, []int) {
+	return file_gorestapi_grpcapi_gorestapi_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Thing) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Thing) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Thing) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *Thing) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Thing) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type Widget struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// ID (Auto-Generated)
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Created Timestamp
+	Created *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created,proto3" json:"created,omitempty"`
+	// Updated Timestamp
+	Updated *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated,proto3" json:"updated,omitempty"`
+	// Name
+	Name string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	// Description
+	Description string `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	// ThingID
+	ThingId *string `protobuf:"bytes,6,opt,name=thing_id,json=thingId,proto3,oneof" json:"thing_id,omitempty"`
+	// Loaded Structs
+	Thing *Thing `protobuf:"bytes,7,opt,name=thing,proto3" json:"thing,omitempty"`
+}
+
+func (x *Widget) Reset() {
+	*x = Widget{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Widget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Widget) ProtoMessage() {}
+
+func (x *Widget) ProtoReflect() protoreflect.Message {
+	mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Widget.ProtoReflect.Descriptor instead.
+func (*Widget) Descriptor() ([]byte, []int) {
+	return file_gorestapi_grpcapi_gorestapi_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Widget) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Widget) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Widget) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *Widget) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Widget) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Widget) GetThingId() string {
+	if x != nil && x.ThingId != nil {
+		return *x.ThingId
+	}
+	return ""
+}
+
+func (x *Widget) GetThing() *Thing {
+	if x != nil {
+		return x.Thing
+	}
+	return nil
+}
+
+type GetByIDRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetByIDRequest) Reset() {
+	*x = GetByIDRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetByIDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetByIDRequest) ProtoMessage() {}
+
+func (x *GetByIDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetByIDRequest.ProtoReflect.Descriptor instead.
+func (*GetByIDRequest) Descriptor() ([]byte, []int) {
+	return file_gorestapi_grpcapi_gorestapi_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetByIDRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteByIDRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DeleteByIDRequest) Reset() {
+	*x = DeleteByIDRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteByIDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteByIDRequest) ProtoMessage() {}
+
+func (x *DeleteByIDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteByIDRequest.ProtoReflect.Descriptor instead.
+func (*DeleteByIDRequest) Descriptor() ([]byte, []int) {
+	return file_gorestapi_grpcapi_gorestapi_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DeleteByIDRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type FindRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Filter, sort and pagination
+	Query *qppb.QueryParameters `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+}
+
+func (x *FindRequest) Reset() {
+	*x = FindRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FindRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindRequest) ProtoMessage() {}
+
+func (x *FindRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindRequest.ProtoReflect.Descriptor instead.
+func (*FindRequest) Descriptor() ([]byte, []int) {
+	return file_gorestapi_grpcapi_gorestapi_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FindRequest) GetQuery() *qppb.QueryParameters {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+type ThingsFindResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*Thing `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Count   *int64   `protobuf:"varint,2,opt,name=count,proto3,oneof" json:"count,omitempty"`
+}
+
+func (x *ThingsFindResponse) Reset() {
+	*x = ThingsFindResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ThingsFindResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThingsFindResponse) ProtoMessage() {}
+
+func (x *ThingsFindResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThingsFindResponse.ProtoReflect.Descriptor instead.
+func (*ThingsFindResponse) Descriptor() ([]byte, []int) {
+	return file_gorestapi_grpcapi_gorestapi_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ThingsFindResponse) GetResults() []*Thing {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *ThingsFindResponse) GetCount() int64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+type WidgetsFindResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*Widget `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Count   *int64    `protobuf:"varint,2,opt,name=count,proto3,oneof" json:"count,omitempty"`
+}
+
+func (x *WidgetsFindResponse) Reset() {
+	*x = WidgetsFindResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WidgetsFindResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WidgetsFindResponse) ProtoMessage() {}
+
+func (x *WidgetsFindResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gorestapi_grpcapi_gorestapi_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WidgetsFindResponse.ProtoReflect.Descriptor instead.
+func (*WidgetsFindResponse) Descriptor() ([]byte, []int) {
+	return file_gorestapi_grpcapi_gorestapi_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WidgetsFindResponse) GetResults() []*Widget {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *WidgetsFindResponse) GetCount() int64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+var File_gorestapi_grpcapi_gorestapi_proto protoreflect.FileDescriptor
+
+var file_gorestapi_grpcapi_gorestapi_proto_rawDesc = []byte{
+	0x0a, 0x21, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x72, 0x70, 0x63,
+	0x61, 0x70, 0x69, 0x2f, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x09, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x1a, 0x1b,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x11, 0x71, 0x70,
+	0x70, 0x62, 0x2f, 0x71, 0x75, 0x65, 0x72, 0x79, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xb9, 0x01, 0x0a, 0x05, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x34, 0x0a, 0x07, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12,
+	0x34, 0x0a, 0x07, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x75, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x8f, 0x02, 0x0a, 0x06,
+	0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x34, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x34, 0x0a, 0x07,
+	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x75, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x08, 0x74, 0x68, 0x69, 0x6e,
+	0x67, 0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x07, 0x74, 0x68,
+	0x69, 0x6e, 0x67, 0x49, 0x64, 0x88, 0x01, 0x01, 0x12, 0x26, 0x0a, 0x05, 0x74, 0x68, 0x69, 0x6e,
+	0x67, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74,
+	0x61, 0x70, 0x69, 0x2e, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x52, 0x05, 0x74, 0x68, 0x69, 0x6e, 0x67,
+	0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x5f, 0x69, 0x64, 0x22, 0x20, 0x0a,
+	0x0e, 0x47, 0x65, 0x74, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22,
+	0x23, 0x0a, 0x11, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x69, 0x64, 0x22, 0x3c, 0x0a, 0x0b, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x71, 0x75, 0x65, 0x72, 0x79, 0x70, 0x2e, 0x51, 0x75, 0x65, 0x72,
+	0x79, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x73, 0x52, 0x05, 0x71, 0x75, 0x65,
+	0x72, 0x79, 0x22, 0x65, 0x0a, 0x12, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x73, 0x46, 0x69, 0x6e, 0x64,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2a, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x67, 0x6f, 0x72, 0x65,
+	0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x52, 0x07, 0x72, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x73, 0x12, 0x19, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x42,
+	0x08, 0x0a, 0x06, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x67, 0x0a, 0x13, 0x57, 0x69, 0x64,
+	0x67, 0x65, 0x74, 0x73, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x2b, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x11, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x57, 0x69,
+	0x64, 0x67, 0x65, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x19, 0x0a,
+	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00, 0x52, 0x05,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x32, 0x89, 0x04, 0x0a, 0x07, 0x47, 0x52, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x3b,
+	0x0a, 0x0c, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x47, 0x65, 0x74, 0x42, 0x79, 0x49, 0x44, 0x12, 0x19,
+	0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x79,
+	0x49, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x67, 0x6f, 0x72, 0x65,
+	0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x12, 0x2f, 0x0a, 0x09, 0x54,
+	0x68, 0x69, 0x6e, 0x67, 0x53, 0x61, 0x76, 0x65, 0x12, 0x10, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73,
+	0x74, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x1a, 0x10, 0x2e, 0x67, 0x6f, 0x72,
+	0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x12, 0x47, 0x0a, 0x0f,
+	0x54, 0x68, 0x69, 0x6e, 0x67, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x79, 0x49, 0x44, 0x12,
+	0x1c, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x43, 0x0a, 0x0a, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x73, 0x46,
+	0x69, 0x6e, 0x64, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e,
+	0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x67, 0x6f,
+	0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x73, 0x46, 0x69,
+	0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x0d, 0x57, 0x69,
+	0x64, 0x67, 0x65, 0x74, 0x47, 0x65, 0x74, 0x42, 0x79, 0x49, 0x44, 0x12, 0x19, 0x2e, 0x67, 0x6f,
+	0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x79, 0x49, 0x44, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61,
+	0x70, 0x69, 0x2e, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x12, 0x32, 0x0a, 0x0a, 0x57, 0x69, 0x64,
+	0x67, 0x65, 0x74, 0x53, 0x61, 0x76, 0x65, 0x12, 0x11, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74,
+	0x61, 0x70, 0x69, 0x2e, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x1a, 0x11, 0x2e, 0x67, 0x6f, 0x72,
+	0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x12, 0x48, 0x0a,
+	0x10, 0x57, 0x69, 0x64, 0x67, 0x65, 0x74, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x79, 0x49,
+	0x44, 0x12, 0x1c, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x45, 0x0a, 0x0b, 0x57, 0x69, 0x64, 0x67, 0x65,
+	0x74, 0x73, 0x46, 0x69, 0x6e, 0x64, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61,
+	0x70, 0x69, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e,
+	0x2e, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2e, 0x57, 0x69, 0x64, 0x67, 0x65,
+	0x74, 0x73, 0x46, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x31,
+	0x5a, 0x2f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x73, 0x6e, 0x6f,
+	0x77, 0x7a, 0x61, 0x63, 0x68, 0x2f, 0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2f,
+	0x67, 0x6f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x70, 0x69, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x61, 0x70,
+	0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_gorestapi_grpcapi_gorestapi_proto_rawDescOnce sync.Once
+	file_gorestapi_grpcapi_gorestapi_proto_rawDescData = file_gorestapi_grpcapi_gorestapi_proto_rawDesc
+)
+
+func file_gorestapi_grpcapi_gorestapi_proto_rawDescGZIP() []byte {
+	file_gorestapi_grpcapi_gorestapi_proto_rawDescOnce.Do(func() {
+		file_gorestapi_grpcapi_gorestapi_proto_rawDescData = protoimpl.X.CompressGZIP(file_gorestapi_grpcapi_gorestapi_proto_rawDescData)
+	})
+	return file_gorestapi_grpcapi_gorestapi_proto_rawDescData
+}
+
+var file_gorestapi_grpcapi_gorestapi_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_gorestapi_grpcapi_gorestapi_proto_goTypes = []interface{}{
+	(*Thing)(nil),                 // 0: gorestapi.Thing
+	(*Widget)(nil),                // 1: gorestapi.Widget
+	(*GetByIDRequest)(nil),        // 2: gorestapi.GetByIDRequest
+	(*DeleteByIDRequest)(nil),     // 3: gorestapi.DeleteByIDRequest
+	(*FindRequest)(nil),           // 4: gorestapi.FindRequest
+	(*ThingsFindResponse)(nil),    // 5: gorestapi.ThingsFindResponse
+	(*WidgetsFindResponse)(nil),   // 6: gorestapi.WidgetsFindResponse
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+	(*qppb.QueryParameters)(nil),  // 8: queryp.QueryParameters
+	(*emptypb.Empty)(nil),         // 9: google.protobuf.Empty
+}
+var file_gorestapi_grpcapi_gorestapi_proto_depIdxs = []int32{
+	7,  // 0: gorestapi.Thing.created:type_name -> google.protobuf.Timestamp
+	7,  // 1: gorestapi.Thing.updated:type_name -> google.protobuf.Timestamp
+	7,  // 2: gorestapi.Widget.created:type_name -> google.protobuf.Timestamp
+	7,  // 3: gorestapi.Widget.updated:type_name -> google.protobuf.Timestamp
+	0,  // 4: gorestapi.Widget.thing:type_name -> gorestapi.Thing
+	8,  // 5: gorestapi.FindRequest.query:type_name -> queryp.QueryParameters
+	0,  // 6: gorestapi.ThingsFindResponse.results:type_name -> gorestapi.Thing
+	1,  // 7: gorestapi.WidgetsFindResponse.results:type_name -> gorestapi.Widget
+	2,  // 8: gorestapi.GRStore.ThingGetByID:input_type -> gorestapi.GetByIDRequest
+	0,  // 9: gorestapi.GRStore.ThingSave:input_type -> gorestapi.Thing
+	3,  // 10: gorestapi.GRStore.ThingDeleteByID:input_type -> gorestapi.DeleteByIDRequest
+	4,  // 11: gorestapi.GRStore.ThingsFind:input_type -> gorestapi.FindRequest
+	2,  // 12: gorestapi.GRStore.WidgetGetByID:input_type -> gorestapi.GetByIDRequest
+	1,  // 13: gorestapi.GRStore.WidgetSave:input_type -> gorestapi.Widget
+	3,  // 14: gorestapi.GRStore.WidgetDeleteByID:input_type -> gorestapi.DeleteByIDRequest
+	4,  // 15: gorestapi.GRStore.WidgetsFind:input_type -> gorestapi.FindRequest
+	0,  // 16: gorestapi.GRStore.ThingGetByID:output_type -> gorestapi.Thing
+	0,  // 17: gorestapi.GRStore.ThingSave:output_type -> gorestapi.Thing
+	9,  // 18: gorestapi.GRStore.ThingDeleteByID:output_type -> google.protobuf.Empty
+	5,  // 19: gorestapi.GRStore.ThingsFind:output_type -> gorestapi.ThingsFindResponse
+	1,  // 20: gorestapi.GRStore.WidgetGetByID:output_type -> gorestapi.Widget
+	1,  // 21: gorestapi.GRStore.WidgetSave:output_type -> gorestapi.Widget
+	9,  // 22: gorestapi.GRStore.WidgetDeleteByID:output_type -> google.protobuf.Empty
+	6,  // 23: gorestapi.GRStore.WidgetsFind:output_type -> gorestapi.WidgetsFindResponse
+	16, // [16:24] is the sub-list for method output_type
+	8,  // [8:16] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_gorestapi_grpcapi_gorestapi_proto_init() }
+func file_gorestapi_grpcapi_gorestapi_proto_init() {
+	if File_gorestapi_grpcapi_gorestapi_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gorestapi_grpcapi_gorestapi_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Thing); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gorestapi_grpcapi_gorestapi_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Widget); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gorestapi_grpcapi_gorestapi_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetByIDRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gorestapi_grpcapi_gorestapi_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteByIDRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gorestapi_grpcapi_gorestapi_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FindRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gorestapi_grpcapi_gorestapi_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ThingsFindResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gorestapi_grpcapi_gorestapi_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WidgetsFindResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_gorestapi_grpcapi_gorestapi_proto_msgTypes[1].OneofWrappers = []interface{}{}
+	file_gorestapi_grpcapi_gorestapi_proto_msgTypes[5].OneofWrappers = []interface{}{}
+	file_gorestapi_grpcapi_gorestapi_proto_msgTypes[6].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gorestapi_grpcapi_gorestapi_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gorestapi_grpcapi_gorestapi_proto_goTypes,
+		DependencyIndexes: file_gorestapi_grpcapi_gorestapi_proto_depIdxs,
+		MessageInfos:      file_gorestapi_grpcapi_gorestapi_proto_msgTypes,
+	}.Build()
+	File_gorestapi_grpcapi_gorestapi_proto = out.File
+	file_gorestapi_grpcapi_gorestapi_proto_rawDesc = nil
+	file_gorestapi_grpcapi_gorestapi_proto_goTypes = nil
+	file_gorestapi_grpcapi_gorestapi_proto_depIdxs = nil
+}
@@ -0,0 +1,369 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: gorestapi/grpcapi/gorestapi.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	GRStore_ThingGetByID_FullMethodName     = "/gorestapi.GRStore/ThingGetByID"
+	GRStore_ThingSave_FullMethodName        = "/gorestapi.GRStore/ThingSave"
+	GRStore_ThingDeleteByID_FullMethodName  = "/gorestapi.GRStore/ThingDeleteByID"
+	GRStore_ThingsFind_FullMethodName       = "/gorestapi.GRStore/ThingsFind"
+	GRStore_WidgetGetByID_FullMethodName    = "/gorestapi.GRStore/WidgetGetByID"
+	GRStore_WidgetSave_FullMethodName       = "/gorestapi.GRStore/WidgetSave"
+	GRStore_WidgetDeleteByID_FullMethodName = "/gorestapi.GRStore/WidgetDeleteByID"
+	GRStore_WidgetsFind_FullMethodName      = "/gorestapi.GRStore/WidgetsFind"
+)
+
+// GRStoreClient is the client API for GRStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GRStoreClient interface {
+	ThingGetByID(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*Thing, error)
+	ThingSave(ctx context.Context, in *Thing, opts ...grpc.CallOption) (*Thing, error)
+	ThingDeleteByID(ctx context.Context, in *DeleteByIDRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ThingsFind(ctx context.Context, in *FindRequest, opts ...grpc.CallOption) (*ThingsFindResponse, error)
+	WidgetGetByID(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*Widget, error)
+	WidgetSave(ctx context.Context, in *Widget, opts ...grpc.CallOption) (*Widget, error)
+	WidgetDeleteByID(ctx context.Context, in *DeleteByIDRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	WidgetsFind(ctx context.Context, in *FindRequest, opts ...grpc.CallOption) (*WidgetsFindResponse, error)
+}
+
+type gRStoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGRStoreClient(cc grpc.ClientConnInterface) GRStoreClient {
+	return &gRStoreClient{cc}
+}
+
+func (c *gRStoreClient) ThingGetByID(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*Thing, error) {
+	out := new(Thing)
+	err := c.cc.Invoke(ctx, GRStore_ThingGetByID_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRStoreClient) ThingSave(ctx context.Context, in *Thing, opts ...grpc.CallOption) (*Thing, error) {
+	out := new(Thing)
+	err := c.cc.Invoke(ctx, GRStore_ThingSave_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRStoreClient) ThingDeleteByID(ctx context.Context, in *DeleteByIDRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GRStore_ThingDeleteByID_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRStoreClient) ThingsFind(ctx context.Context, in *FindRequest, opts ...grpc.CallOption) (*ThingsFindResponse, error) {
+	out := new(ThingsFindResponse)
+	err := c.cc.Invoke(ctx, GRStore_ThingsFind_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRStoreClient) WidgetGetByID(ctx context.Context, in *GetByIDRequest, opts ...grpc.CallOption) (*Widget, error) {
+	out := new(Widget)
+	err := c.cc.Invoke(ctx, GRStore_WidgetGetByID_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRStoreClient) WidgetSave(ctx context.Context, in *Widget, opts ...grpc.CallOption) (*Widget, error) {
+	out := new(Widget)
+	err := c.cc.Invoke(ctx, GRStore_WidgetSave_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRStoreClient) WidgetDeleteByID(ctx context.Context, in *DeleteByIDRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GRStore_WidgetDeleteByID_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRStoreClient) WidgetsFind(ctx context.Context, in *FindRequest, opts ...grpc.CallOption) (*WidgetsFindResponse, error) {
+	out := new(WidgetsFindResponse)
+	err := c.cc.Invoke(ctx, GRStore_WidgetsFind_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GRStoreServer is the server API for GRStore service.
+// All implementations must embed UnimplementedGRStoreServer
+// for forward compatibility
+type GRStoreServer interface {
+	ThingGetByID(context.Context, *GetByIDRequest) (*Thing, error)
+	ThingSave(context.Context, *Thing) (*Thing, error)
+	ThingDeleteByID(context.Context, *DeleteByIDRequest) (*emptypb.Empty, error)
+	ThingsFind(context.Context, *FindRequest) (*ThingsFindResponse, error)
+	WidgetGetByID(context.Context, *GetByIDRequest) (*Widget, error)
+	WidgetSave(context.Context, *Widget) (*Widget, error)
+	WidgetDeleteByID(context.Context, *DeleteByIDRequest) (*emptypb.Empty, error)
+	WidgetsFind(context.Context, *FindRequest) (*WidgetsFindResponse, error)
+	mustEmbedUnimplementedGRStoreServer()
+}
+
+// UnimplementedGRStoreServer must be embedded to have forward compatible implementations.
+type UnimplementedGRStoreServer struct {
+}
+
+func (UnimplementedGRStoreServer) ThingGetByID(context.Context, *GetByIDRequest) (*Thing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ThingGetByID not implemented")
+}
+func (UnimplementedGRStoreServer) ThingSave(context.Context, *Thing) (*Thing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ThingSave not implemented")
+}
+func (UnimplementedGRStoreServer) ThingDeleteByID(context.Context, *DeleteByIDRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ThingDeleteByID not implemented")
+}
+func (UnimplementedGRStoreServer) ThingsFind(context.Context, *FindRequest) (*ThingsFindResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ThingsFind not implemented")
+}
+func (UnimplementedGRStoreServer) WidgetGetByID(context.Context, *GetByIDRequest) (*Widget, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WidgetGetByID not implemented")
+}
+func (UnimplementedGRStoreServer) WidgetSave(context.Context, *Widget) (*Widget, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WidgetSave not implemented")
+}
+func (UnimplementedGRStoreServer) WidgetDeleteByID(context.Context, *DeleteByIDRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WidgetDeleteByID not implemented")
+}
+func (UnimplementedGRStoreServer) WidgetsFind(context.Context, *FindRequest) (*WidgetsFindResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WidgetsFind not implemented")
+}
+func (UnimplementedGRStoreServer) mustEmbedUnimplementedGRStoreServer() {}
+
+// UnsafeGRStoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GRStoreServer will
+// result in compilation errors.
+type UnsafeGRStoreServer interface {
+	mustEmbedUnimplementedGRStoreServer()
+}
+
+func RegisterGRStoreServer(s grpc.ServiceRegistrar, srv GRStoreServer) {
+	s.RegisterService(&GRStore_ServiceDesc, srv)
+}
+
+func _GRStore_ThingGetByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRStoreServer).ThingGetByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRStore_ThingGetByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRStoreServer).ThingGetByID(ctx, req.(*GetByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRStore_ThingSave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Thing)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRStoreServer).ThingSave(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRStore_ThingSave_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRStoreServer).ThingSave(ctx, req.(*Thing))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRStore_ThingDeleteByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRStoreServer).ThingDeleteByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRStore_ThingDeleteByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRStoreServer).ThingDeleteByID(ctx, req.(*DeleteByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRStore_ThingsFind_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRStoreServer).ThingsFind(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRStore_ThingsFind_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRStoreServer).ThingsFind(ctx, req.(*FindRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRStore_WidgetGetByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRStoreServer).WidgetGetByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRStore_WidgetGetByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRStoreServer).WidgetGetByID(ctx, req.(*GetByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRStore_WidgetSave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Widget)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRStoreServer).WidgetSave(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRStore_WidgetSave_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRStoreServer).WidgetSave(ctx, req.(*Widget))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRStore_WidgetDeleteByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRStoreServer).WidgetDeleteByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRStore_WidgetDeleteByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRStoreServer).WidgetDeleteByID(ctx, req.(*DeleteByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRStore_WidgetsFind_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRStoreServer).WidgetsFind(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRStore_WidgetsFind_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRStoreServer).WidgetsFind(ctx, req.(*FindRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GRStore_ServiceDesc is the grpc.ServiceDesc for GRStore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GRStore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gorestapi.GRStore",
+	HandlerType: (*GRStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ThingGetByID",
+			Handler:    _GRStore_ThingGetByID_Handler,
+		},
+		{
+			MethodName: "ThingSave",
+			Handler:    _GRStore_ThingSave_Handler,
+		},
+		{
+			MethodName: "ThingDeleteByID",
+			Handler:    _GRStore_ThingDeleteByID_Handler,
+		},
+		{
+			MethodName: "ThingsFind",
+			Handler:    _GRStore_ThingsFind_Handler,
+		},
+		{
+			MethodName: "WidgetGetByID",
+			Handler:    _GRStore_WidgetGetByID_Handler,
+		},
+		{
+			MethodName: "WidgetSave",
+			Handler:    _GRStore_WidgetSave_Handler,
+		},
+		{
+			MethodName: "WidgetDeleteByID",
+			Handler:    _GRStore_WidgetDeleteByID_Handler,
+		},
+		{
+			MethodName: "WidgetsFind",
+			Handler:    _GRStore_WidgetsFind_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "gorestapi/grpcapi/gorestapi.proto",
+}
@@ -0,0 +1,92 @@
+package grpcapi
+
+import (
+	"time"
+
+	"github.com/snowzach/queryp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// ThingPB converts a thing to protobuf
+func ThingPB(thing *gorestapi.Thing) *Thing {
+	if thing == nil {
+		return nil
+	}
+	return &Thing{
+		Id:          thing.ID,
+		Created:     timestampPB(thing.Created),
+		Updated:     timestampPB(thing.Updated),
+		Name:        thing.Name,
+		Description: thing.Description,
+	}
+}
+
+// Thing converts from protobuf to a thing
+func (t *Thing) Thing() *gorestapi.Thing {
+	if t == nil {
+		return nil
+	}
+	return &gorestapi.Thing{
+		ID:          t.Id,
+		Created:     timestamp(t.Created),
+		Updated:     timestamp(t.Updated),
+		Name:        t.Name,
+		Description: t.Description,
+	}
+}
+
+// WidgetPB converts a widget to protobuf
+func WidgetPB(widget *gorestapi.Widget) *Widget {
+	if widget == nil {
+		return nil
+	}
+	return &Widget{
+		Id:          widget.ID,
+		Created:     timestampPB(widget.Created),
+		Updated:     timestampPB(widget.Updated),
+		Name:        widget.Name,
+		Description: widget.Description,
+		ThingId:     widget.ThingID,
+		Thing:       ThingPB(widget.Thing),
+	}
+}
+
+// Widget converts from protobuf to a widget
+func (w *Widget) Widget() *gorestapi.Widget {
+	if w == nil {
+		return nil
+	}
+	return &gorestapi.Widget{
+		ID:          w.Id,
+		Created:     timestamp(w.Created),
+		Updated:     timestamp(w.Updated),
+		Name:        w.Name,
+		Description: w.Description,
+		ThingID:     w.ThingId,
+		Thing:       w.Thing.Thing(),
+	}
+}
+
+// QueryParameters returns the query parameters of the request
+func (r *FindRequest) QueryParameters() *queryp.QueryParameters {
+	if r.GetQuery() == nil {
+		return &queryp.QueryParameters{}
+	}
+	return r.Query.QueryParameters()
+}
+
+func timestampPB(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
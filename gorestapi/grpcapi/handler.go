@@ -0,0 +1,22 @@
+package grpcapi
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// Handler serves gRPC requests with grpcServer and everything else with handler so both can share
+// a port. Plaintext HTTP/2 (h2c) is accepted so gRPC clients can connect without TLS.
+func Handler(grpcServer *grpc.Server, handler http.Handler) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}), &http2.Server{})
+}
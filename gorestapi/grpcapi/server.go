@@ -0,0 +1,125 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/snowzach/golib/log"
+	"github.com/snowzach/golib/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// Server implements the GRStore gRPC service backed by a gorestapi.GRStore
+type Server struct {
+	UnimplementedGRStoreServer
+	logger  *slog.Logger
+	grStore gorestapi.GRStore
+}
+
+// NewServer creates a new gRPC server for the store
+func NewServer(grStore gorestapi.GRStore) *Server {
+	return &Server{
+		logger:  log.Logger.With("context", "grpcapi"),
+		grStore: grStore,
+	}
+}
+
+// ThingGetByID gets a thing
+func (s *Server) ThingGetByID(ctx context.Context, req *GetByIDRequest) (*Thing, error) {
+	thing, err := s.grStore.ThingGetByID(ctx, req.Id)
+	if err != nil {
+		return nil, s.error("ThingGetByID", store.ErrorOpGet, "thing", err)
+	}
+	return ThingPB(thing), nil
+}
+
+// ThingSave saves a thing
+func (s *Server) ThingSave(ctx context.Context, req *Thing) (*Thing, error) {
+	thing := req.Thing()
+	if err := s.grStore.ThingSave(ctx, thing); err != nil {
+		return nil, s.error("ThingSave", store.ErrorOpSave, "thing", err)
+	}
+	return ThingPB(thing), nil
+}
+
+// ThingDeleteByID deletes a thing
+func (s *Server) ThingDeleteByID(ctx context.Context, req *DeleteByIDRequest) (*emptypb.Empty, error) {
+	if err := s.grStore.ThingDeleteByID(ctx, req.Id); err != nil {
+		return nil, s.error("ThingDeleteByID", store.ErrorOpDelete, "thing", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ThingsFind finds things
+func (s *Server) ThingsFind(ctx context.Context, req *FindRequest) (*ThingsFindResponse, error) {
+	things, count, err := s.grStore.ThingsFind(ctx, req.QueryParameters())
+	if err != nil {
+		return nil, s.error("ThingsFind", store.ErrorOpFind, "thing", err)
+	}
+	resp := &ThingsFindResponse{
+		Results: make([]*Thing, 0, len(things)),
+		Count:   count,
+	}
+	for _, thing := range things {
+		resp.Results = append(resp.Results, ThingPB(thing))
+	}
+	return resp, nil
+}
+
+// WidgetGetByID gets a widget
+func (s *Server) WidgetGetByID(ctx context.Context, req *GetByIDRequest) (*Widget, error) {
+	widget, err := s.grStore.WidgetGetByID(ctx, req.Id)
+	if err != nil {
+		return nil, s.error("WidgetGetByID", store.ErrorOpGet, "widget", err)
+	}
+	return WidgetPB(widget), nil
+}
+
+// WidgetSave saves a widget
+func (s *Server) WidgetSave(ctx context.Context, req *Widget) (*Widget, error) {
+	widget := req.Widget()
+	if err := s.grStore.WidgetSave(ctx, widget); err != nil {
+		return nil, s.error("WidgetSave", store.ErrorOpSave, "widget", err)
+	}
+	return WidgetPB(widget), nil
+}
+
+// WidgetDeleteByID deletes a widget
+func (s *Server) WidgetDeleteByID(ctx context.Context, req *DeleteByIDRequest) (*emptypb.Empty, error) {
+	if err := s.grStore.WidgetDeleteByID(ctx, req.Id); err != nil {
+		return nil, s.error("WidgetDeleteByID", store.ErrorOpDelete, "widget", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WidgetsFind finds widgets
+func (s *Server) WidgetsFind(ctx context.Context, req *FindRequest) (*WidgetsFindResponse, error) {
+	widgets, count, err := s.grStore.WidgetsFind(ctx, req.QueryParameters())
+	if err != nil {
+		return nil, s.error("WidgetsFind", store.ErrorOpFind, "widget", err)
+	}
+	resp := &WidgetsFindResponse{
+		Results: make([]*Widget, 0, len(widgets)),
+		Count:   count,
+	}
+	for _, widget := range widgets {
+		resp.Results = append(resp.Results, WidgetPB(widget))
+	}
+	return resp, nil
+}
+
+// error maps store errors to gRPC status errors the same way the REST handlers map them to
+// HTTP status codes
+func (s *Server) error(method string, op store.ErrorOp, resource string, err error) error {
+	if err == store.ErrNotFound {
+		return status.Error(codes.NotFound, resource+" not found")
+	} else if serr, ok := err.(*store.Error); ok {
+		return status.Error(codes.InvalidArgument, serr.ErrorForOp(op).Error())
+	}
+	s.logger.Error(method+" error", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,137 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+	"github.com/snowzach/queryp/qppb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+// newTestClient serves the gRPC server multiplexed with an HTTP handler and returns a client
+func newTestClient(t *testing.T, grStore gorestapi.GRStore) GRStoreClient {
+
+	grpcServer := grpc.NewServer()
+	RegisterGRStoreServer(grpcServer, NewServer(grStore))
+
+	server := httptest.NewServer(Handler(grpcServer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+	t.Cleanup(server.Close)
+
+	conn, err := grpc.Dial(strings.TrimPrefix(server.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	// Everything else is still served by the HTTP handler
+	resp, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	return NewGRStoreClient(conn)
+
+}
+
+func TestThingSave(t *testing.T) {
+
+	// Mock Store and client
+	grs := new(mocks.GRStore)
+	client := newTestClient(t, grs)
+
+	now := time.Now().UTC()
+	grs.On("ThingSave", mock.Anything, &gorestapi.Thing{Name: "name", Description: "description"}).Once().Run(func(args mock.Arguments) {
+		thing := args.Get(1).(*gorestapi.Thing)
+		thing.ID = "id"
+		thing.Created = now
+		thing.Updated = now
+	}).Return(nil)
+
+	thing, err := client.ThingSave(context.Background(), &Thing{Name: "name", Description: "description"})
+	assert.Nil(t, err)
+	assert.Equal(t, &gorestapi.Thing{ID: "id", Created: now, Updated: now, Name: "name", Description: "description"}, thing.Thing())
+
+	// Store errors are invalid arguments
+	grs.On("ThingSave", mock.Anything, mock.Anything).Once().Return(&store.Error{Type: store.ErrorTypeDuplicate, Err: errors.New("duplicate")})
+	_, err = client.ThingSave(context.Background(), &Thing{Name: "name"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestWidgetGetByID(t *testing.T) {
+
+	// Mock Store and client
+	grs := new(mocks.GRStore)
+	client := newTestClient(t, grs)
+
+	thingID := "thing_id"
+	grs.On("WidgetGetByID", mock.Anything, "id").Once().Return(&gorestapi.Widget{ID: "id", Name: "name", ThingID: &thingID, Thing: &gorestapi.Thing{ID: thingID}}, nil)
+	widget, err := client.WidgetGetByID(context.Background(), &GetByIDRequest{Id: "id"})
+	assert.Nil(t, err)
+	assert.Equal(t, "name", widget.Name)
+	assert.Equal(t, thingID, widget.GetThingId())
+	assert.Equal(t, thingID, widget.Thing.Id)
+
+	// Not found and internal errors
+	grs.On("WidgetGetByID", mock.Anything, "missing").Once().Return(nil, store.ErrNotFound)
+	_, err = client.WidgetGetByID(context.Background(), &GetByIDRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	grs.On("WidgetGetByID", mock.Anything, "broken").Once().Return(nil, errors.New("database down"))
+	_, err = client.WidgetGetByID(context.Background(), &GetByIDRequest{Id: "broken"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestThingsFind(t *testing.T) {
+
+	// Mock Store and client
+	grs := new(mocks.GRStore)
+	client := newTestClient(t, grs)
+
+	qp, err := queryp.ParseQuery("name=~test&sort=-created&limit=5&offset=10")
+	assert.Nil(t, err)
+
+	count := int64(1)
+	grs.On("ThingsFind", mock.Anything, mock.MatchedBy(func(q *queryp.QueryParameters) bool {
+		return q.Filter.String() == qp.Filter.String() && len(q.Sort) == 1 && q.Sort[0].Field == "created" && q.Sort[0].Desc && q.Limit == 5 && q.Offset == 10
+	})).Once().Return([]*gorestapi.Thing{{ID: "id", Name: "test"}}, &count, nil)
+
+	resp, err := client.ThingsFind(context.Background(), &FindRequest{Query: qppb.QueryParametersPB(qp)})
+	assert.Nil(t, err)
+	assert.Equal(t, count, resp.GetCount())
+	assert.Len(t, resp.Results, 1)
+	assert.Equal(t, "id", resp.Results[0].Id)
+
+	// An empty request finds everything
+	grs.On("ThingsFind", mock.Anything, &queryp.QueryParameters{}).Once().Return([]*gorestapi.Thing{}, nil, nil)
+	resp, err = client.ThingsFind(context.Background(), &FindRequest{})
+	assert.Nil(t, err)
+	assert.Nil(t, resp.Count)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
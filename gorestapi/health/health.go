@@ -0,0 +1,169 @@
+// Package health serves liveness and readiness endpoints for load balancers and orchestrators.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// Statuses of the readiness response and its checks
+const (
+	StatusOK           = "ok"
+	StatusFailed       = "failed"
+	StatusShuttingDown = "shutting down"
+)
+
+// Checker checks a dependency is available
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is a function Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Response is the body of the readiness endpoint
+type Response struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the result of a check
+type CheckResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+type check struct {
+	name    string
+	checker Checker
+}
+
+// Health tracks the registered checks and whether the server is shutting down
+type Health struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+// New creates a Health where each readiness request runs the checks within timeout
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout}
+}
+
+// Register adds a check to readiness, replacing any check of the same name
+func (h *Health) Register(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.checks {
+		if h.checks[i].name == name {
+			h.checks[i].checker = checker
+			return
+		}
+	}
+	h.checks = append(h.checks, check{name: name, checker: checker})
+}
+
+// Shutdown makes readiness fail so load balancers stop sending traffic
+func (h *Health) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Ready runs every check and returns whether they all passed
+func (h *Health) Ready(ctx context.Context) (bool, Response) {
+
+	if h.shuttingDown.Load() {
+		return false, Response{Status: StatusShuttingDown}
+	}
+
+	h.mu.RLock()
+	checks := make([]check, len(h.checks))
+	copy(checks, h.checks)
+	h.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool { return checks[i].name < checks[j].name })
+
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = run(ctx, checks[i].checker)
+		}(i)
+	}
+	wg.Wait()
+
+	ready := true
+	response := Response{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i := range checks {
+		if results[i].Status != StatusOK {
+			ready = false
+			response.Status = StatusFailed
+		}
+		response.Checks[checks[i].name] = results[i]
+	}
+	return ready, response
+
+}
+
+// run runs a check, giving up when ctx is done even if the checker does not
+func run(ctx context.Context, checker Checker) CheckResult {
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- checker.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{Status: StatusOK, Latency: time.Since(start).String()}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+
+}
+
+// Liveness returns the handler of /healthz that responds ok while the process is serving requests
+func (h *Health) Liveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		render.Encode(w, http.StatusOK, Response{Status: StatusOK})
+	}
+}
+
+// Readiness returns the handler of /readyz that responds 503 when a check fails or the server is shutting down
+func (h *Health) Readiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ready, response := h.Ready(r.Context())
+		status := http.StatusOK
+		if !ready {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		render.Encode(w, status, response)
+	}
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadiness(t *testing.T) {
+
+	h := New(50 * time.Millisecond)
+	h.Register("database", CheckerFunc(func(ctx context.Context) error { return nil }))
+
+	get := func(handler http.HandlerFunc) (int, Response) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		var response Response
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	code, response := get(h.Readiness())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, response.Status)
+	assert.Equal(t, StatusOK, response.Checks["database"].Status)
+	assert.NotEmpty(t, response.Checks["database"].Latency)
+
+	// A failing check
+	h.Register("migrations", CheckerFunc(func(ctx context.Context) error { return errors.New("schema version 1 is behind 2") }))
+	code, response = get(h.Readiness())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFailed, response.Status)
+	assert.Equal(t, StatusOK, response.Checks["database"].Status)
+	assert.Equal(t, CheckResult{Status: StatusFailed, Latency: response.Checks["migrations"].Latency, Error: "schema version 1 is behind 2"}, response.Checks["migrations"])
+
+	// Registering the same name replaces the check, a check that hangs times out
+	h.Register("migrations", CheckerFunc(func(ctx context.Context) error { time.Sleep(time.Second); return nil }))
+	code, response = get(h.Readiness())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Len(t, response.Checks, 2)
+	assert.Equal(t, context.DeadlineExceeded.Error(), response.Checks["migrations"].Error)
+
+	// Shutting down fails readiness but not liveness
+	h.Register("migrations", CheckerFunc(func(ctx context.Context) error { return nil }))
+	code, _ = get(h.Readiness())
+	assert.Equal(t, http.StatusOK, code)
+	h.Shutdown()
+	code, response = get(h.Readiness())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, Response{Status: StatusShuttingDown}, response)
+	code, response = get(h.Liveness())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, Response{Status: StatusOK}, response)
+
+}
@@ -0,0 +1,37 @@
+package gorestapi
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is the stored result of a request made with an Idempotency-Key
+type IdempotencyRecord struct {
+	// Key is the client supplied Idempotency-Key
+	Key string `json:"key"`
+	// Created Timestamp
+	Created time.Time `json:"created"`
+	// Expires Timestamp
+	Expires time.Time `json:"expires"`
+	// Fingerprint of the request that claimed the key
+	Fingerprint string `json:"fingerprint"`
+	// Completed is false while the original request is still in progress
+	Completed bool `json:"completed"`
+	// StatusCode of the stored response
+	StatusCode int `json:"status_code" db:"status_code"`
+	// ContentType of the stored response
+	ContentType string `json:"content_type" db:"content_type"`
+	// Body of the stored response
+	Body []byte `json:"body"`
+}
+
+// IdempotencyStore is the persistent store of idempotency keys and their responses
+type IdempotencyStore interface {
+	// IdempotencyClaim attempts to claim key for a new request. If the key is already
+	// held by an unexpired record, that record is returned and claimed is false.
+	IdempotencyClaim(ctx context.Context, key string, fingerprint string, ttl time.Duration) (record *IdempotencyRecord, claimed bool, err error)
+	// IdempotencyComplete stores the response for a claimed key.
+	IdempotencyComplete(ctx context.Context, record *IdempotencyRecord) error
+	// IdempotencyRelease releases a claimed key so the request can be retried.
+	IdempotencyRelease(ctx context.Context, key string) error
+}
@@ -0,0 +1,13 @@
+package gorestapi
+
+import (
+	"context"
+)
+
+// ImportStore saves records in bulk
+type ImportStore interface {
+	// ThingsSave saves the things in a single transaction
+	ThingsSave(ctx context.Context, things []*Thing) error
+	// WidgetsSave saves the widgets in a single transaction
+	WidgetsSave(ctx context.Context, widgets []*Widget) error
+}
@@ -0,0 +1,407 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// Row actions
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+	ActionFailed    = "failed"
+)
+
+// Config configures the importer
+type Config struct {
+	// How many rows to save in each transaction
+	BatchSize int `conf:"batch_size"`
+}
+
+// InputError is returned when the input can't be read
+type InputError struct {
+	Err error
+}
+
+func (e *InputError) Error() string { return e.Err.Error() }
+func (e *InputError) Unwrap() error { return e.Err }
+
+// Result is the outcome of an import
+type Result struct {
+	DryRun    bool `json:"dry_run"`
+	Rows      int  `json:"rows"`
+	Created   int  `json:"created"`
+	Updated   int  `json:"updated"`
+	Unchanged int  `json:"unchanged"`
+	Failed    int  `json:"failed"`
+	// Report has every row that was (or would be with a dry run) created, updated or failed
+	Report []*RowResult `json:"report"`
+}
+
+// RowResult is the outcome of a row
+type RowResult struct {
+	Line   int    `json:"line"`
+	ID     string `json:"id,omitempty"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// add counts a row result
+func (r *Result) add(rr *RowResult) {
+	r.Rows++
+	switch rr.Action {
+	case ActionCreated:
+		r.Created++
+	case ActionUpdated:
+		r.Updated++
+	case ActionUnchanged:
+		r.Unchanged++
+		return
+	case ActionFailed:
+		r.Failed++
+	}
+	r.Report = append(r.Report, rr)
+}
+
+// WriteCSV writes the report as CSV
+func (r *Result) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"line", "id", "action", "error"})
+	for _, rr := range r.Report {
+		_ = cw.Write([]string{strconv.Itoa(rr.Line), rr.ID, rr.Action, rr.Error})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Importer imports things and widgets
+type Importer struct {
+	grStore     gorestapi.GRStore
+	importStore gorestapi.ImportStore
+	config      Config
+}
+
+// New creates a new importer
+func New(grStore gorestapi.GRStore, importStore gorestapi.ImportStore, config Config) *Importer {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	return &Importer{
+		grStore:     grStore,
+		importStore: importStore,
+		config:      config,
+	}
+}
+
+// pending is a row waiting to be saved with the rest of its batch
+type pending[T any] struct {
+	result *RowResult
+	record *T
+	ref    *thingRef
+}
+
+// Things imports things in the format from r. Rows are validated and saved in batches, rows that fail are reported
+// in the result. With dryRun nothing is saved. An InputError is returned if the input can't be read, other errors
+// are from the store.
+func (i *Importer) Things(ctx context.Context, format string, r io.Reader, dryRun bool) (*Result, error) {
+	return run(ctx, i, format, r, dryRun, thingColumns,
+		func(rec *record) *gorestapi.Thing {
+			return &gorestapi.Thing{ID: rec.ID, Name: rec.Name, Description: rec.Description}
+		},
+		func(thing *gorestapi.Thing) string { return thing.ID },
+		i.thingsPrepare,
+		i.importStore.ThingsSave,
+	)
+}
+
+// Widgets imports widgets like Things. The thing of a widget can be given by thing_id or by thing.name.
+func (i *Importer) Widgets(ctx context.Context, format string, r io.Reader, dryRun bool) (*Result, error) {
+	return run(ctx, i, format, r, dryRun, widgetColumns,
+		func(rec *record) *gorestapi.Widget {
+			return &gorestapi.Widget{ID: rec.ID, Name: rec.Name, Description: rec.Description, ThingID: rec.ThingID}
+		},
+		func(widget *gorestapi.Widget) string { return widget.ID },
+		i.widgetsPrepare,
+		i.importStore.WidgetsSave,
+	)
+}
+
+// run reads, prepares and saves the rows in batches
+func run[T any](ctx context.Context, i *Importer, format string, r io.Reader, dryRun bool, cols columns,
+	newRecord func(*record) *T,
+	id func(*T) string,
+	prepare func(ctx context.Context, batch []*pending[T]) error,
+	save func(ctx context.Context, records []*T) error,
+) (*Result, error) {
+
+	rr, err := newReader(format, r, cols)
+	if err != nil {
+		return nil, &InputError{Err: err}
+	}
+
+	result := &Result{DryRun: dryRun}
+	seen := make(map[string]int)
+	batch := make([]*pending[T], 0, i.config.BatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := saveBatch(ctx, batch, dryRun, id, prepare, save); err != nil {
+			return err
+		}
+		for _, p := range batch {
+			result.add(p.result)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		row, err := rr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, &InputError{Err: fmt.Errorf("could not read %s: %w", format, err)}
+		}
+
+		// Validate the row
+		if row.err == nil {
+			if strings.TrimSpace(row.record.Name) == "" {
+				row.err = errors.New("name is required")
+			} else if line, ok := seen[row.record.ID]; ok && row.record.ID != "" {
+				row.err = fmt.Errorf("duplicate id on line %d", line)
+			}
+		}
+		if row.err != nil {
+			var id string
+			if row.record != nil {
+				id = row.record.ID
+			}
+			result.add(&RowResult{Line: row.line, ID: id, Action: ActionFailed, Error: row.err.Error()})
+			continue
+		}
+		if row.record.ID != "" {
+			seen[row.record.ID] = row.line
+		}
+
+		batch = append(batch, &pending[T]{
+			result: &RowResult{Line: row.line, ID: row.record.ID},
+			record: newRecord(row.record),
+			ref:    row.record.Thing,
+		})
+		if len(batch) >= i.config.BatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	// Rows that fail validation are reported before the rest of their batch
+	sort.SliceStable(result.Report, func(i, j int) bool { return result.Report[i].Line < result.Report[j].Line })
+
+	return result, nil
+
+}
+
+// saveBatch prepares the batch, setting the action of every row, and saves the created and updated records.
+// If the store rejects the batch every row being saved fails.
+func saveBatch[T any](ctx context.Context, batch []*pending[T], dryRun bool,
+	id func(*T) string,
+	prepare func(ctx context.Context, batch []*pending[T]) error,
+	save func(ctx context.Context, records []*T) error,
+) error {
+
+	if err := prepare(ctx, batch); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+
+	var saving []*pending[T]
+	records := make([]*T, 0, len(batch))
+	for _, p := range batch {
+		if p.result.Action == ActionCreated || p.result.Action == ActionUpdated {
+			saving = append(saving, p)
+			records = append(records, p.record)
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	if err := save(ctx, records); err != nil {
+		var serr *store.Error
+		if !errors.As(err, &serr) {
+			return fmt.Errorf("could not save batch: %w", err)
+		}
+		for _, p := range saving {
+			p.result.Action = ActionFailed
+			p.result.Error = serr.ErrorForOp(store.ErrorOpSave).Error()
+		}
+		return nil
+	}
+
+	// Report the ids of created records
+	for _, p := range saving {
+		p.result.ID = id(p.record)
+	}
+	return nil
+
+}
+
+// fail marks a row as failed
+func (p *pending[T]) fail(format string, args ...any) {
+	p.result.Action = ActionFailed
+	p.result.Error = fmt.Sprintf(format, args...)
+}
+
+// thingsPrepare sets the action of each thing by comparing it to the existing thing
+func (i *Importer) thingsPrepare(ctx context.Context, batch []*pending[gorestapi.Thing]) error {
+
+	var ids []string
+	for _, p := range batch {
+		if p.record.ID != "" {
+			ids = append(ids, p.record.ID)
+		}
+	}
+	existing, err := i.thingsByField(ctx, "thing.id", ids)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range batch {
+		if current, ok := existing[p.record.ID]; !ok {
+			p.result.Action = ActionCreated
+		} else if current[0].Name == p.record.Name && current[0].Description == p.record.Description {
+			p.result.Action = ActionUnchanged
+		} else {
+			p.result.Action = ActionUpdated
+		}
+	}
+	return nil
+
+}
+
+// widgetsPrepare resolves the thing of each widget and sets the action by comparing it to the existing widget
+func (i *Importer) widgetsPrepare(ctx context.Context, batch []*pending[gorestapi.Widget]) error {
+
+	// Resolve things by id or name
+	var thingIDs, thingNames, ids []string
+	for _, p := range batch {
+		if p.record.ThingID != nil {
+			thingIDs = append(thingIDs, *p.record.ThingID)
+		} else if p.ref != nil && p.ref.Name != "" {
+			thingNames = append(thingNames, p.ref.Name)
+		}
+		if p.record.ID != "" {
+			ids = append(ids, p.record.ID)
+		}
+	}
+	thingsByID, err := i.thingsByField(ctx, "thing.id", thingIDs)
+	if err != nil {
+		return err
+	}
+	thingsByName, err := i.thingsByField(ctx, "thing.name", thingNames)
+	if err != nil {
+		return err
+	}
+	for _, p := range batch {
+		if p.record.ThingID != nil {
+			if _, ok := thingsByID[*p.record.ThingID]; !ok {
+				p.fail("thing %s not found", *p.record.ThingID)
+			}
+		} else if p.ref != nil && p.ref.Name != "" {
+			switch things := thingsByName[p.ref.Name]; len(things) {
+			case 0:
+				p.fail("no thing named %s", p.ref.Name)
+			case 1:
+				p.record.ThingID = &things[0].ID
+			default:
+				p.fail("%d things named %s", len(things), p.ref.Name)
+			}
+		}
+	}
+
+	// Compare to the existing widgets
+	existing := make(map[string]*gorestapi.Widget)
+	if len(ids) > 0 {
+		widgets, _, err := i.grStore.WidgetsFind(ctx, &queryp.QueryParameters{
+			Filter: queryp.Filter{{Field: "widget.id", Op: queryp.FilterOpEquals, Value: anySlice(ids)}},
+		})
+		if err != nil {
+			return fmt.Errorf("could not find widgets: %w", err)
+		}
+		for _, widget := range widgets {
+			existing[widget.ID] = widget
+		}
+	}
+	for _, p := range batch {
+		if p.result.Action == ActionFailed {
+			continue
+		}
+		if current, ok := existing[p.record.ID]; !ok {
+			p.result.Action = ActionCreated
+		} else if current.Name == p.record.Name && current.Description == p.record.Description && equalPtr(current.ThingID, p.record.ThingID) {
+			p.result.Action = ActionUnchanged
+		} else {
+			p.result.Action = ActionUpdated
+		}
+	}
+	return nil
+
+}
+
+// thingsByField finds the things with any of the values of the field grouped by value
+func (i *Importer) thingsByField(ctx context.Context, field string, values []string) (map[string][]*gorestapi.Thing, error) {
+	ret := make(map[string][]*gorestapi.Thing)
+	if len(values) == 0 {
+		return ret, nil
+	}
+	things, _, err := i.grStore.ThingsFind(ctx, &queryp.QueryParameters{
+		Filter: queryp.Filter{{Field: field, Op: queryp.FilterOpEquals, Value: anySlice(values)}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not find things: %w", err)
+	}
+	for _, thing := range things {
+		key := thing.ID
+		if field == "thing.name" {
+			key = thing.Name
+		}
+		ret[key] = append(ret[key], thing)
+	}
+	return ret, nil
+}
+
+// anySlice converts values to the slice type used by queryp for lists
+func anySlice(values []string) []any {
+	ret := make([]any, len(values))
+	for i, value := range values {
+		ret[i] = value
+	}
+	return ret
+}
+
+func equalPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,153 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+// filterValues returns a matcher for a find with a single filter on the field with the values
+func filterValues(field string, values ...any) any {
+	return mock.MatchedBy(func(qp *queryp.QueryParameters) bool {
+		return len(qp.Filter) == 1 && qp.Filter[0].Field == field && assert.ObjectsAreEqual(values, qp.Filter[0].Value)
+	})
+}
+
+func TestThingsCSV(t *testing.T) {
+
+	grs := new(mocks.GRStore)
+	is := new(mocks.ImportStore)
+	imp := New(grs, is, Config{BatchSize: 10})
+
+	input := "id,created,name,description\n" +
+		",,new,created\n" +
+		"t1,2023-01-01T00:00:00Z,same,unchanged\n" +
+		"t2,,changed,updated\n" +
+		"t3,,,missing name\n" +
+		"t2,,dup,duplicate\n" +
+		"t4,too,many,columns,here\n"
+
+	grs.On("ThingsFind", mock.Anything, filterValues("thing.id", "t1", "t2")).Once().Return([]*gorestapi.Thing{
+		{ID: "t1", Name: "same", Description: "unchanged"},
+		{ID: "t2", Name: "original", Description: "updated"},
+	}, nil, nil)
+	is.On("ThingsSave", mock.Anything, []*gorestapi.Thing{
+		{Name: "new", Description: "created"},
+		{ID: "t2", Name: "changed", Description: "updated"},
+	}).Once().Run(func(args mock.Arguments) {
+		args.Get(1).([]*gorestapi.Thing)[0].ID = "generated"
+	}).Return(nil)
+
+	result, err := imp.Things(context.Background(), FormatCSV, strings.NewReader(input), false)
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{
+		Rows:      6,
+		Created:   1,
+		Updated:   1,
+		Unchanged: 1,
+		Failed:    3,
+		Report: []*RowResult{
+			{Line: 2, ID: "generated", Action: ActionCreated},
+			{Line: 4, ID: "t2", Action: ActionUpdated},
+			{Line: 5, ID: "t3", Action: ActionFailed, Error: "name is required"},
+			{Line: 6, ID: "t2", Action: ActionFailed, Error: "duplicate id on line 4"},
+			{Line: 7, Action: ActionFailed, Error: "expected 4 columns, got 5"},
+		},
+	}, result)
+
+	var report strings.Builder
+	assert.Nil(t, result.WriteCSV(&report))
+	assert.Equal(t, "line,id,action,error\n2,generated,created,\n4,t2,updated,\n5,t3,failed,name is required\n6,t2,failed,duplicate id on line 4\n7,,failed,\"expected 4 columns, got 5\"\n", report.String())
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+	is.AssertExpectations(t)
+
+}
+
+func TestWidgetsNDJSON(t *testing.T) {
+
+	grs := new(mocks.GRStore)
+	is := new(mocks.ImportStore)
+	imp := New(grs, is, Config{BatchSize: 2})
+
+	input := `{"id":"w1","name":"by id","thing_id":"t1"}
+{"name":"by name","thing":{"name":"thing one"}}
+
+{"name":"ambiguous","thing":{"name":"twins"}}
+{"name":"missing","thing_id":"t9"}
+not json
+`
+	// First batch
+	grs.On("ThingsFind", mock.Anything, filterValues("thing.id", "t1")).Once().Return([]*gorestapi.Thing{{ID: "t1"}}, nil, nil)
+	grs.On("ThingsFind", mock.Anything, filterValues("thing.name", "thing one")).Once().Return([]*gorestapi.Thing{{ID: "t1", Name: "thing one"}}, nil, nil)
+	grs.On("WidgetsFind", mock.Anything, filterValues("widget.id", "w1")).Once().Return([]*gorestapi.Widget{}, nil, nil)
+
+	// Second batch
+	grs.On("ThingsFind", mock.Anything, filterValues("thing.id", "t9")).Once().Return([]*gorestapi.Thing{}, nil, nil)
+	grs.On("ThingsFind", mock.Anything, filterValues("thing.name", "twins")).Once().Return([]*gorestapi.Thing{{ID: "t2", Name: "twins"}, {ID: "t3", Name: "twins"}}, nil, nil)
+
+	// Nothing is saved on a dry run
+	result, err := imp.Widgets(context.Background(), FormatNDJSON, strings.NewReader(input), true)
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{
+		DryRun:  true,
+		Rows:    5,
+		Created: 2,
+		Failed:  3,
+		Report: []*RowResult{
+			{Line: 1, ID: "w1", Action: ActionCreated},
+			{Line: 2, Action: ActionCreated},
+			{Line: 4, Action: ActionFailed, Error: "2 things named twins"},
+			{Line: 5, Action: ActionFailed, Error: "thing t9 not found"},
+			{Line: 6, Action: ActionFailed, Error: "invalid json: invalid character 'o' in literal null (expecting 'u')"},
+		},
+	}, result)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+	is.AssertExpectations(t)
+
+}
+
+func TestImportErrors(t *testing.T) {
+
+	grs := new(mocks.GRStore)
+	is := new(mocks.ImportStore)
+	imp := New(grs, is, Config{})
+
+	// Input errors
+	var ierr *InputError
+	_, err := imp.Things(context.Background(), FormatCSV, strings.NewReader("id,nope\n"), false)
+	assert.ErrorAs(t, err, &ierr)
+	_, err = imp.Things(context.Background(), FormatCSV, strings.NewReader("id\n"), false)
+	assert.ErrorAs(t, err, &ierr)
+	_, err = imp.Things(context.Background(), "xml", strings.NewReader(""), false)
+	assert.ErrorAs(t, err, &ierr)
+
+	// Store rejections fail the rows in the batch, other errors stop the import
+	is.On("ThingsSave", mock.Anything, mock.Anything).Once().Return(&store.Error{Type: store.ErrorTypeDuplicate, Err: errors.New("duplicate")})
+	result, err := imp.Things(context.Background(), FormatCSV, strings.NewReader("name\none\n"), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, ActionFailed, result.Report[0].Action)
+
+	is.On("ThingsSave", mock.Anything, mock.Anything).Once().Return(errors.New("database down"))
+	_, err = imp.Things(context.Background(), FormatCSV, strings.NewReader("name\none\n"), false)
+	assert.NotNil(t, err)
+	assert.False(t, errors.As(err, &ierr))
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+	is.AssertExpectations(t)
+
+}
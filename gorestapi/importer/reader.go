@@ -0,0 +1,212 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Import formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// maxLineSize is the longest NDJSON line that can be read
+const maxLineSize = 1 << 20
+
+// FormatForContentType returns the format for a content type or an empty string if it is not supported
+func FormatForContentType(contentType string) string {
+	switch strings.TrimSpace(strings.Split(contentType, ";")[0]) {
+	case "text/csv":
+		return FormatCSV
+	case "application/x-ndjson":
+		return FormatNDJSON
+	}
+	return ""
+}
+
+// FormatForFilename returns the format for a filename by extension or an empty string if it is not supported
+func FormatForFilename(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	return ""
+}
+
+// record is an imported row. Both formats use the same field names as the export so exports can be imported.
+type record struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ThingID     *string   `json:"thing_id"`
+	Thing       *thingRef `json:"thing"`
+}
+
+// thingRef refers to the thing of a widget by id or name
+type thingRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// row is a record read from the input. Errors in a single row are returned in the row rather than stopping the import.
+type row struct {
+	line   int
+	record *record
+	err    error
+}
+
+// reader reads rows. It returns io.EOF when there are no more rows.
+type reader interface {
+	Next() (*row, error)
+}
+
+// columns maps csv columns to the record
+type columns struct {
+	// fields that are imported
+	fields map[string]func(rec *record, value string)
+	// ignored fields are in exports but can't be imported, ie. created
+	ignored []string
+}
+
+var thingColumns = columns{
+	fields: map[string]func(rec *record, value string){
+		"id":          func(rec *record, value string) { rec.ID = value },
+		"name":        func(rec *record, value string) { rec.Name = value },
+		"description": func(rec *record, value string) { rec.Description = value },
+	},
+	ignored: []string{"created", "updated"},
+}
+
+var widgetColumns = columns{
+	fields: map[string]func(rec *record, value string){
+		"id":          func(rec *record, value string) { rec.ID = value },
+		"name":        func(rec *record, value string) { rec.Name = value },
+		"description": func(rec *record, value string) { rec.Description = value },
+		"thing_id": func(rec *record, value string) {
+			if value != "" {
+				rec.ThingID = &value
+			}
+		},
+		"thing.id": func(rec *record, value string) {
+			if value != "" && rec.ThingID == nil {
+				rec.ThingID = &value
+			}
+		},
+		"thing.name": func(rec *record, value string) {
+			if value != "" {
+				rec.Thing = &thingRef{Name: value}
+			}
+		},
+	},
+	ignored: []string{"created", "updated", "thing.created", "thing.updated", "thing.description"},
+}
+
+// newReader creates a reader for the format
+func newReader(format string, r io.Reader, cols columns) (reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r, cols)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &ndjsonReader{scanner: scanner}, nil
+	}
+	return nil, fmt.Errorf("unknown format %s", format)
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	fields []func(rec *record, value string)
+}
+
+// newCSVReader reads the header and maps the columns. Unknown columns are an error.
+func newCSVReader(r io.Reader, cols columns) (*csvReader, error) {
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing header")
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read header: %w", err)
+	}
+
+	var hasName bool
+	fields := make([]func(rec *record, value string), len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if field, ok := cols.fields[name]; ok {
+			fields[i] = field
+			hasName = hasName || name == "name"
+		} else if !slices.Contains(cols.ignored, name) {
+			return nil, fmt.Errorf("unknown column %s", name)
+		}
+	}
+	if !hasName {
+		return nil, errors.New("missing name column")
+	}
+
+	return &csvReader{r: cr, fields: fields}, nil
+
+}
+
+func (cr *csvReader) Next() (*row, error) {
+	values, err := cr.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	line, _ := cr.r.FieldPos(0)
+	if len(values) != len(cr.fields) {
+		return &row{line: line, err: fmt.Errorf("expected %d columns, got %d", len(cr.fields), len(values))}, nil
+	}
+	rec := new(record)
+	for i, value := range values {
+		if cr.fields[i] != nil {
+			cr.fields[i](rec, value)
+		}
+	}
+	return &row{line: line, record: rec}, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (nr *ndjsonReader) Next() (*row, error) {
+	for nr.scanner.Scan() {
+		nr.line++
+		b := bytes.TrimSpace(nr.scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		rec := new(record)
+		if err := json.Unmarshal(b, rec); err != nil {
+			return &row{line: nr.line, err: fmt.Errorf("invalid json: %w", err)}, nil
+		}
+		if rec.ThingID != nil && *rec.ThingID == "" {
+			rec.ThingID = nil
+		}
+		if rec.ThingID == nil && rec.Thing != nil && rec.Thing.ID != "" {
+			rec.ThingID = &rec.Thing.ID
+		}
+		return &row{line: nr.line, record: rec}, nil
+	}
+	if err := nr.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", nr.line+1, err)
+	}
+	return nil, io.EOF
+}
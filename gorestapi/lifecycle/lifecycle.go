@@ -0,0 +1,113 @@
+// Package lifecycle stops the parts of the server in order when it shuts down.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Lifecycle runs shutdown stages in the order they were added
+type Lifecycle struct {
+	logger *slog.Logger
+	mu     sync.Mutex
+	stages []stage
+}
+
+type stage struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+// New creates a Lifecycle logging each stage to logger
+func New(logger *slog.Logger) *Lifecycle {
+	return &Lifecycle{logger: logger}
+}
+
+// Add adds a stage that runs after the stages already added. The context of stop is canceled after timeout, 0 for
+// no timeout.
+func (l *Lifecycle) Add(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stages = append(l.stages, stage{name: name, timeout: timeout, stop: stop})
+}
+
+// Shutdown runs every stage in order, even when one fails, and returns the errors of the stages
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+
+	l.mu.Lock()
+	stages := make([]stage, len(l.stages))
+	copy(stages, l.stages)
+	l.mu.Unlock()
+
+	start := time.Now()
+	var errs []error
+	for _, stage := range stages {
+		stageStart := time.Now()
+		if err := stage.run(ctx); err != nil {
+			l.logger.Error("Shutdown stage failed", "stage", stage.name, "duration", time.Since(stageStart).String(), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", stage.name, err))
+			continue
+		}
+		l.logger.Info("Shutdown stage complete", "stage", stage.name, "duration", time.Since(stageStart).String())
+	}
+	l.logger.Info("Shutdown complete", "duration", time.Since(start).String())
+	return errors.Join(errs...)
+
+}
+
+func (s stage) run(ctx context.Context) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	return s.stop(ctx)
+}
+
+// Group runs background workers until it is stopped
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewGroup creates a new group
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in the background with a context that is canceled when the group is stopped
+func (g *Group) Go(fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+}
+
+// Context returns the context that is canceled when the group is stopped
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Stop cancels the workers and waits for them to return or ctx to be done
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers did not stop: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,63 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdown(t *testing.T) {
+
+	l := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var order []string
+	l.Add("server", time.Second, func(ctx context.Context) error {
+		order = append(order, "server")
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "the stage has a deadline")
+		return nil
+	})
+	l.Add("workers", 10*time.Millisecond, func(ctx context.Context) error {
+		order = append(order, "workers")
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	l.Add("database", 0, func(ctx context.Context) error {
+		order = append(order, "database")
+		return errors.New("close error")
+	})
+
+	err := l.Shutdown(context.Background())
+	assert.Equal(t, []string{"server", "workers", "database"}, order, "stages run in order even when one fails")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualError(t, err, "workers: context deadline exceeded\ndatabase: close error")
+
+}
+
+func TestGroup(t *testing.T) {
+
+	g := NewGroup()
+	stopped := make(chan struct{})
+	g.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+	assert.Nil(t, g.Stop(context.Background()))
+	assert.Equal(t, context.Canceled, g.Context().Err())
+	<-stopped
+
+	// A worker that does not stop
+	g = NewGroup()
+	release := make(chan struct{})
+	defer close(release)
+	g.Go(func(ctx context.Context) { <-release })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, g.Stop(ctx), context.DeadlineExceeded)
+
+}
@@ -0,0 +1,263 @@
+package mainrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// defaultBatchMaxRequests is the most requests in a batch if not configured
+const defaultBatchMaxRequests = 100
+
+// Batch is a list of requests to run in one round trip
+type Batch struct {
+	// Atomic runs the requests in a single transaction that is rolled back if any request fails
+	Atomic   bool            `json:"atomic"`
+	Requests []*BatchRequest `json:"requests"`
+}
+
+// BatchRequest is a request in a batch
+type BatchRequest struct {
+	// Method is the HTTP method, ie. GET
+	Method string `json:"method"`
+	// Path is the path and query of the request, ie. /api/things?limit=10
+	Path string `json:"path"`
+	// Headers of the request. The Accept header defaults to application/json.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is sent as JSON
+	Body any `json:"body,omitempty" swaggertype:"object"`
+}
+
+// BatchResponse is the response to a request in a batch
+type BatchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is the decoded JSON response or a string if the response is not JSON
+	Body any `json:"body,omitempty" swaggertype:"object"`
+}
+
+// errBatchFailed stops an atomic batch when a request fails
+var errBatchFailed = errors.New("batch request failed")
+
+// Batch runs many requests in one round trip
+//
+// @ID Batch
+// @Tags Batch
+// @Summary Batch requests
+// @Description Run a list of requests in order and return their responses. Atomic batches run in a single transaction and stop at the first failed request.
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml
+// @Param batch body Batch true "Batch or a list of requests"
+// @Param atomic query bool false "Atomic for a list of requests"
+// @Success 200 {array} BatchResponse
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /batch [post]
+func (s *Server) Batch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		batch, err := decodeBatch(r)
+		if err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+		if len(batch.Requests) > s.batchMaxRequests {
+			render.ErrInvalidRequest(w, fmt.Errorf("batch exceeds %d requests", s.batchMaxRequests))
+			return
+		}
+		if batch.Atomic && s.txStore == nil {
+			render.ErrInvalidRequest(w, errors.New("atomic batches are not supported"))
+			return
+		}
+		for i, req := range batch.Requests {
+			if err := req.validate(batch.Atomic); err != nil {
+				render.ErrInvalidRequest(w, fmt.Errorf("request %d: %w", i, err))
+				return
+			}
+		}
+
+		responses := make([]*BatchResponse, len(batch.Requests))
+		if !batch.Atomic {
+			for i, req := range batch.Requests {
+				responses[i] = s.batchServe(ctx, req)
+			}
+			render.Encode(w, http.StatusOK, responses)
+			return
+		}
+
+		// Stop at the first failure so the transaction is rolled back
+		err = s.txStore.WithTransaction(ctx, nil, func(ctx context.Context) error {
+			for i, req := range batch.Requests {
+				responses[i] = s.batchServe(ctx, req)
+				if responses[i].Status >= http.StatusBadRequest {
+					return errBatchFailed
+				}
+			}
+			return nil
+		})
+		if err != nil && err != errBatchFailed {
+			requestID := middleware.GetReqID(ctx)
+			render.ErrInternalWithID(w, requestID, nil)
+			s.logger.Error("Batch error", "error", err, "request_id", requestID)
+			return
+		}
+
+		// Everything else in a failed batch was rolled back or not run
+		if err == errBatchFailed {
+			for i, resp := range responses {
+				if resp == nil || resp.Status < http.StatusBadRequest {
+					responses[i] = &BatchResponse{
+						Status: http.StatusFailedDependency,
+						Body:   render.ErrResponse{Status: "failed dependency", Error: "another request in the atomic batch failed"},
+					}
+				}
+			}
+		}
+
+		render.Encode(w, http.StatusOK, responses)
+
+	}
+}
+
+// decodeBatch decodes a batch or a bare list of requests. Atomic of a list is from the atomic query parameter.
+func decodeBatch(r *http.Request) (*Batch, error) {
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := new(Batch)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err = render.Decode(r, batch); err == nil {
+		return batch, nil
+	}
+	batch = new(Batch)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if render.Decode(r, &batch.Requests) != nil {
+		return nil, err
+	}
+
+	if value := r.URL.Query().Get("atomic"); value != "" {
+		if batch.Atomic, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid atomic: %s", value)
+		}
+	}
+	return batch, nil
+
+}
+
+// validate checks the request can be run in a batch
+func (req *BatchRequest) validate(atomic bool) error {
+	switch req.Method = strings.ToUpper(req.Method); req.Method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return fmt.Errorf("invalid method %q", req.Method)
+	}
+	requestPath, _, _ := strings.Cut(req.Path, "?")
+	if !strings.HasPrefix(requestPath, apiPath+"/") {
+		return fmt.Errorf("path must start with %s/", apiPath)
+	}
+	switch path.Clean(requestPath) {
+	case apiPath + "/batch":
+		return errors.New("batches can not be nested")
+	case apiPath + "/events", apiPath + "/ws":
+		return errors.New("streams can not be batched")
+	case apiPath + "/graphql":
+		// Resolvers run in parallel and can't share the transaction
+		if atomic {
+			return errors.New("graphql is not supported in atomic batches")
+		}
+	}
+	// Keys are stored outside the transaction so a rolled back request would replay as a success, the key can be
+	// set on the batch request instead
+	if atomic {
+		for name := range req.Headers {
+			if http.CanonicalHeaderKey(name) == IdempotencyKeyHeader {
+				return fmt.Errorf("%s is not supported on requests of atomic batches, set it on the batch", IdempotencyKeyHeader)
+			}
+		}
+	}
+	return nil
+}
+
+// batchServe runs the request through the router
+func (s *Server) batchServe(ctx context.Context, req *BatchRequest) *BatchResponse {
+
+	var body bytes.Buffer
+	if req.Body != nil {
+		if err := json.NewEncoder(&body).Encode(req.Body); err != nil {
+			return &BatchResponse{Status: http.StatusBadRequest, Body: render.ErrResponse{Status: "invalid request", Error: err.Error()}}
+		}
+	}
+
+	// Clear the route context of the batch so the router routes the request from the start
+	ctx = context.WithValue(ctx, chi.RouteCtxKey, (*chi.Context)(nil))
+	r, err := http.NewRequestWithContext(ctx, req.Method, req.Path, &body)
+	if err != nil {
+		return &BatchResponse{Status: http.StatusBadRequest, Body: render.ErrResponse{Status: "invalid request", Error: err.Error()}}
+	}
+	for name, value := range req.Headers {
+		r.Header.Set(name, value)
+	}
+	if r.Header.Get("Accept") == "" {
+		r.Header.Set("Accept", render.ContentTypeJSON)
+	}
+	if req.Body != nil && r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", render.ContentTypeJSON)
+	}
+
+	w := &batchResponseWriter{header: make(http.Header)}
+	s.router.ServeHTTP(w, r)
+
+	resp := &BatchResponse{Status: w.status, Headers: make(map[string]string)}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	for name := range w.header {
+		resp.Headers[name] = w.header.Get(name)
+	}
+	if w.body.Len() > 0 {
+		if err := json.Unmarshal(w.body.Bytes(), &resp.Body); err != nil {
+			resp.Body = w.body.String()
+		}
+	}
+	return resp
+
+}
+
+// batchResponseWriter records the response to a request in a batch
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
@@ -0,0 +1,166 @@
+package mainrpc
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/snowzach/golib/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+// batchTxKey marks the context of the test transaction
+type batchTxKey struct{}
+
+func TestBatch(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs, WithBatch(nil, 3))
+	assert.Nil(t, err)
+
+	grs.On("ThingGetByID", mock.Anything, "t1").Once().Return(&gorestapi.Thing{ID: "t1", Name: "thing"}, nil)
+	grs.On("ThingGetByID", mock.Anything, "t2").Once().Return(nil, store.ErrNotFound)
+	grs.On("WidgetSave", mock.Anything, &gorestapi.Widget{Name: "widget"}).Once().Return(nil)
+
+	e := httpexpect.New(t, server.URL)
+	arr := e.POST("/api/batch").WithJSON(map[string]any{
+		"requests": []map[string]any{
+			{"method": "get", "path": "/api/things/t1"},
+			{"method": "GET", "path": "/api/things/t2"},
+			{"method": "POST", "path": "/api/widgets", "body": map[string]any{"name": "widget"}},
+		},
+	}).Expect().Status(http.StatusOK).JSON().Array()
+	arr.Length().Equal(3)
+	arr.Element(0).Object().ValueEqual("status", http.StatusOK)
+	arr.Element(0).Path("$.body.name").Equal("thing")
+	arr.Element(0).Object().Value("headers").Object().ValueEqual("Content-Type", "application/json")
+	arr.Element(1).Object().ValueEqual("status", http.StatusNotFound)
+	arr.Element(1).Path("$.body.error").Equal("thing not found")
+	arr.Element(2).Object().ValueEqual("status", http.StatusOK)
+	arr.Element(2).Path("$.body.name").Equal("widget")
+
+	// A bare list of requests
+	grs.On("ThingGetByID", mock.Anything, "t3").Once().Return(&gorestapi.Thing{ID: "t3", Name: "thing"}, nil)
+	arr = e.POST("/api/batch").WithJSON([]map[string]any{{"method": "GET", "path": "/api/things/t3"}}).
+		Expect().Status(http.StatusOK).JSON().Array()
+	arr.Length().Equal(1)
+	arr.Element(0).Path("$.body.id").Equal("t3")
+
+	// Invalid batches
+	e.POST("/api/batch").WithJSON(map[string]any{"requests": []map[string]any{{"method": "GET", "path": "/version"}}}).
+		Expect().Status(http.StatusBadRequest)
+	e.POST("/api/batch").WithJSON(map[string]any{"requests": []map[string]any{{"method": "POST", "path": "/api/batch"}}}).
+		Expect().Status(http.StatusBadRequest)
+	e.POST("/api/batch").WithJSON(map[string]any{"requests": []map[string]any{{"method": "TRACE", "path": "/api/things"}}}).
+		Expect().Status(http.StatusBadRequest)
+	e.POST("/api/batch").WithJSON(map[string]any{"requests": []map[string]any{{"method": "GET", "path": "/api/events?resource=thing"}}}).
+		Expect().Status(http.StatusBadRequest).JSON().Object().ValueEqual("error", "request 0: streams can not be batched")
+	e.POST("/api/batch").WithJSON(map[string]any{"requests": []map[string]any{{"method": "GET", "path": "/api/./ws"}}}).
+		Expect().Status(http.StatusBadRequest).JSON().Object().ValueEqual("error", "request 0: streams can not be batched")
+	e.POST("/api/batch").WithJSON(map[string]any{"requests": make([]map[string]any, 4)}).
+		Expect().Status(http.StatusBadRequest)
+	e.POST("/api/batch").WithJSON(map[string]any{"atomic": true}).
+		Expect().Status(http.StatusBadRequest).JSON().Object().ValueEqual("error", "atomic batches are not supported")
+	e.POST("/api/batch").WithQuery("atomic", "true").WithJSON([]map[string]any{}).
+		Expect().Status(http.StatusBadRequest).JSON().Object().ValueEqual("error", "atomic batches are not supported")
+	e.POST("/api/batch").WithQuery("atomic", "maybe").WithJSON([]map[string]any{}).
+		Expect().Status(http.StatusBadRequest).JSON().Object().ValueEqual("error", "invalid atomic: maybe")
+	e.POST("/api/batch").WithJSON("requests").
+		Expect().Status(http.StatusBadRequest)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestBatchAtomic(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	txs := new(mocks.TxStore)
+	err := Setup(r, grs, WithBatch(txs, 0))
+	assert.Nil(t, err)
+
+	// The transaction runs the batch with its context and fails if the batch does
+	var rolledBack bool
+	txs.On("WithTransaction", mock.Anything, (*sql.TxOptions)(nil), mock.Anything).Return(func(ctx context.Context, opts *sql.TxOptions, fn func(context.Context) error) error {
+		err := fn(context.WithValue(ctx, batchTxKey{}, true))
+		rolledBack = err != nil
+		return err
+	})
+	inTx := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Value(batchTxKey{}) != nil })
+
+	grs.On("ThingSave", inTx, &gorestapi.Thing{ID: "t1", Name: "thing"}).Twice().Return(nil)
+	thingID := "t1"
+	grs.On("WidgetSave", inTx, &gorestapi.Widget{Name: "widget", ThingID: &thingID}).Once().Return(nil)
+
+	e := httpexpect.New(t, server.URL)
+	batch := map[string]any{
+		"atomic": true,
+		"requests": []map[string]any{
+			{"method": "POST", "path": "/api/things", "body": map[string]any{"id": "t1", "name": "thing"}},
+			{"method": "POST", "path": "/api/widgets", "body": map[string]any{"name": "widget", "thing_id": "t1"}},
+		},
+	}
+	arr := e.POST("/api/batch").WithJSON(batch).Expect().Status(http.StatusOK).JSON().Array()
+	arr.Element(0).Object().ValueEqual("status", http.StatusOK)
+	arr.Element(1).Object().ValueEqual("status", http.StatusOK)
+	assert.False(t, rolledBack)
+
+	// The widget fails so the thing is rolled back
+	grs.On("WidgetSave", inTx, mock.Anything).Once().Return(&store.Error{Type: store.ErrorTypeForeignKey, Err: assert.AnError})
+	batch["requests"] = append(batch["requests"].([]map[string]any), map[string]any{"method": "GET", "path": "/api/things/t1"})
+	arr = e.POST("/api/batch").WithJSON(batch).Expect().Status(http.StatusOK).JSON().Array()
+	arr.Length().Equal(3)
+	arr.Element(0).Object().ValueEqual("status", http.StatusFailedDependency)
+	arr.Element(1).Object().ValueEqual("status", http.StatusBadRequest)
+	arr.Element(2).Object().ValueEqual("status", http.StatusFailedDependency)
+	assert.True(t, rolledBack)
+
+	// A bare list of requests is atomic with the query parameter
+	grs.On("ThingSave", inTx, &gorestapi.Thing{ID: "t2", Name: "thing"}).Once().Return(nil)
+	rolledBack = true
+	arr = e.POST("/api/batch").WithQuery("atomic", "true").WithJSON([]map[string]any{
+		{"method": "POST", "path": "/api/things", "body": map[string]any{"id": "t2", "name": "thing"}},
+	}).Expect().Status(http.StatusOK).JSON().Array()
+	arr.Element(0).Object().ValueEqual("status", http.StatusOK)
+	assert.False(t, rolledBack)
+
+	// GraphQL resolvers run in parallel and can't share the transaction
+	e.POST("/api/batch").WithJSON(map[string]any{
+		"atomic":   true,
+		"requests": []map[string]any{{"method": "POST", "path": "/api/graphql", "body": map[string]any{"query": "{ things { count } }"}}},
+	}).Expect().Status(http.StatusBadRequest).JSON().Object().ValueEqual("error", "request 0: graphql is not supported in atomic batches")
+
+	// Idempotency keys of requests would be stored even if the transaction rolls back
+	e.POST("/api/batch").WithJSON(map[string]any{
+		"atomic": true,
+		"requests": []map[string]any{
+			{"method": "POST", "path": "/api/things", "headers": map[string]string{"idempotency-key": "key1"}, "body": map[string]any{"name": "thing"}},
+		},
+	}).Expect().Status(http.StatusBadRequest).JSON().Object().ValueEqual("error", "request 0: Idempotency-Key is not supported on requests of atomic batches, set it on the batch")
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+	txs.AssertExpectations(t)
+
+}
@@ -0,0 +1,122 @@
+package mainrpc
+
+import (
+	"sync"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// EventFilter selects events by resource and resource ID. Empty fields match everything.
+type EventFilter struct {
+	Resources   []string
+	ResourceIDs []string
+}
+
+// Match returns true if the event passes the filter
+func (f EventFilter) Match(event *gorestapi.Event) bool {
+	return matchAny(f.Resources, event.Resource) && matchAny(f.ResourceIDs, event.ResourceID)
+}
+
+func matchAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// EventBroker fans out published events to subscribers
+type EventBroker struct {
+	sync.RWMutex
+	subscriptions map[*EventSubscription]struct{}
+	closed        bool
+}
+
+// EventSubscription receives events matching its filter on C. If the subscriber
+// does not keep up, the subscription is closed and C is closed.
+type EventSubscription struct {
+	C      <-chan *gorestapi.Event
+	c      chan *gorestapi.Event
+	filter EventFilter
+	broker *EventBroker
+}
+
+// NewEventBroker creates a new event broker
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		subscriptions: make(map[*EventSubscription]struct{}),
+	}
+}
+
+// Subscribe creates a subscription buffering up to size events
+func (b *EventBroker) Subscribe(filter EventFilter, size int) *EventSubscription {
+	c := make(chan *gorestapi.Event, size)
+	sub := &EventSubscription{
+		C:      c,
+		c:      c,
+		filter: filter,
+		broker: b,
+	}
+	b.Lock()
+	if b.closed {
+		close(c)
+	} else {
+		b.subscriptions[sub] = struct{}{}
+	}
+	b.Unlock()
+	return sub
+}
+
+// Publish sends the event to all matching subscribers without blocking
+func (b *EventBroker) Publish(event *gorestapi.Event) {
+	var slow []*EventSubscription
+	b.RLock()
+	for sub := range b.subscriptions {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	b.RUnlock()
+
+	// Drop subscribers that can't keep up
+	for _, sub := range slow {
+		sub.Close()
+	}
+}
+
+// Close closes every subscription and any made later so event streams end when the server shuts down
+func (b *EventBroker) Close() {
+	b.Lock()
+	defer b.Unlock()
+	b.closed = true
+	for sub := range b.subscriptions {
+		delete(b.subscriptions, sub)
+		close(sub.c)
+	}
+}
+
+// Closed returns true if the broker was closed
+func (b *EventBroker) Closed() bool {
+	b.RLock()
+	defer b.RUnlock()
+	return b.closed
+}
+
+// Close removes the subscription from the broker and closes C
+func (s *EventSubscription) Close() {
+	s.broker.Lock()
+	if _, ok := s.broker.subscriptions[s]; ok {
+		delete(s.broker.subscriptions, s)
+		close(s.c)
+	}
+	s.broker.Unlock()
+}
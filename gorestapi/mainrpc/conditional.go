@@ -0,0 +1,39 @@
+package mainrpc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// collectionETag identifies a page of results by the newest update, the count and the ids on the page so clients
+// can poll without the results being encoded when nothing changed.
+func collectionETag[T any](w http.ResponseWriter, count *int64, records []*T, id func(*T) string, updated func(*T) time.Time) string {
+	var newest time.Time
+	ids := make([]string, len(records))
+	for i, rec := range records {
+		if u := updated(rec); u.After(newest) {
+			newest = u
+		}
+		ids[i] = id(rec)
+	}
+	total := int64(len(records))
+	if count != nil {
+		total = *count
+	}
+	return render.WeakETag(w, newest.UTC().Format(time.RFC3339Nano), total, ids)
+}
+
+func thingUpdated(thing *gorestapi.Thing) time.Time {
+	return thing.Updated
+}
+
+// widgetUpdated is the last update to the widget or its thing as both are in the response
+func widgetUpdated(widget *gorestapi.Widget) time.Time {
+	if widget.Thing != nil && widget.Thing.Updated.After(widget.Updated) {
+		return widget.Thing.Updated
+	}
+	return widget.Updated
+}
@@ -0,0 +1,150 @@
+package mainrpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+const (
+	// eventsBufferSize is how many events a stream may fall behind before it is dropped
+	eventsBufferSize = 256
+	// eventsResumePageSize is how many events are fetched at a time when resuming a stream
+	eventsResumePageSize = 100
+)
+
+// EventsStream streams change events
+//
+// @ID EventsStream
+// @Tags Events
+// @Summary Stream events
+// @Description Stream create, update and delete events for things and widgets as Server-Sent Events.
+// @Description Streams can be resumed with the Last-Event-ID header or last_event_id parameter.
+// @Produce  text/event-stream
+// @Param resource query string false "Comma separated resources (thing, widget)"
+// @Param id query string false "Comma separated resource IDs"
+// @Param last_event_id query int false "Resume after this event ID"
+// @Param Last-Event-ID header int false "Resume after this event ID"
+// @Success 200 {object} gorestapi.Event
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /events [get]
+func (s *Server) EventsStream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			render.ErrInternal(w, errors.New("streaming not supported"))
+			return
+		}
+
+		filter := EventFilter{
+			Resources:   splitQueryValues(r, "resource"),
+			ResourceIDs: splitQueryValues(r, "id"),
+		}
+
+		var lastEventID int64
+		if value := r.Header.Get("Last-Event-ID"); value != "" {
+			lastEventID, _ = strconv.ParseInt(value, 10, 64)
+		} else if value := r.URL.Query().Get("last_event_id"); value != "" {
+			var err error
+			if lastEventID, err = strconv.ParseInt(value, 10, 64); err != nil {
+				render.ErrInvalidRequest(w, fmt.Errorf("invalid last_event_id: %w", err))
+				return
+			}
+		}
+
+		// Subscribe before resuming so nothing is missed in between
+		sub := s.eventBroker.Subscribe(filter, eventsBufferSize)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// Send any events since the last one the client received
+		if lastEventID > 0 {
+			for {
+				events, err := s.eventStore.EventsSince(ctx, lastEventID, eventsResumePageSize)
+				if err != nil {
+					s.logger.Error("EventsSince error", "error", err, "request_id", middleware.GetReqID(ctx))
+					return
+				}
+				for _, event := range events {
+					lastEventID = event.ID
+					if !filter.Match(event) {
+						continue
+					}
+					if err := writeEvent(w, event); err != nil {
+						return
+					}
+				}
+				flusher.Flush()
+				if len(events) < eventsResumePageSize {
+					break
+				}
+			}
+		}
+
+		keepalive := time.NewTicker(s.eventKeepalive)
+		defer keepalive.Stop()
+
+		for {
+			select {
+			case event, ok := <-sub.C:
+				if !ok {
+					// Dropped for falling behind or shutting down, the client can reconnect and resume
+					return
+				}
+				if event.ID <= lastEventID {
+					continue
+				}
+				lastEventID = event.ID
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-keepalive.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-ctx.Done():
+				return
+			}
+		}
+
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format
+func writeEvent(w http.ResponseWriter, event *gorestapi.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Name(), event.String())
+	return err
+}
+
+// splitQueryValues returns all the values of a query parameter that may be repeated or comma separated
+func splitQueryValues(r *http.Request, key string) []string {
+	var values []string
+	for _, value := range r.URL.Query()[key] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,115 @@
+package mainrpc
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+// readEvent reads the next event from a Server-Sent Events stream
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		assert.Nil(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	es := new(mocks.EventStore)
+	broker := NewEventBroker()
+	err := Setup(r, grs, WithEvents(es, broker, time.Hour))
+	assert.Nil(t, err)
+
+	// Open the stream for things only
+	resp, err := http.Get(server.URL + "/api/events?resource=thing")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Publish a filtered event and a matching event
+	broker.Publish(&gorestapi.Event{ID: 1, Type: gorestapi.EventTypeCreated, Resource: gorestapi.EventResourceWidget, ResourceID: "w1"})
+	thingEvent := &gorestapi.Event{ID: 2, Type: gorestapi.EventTypeUpdated, Resource: gorestapi.EventResourceThing, ResourceID: "t1"}
+	broker.Publish(thingEvent)
+
+	// Validate we only get the thing event
+	reader := bufio.NewReader(resp.Body)
+	assert.Equal(t, []string{"id: 2", "event: thing.updated", "data: " + thingEvent.String()}, readEvent(t, reader))
+
+	// Closing the broker ends the stream
+	broker.Close()
+	_, err = reader.ReadString('\n')
+	assert.Equal(t, io.EOF, err)
+	assert.True(t, broker.Closed())
+
+	// Check remaining expectations
+	es.AssertExpectations(t)
+
+}
+
+func TestEventsStreamResume(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	es := new(mocks.EventStore)
+	broker := NewEventBroker()
+	err := Setup(r, grs, WithEvents(es, broker, time.Hour))
+	assert.Nil(t, err)
+
+	// Return missed events
+	missed := []*gorestapi.Event{
+		{ID: 6, Type: gorestapi.EventTypeCreated, Resource: gorestapi.EventResourceWidget, ResourceID: "w1"},
+		{ID: 7, Type: gorestapi.EventTypeDeleted, Resource: gorestapi.EventResourceThing, ResourceID: "t1"},
+	}
+	es.On("EventsSince", mock.Anything, int64(5), eventsResumePageSize).Once().Return(missed, nil)
+
+	// Resume the stream for a single thing
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/events?id=t1", nil)
+	assert.Nil(t, err)
+	req.Header.Set("Last-Event-ID", "5")
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	// Validate we get the missed event followed by live events, skipping duplicates
+	body := bufio.NewReader(resp.Body)
+	assert.Equal(t, []string{"id: 7", "event: thing.deleted", "data: " + missed[1].String()}, readEvent(t, body))
+	broker.Publish(missed[1])
+	liveEvent := &gorestapi.Event{ID: 8, Type: gorestapi.EventTypeCreated, Resource: gorestapi.EventResourceThing, ResourceID: "t1"}
+	broker.Publish(liveEvent)
+	assert.Equal(t, []string{"id: 8", "event: thing.created", "data: " + liveEvent.String()}, readEvent(t, body))
+
+	// Check remaining expectations
+	es.AssertExpectations(t)
+
+}
@@ -0,0 +1,364 @@
+package mainrpc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// Export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+// exportContentTypes maps export formats to content types
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:    "text/csv",
+	ExportFormatNDJSON: "application/x-ndjson",
+	ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportMediaTypes returns the content types of the export formats
+func exportMediaTypes() []string {
+	ret := make([]string, 0, len(exportContentTypes))
+	for _, contentType := range exportContentTypes {
+		ret = append(ret, contentType)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// exportFlushRows is how many rows are written between flushes to the client
+const exportFlushRows = 1000
+
+// exportColumn is a flattened column of an exported record
+type exportColumn[T any] struct {
+	name  string
+	value func(*T) any
+}
+
+var thingExportColumns = []exportColumn[gorestapi.Thing]{
+	{"id", func(t *gorestapi.Thing) any { return t.ID }},
+	{"created", func(t *gorestapi.Thing) any { return t.Created }},
+	{"updated", func(t *gorestapi.Thing) any { return t.Updated }},
+	{"name", func(t *gorestapi.Thing) any { return t.Name }},
+	{"description", func(t *gorestapi.Thing) any { return t.Description }},
+}
+
+var widgetExportColumns = append([]exportColumn[gorestapi.Widget]{
+	{"id", func(w *gorestapi.Widget) any { return w.ID }},
+	{"created", func(w *gorestapi.Widget) any { return w.Created }},
+	{"updated", func(w *gorestapi.Widget) any { return w.Updated }},
+	{"name", func(w *gorestapi.Widget) any { return w.Name }},
+	{"description", func(w *gorestapi.Widget) any { return w.Description }},
+	{"thing_id", func(w *gorestapi.Widget) any {
+		if w.ThingID == nil {
+			return ""
+		}
+		return *w.ThingID
+	}},
+}, embeddedExportColumns("thing", thingExportColumns, func(w *gorestapi.Widget) *gorestapi.Thing { return w.Thing })...)
+
+// embeddedExportColumns flattens the columns of an embedded record with a prefix. The columns are empty if it is nil.
+func embeddedExportColumns[T any, E any](prefix string, columns []exportColumn[E], embedded func(*T) *E) []exportColumn[T] {
+	ret := make([]exportColumn[T], 0, len(columns))
+	for _, column := range columns {
+		column := column
+		ret = append(ret, exportColumn[T]{prefix + "." + column.name, func(rec *T) any {
+			if e := embedded(rec); e != nil {
+				return column.value(e)
+			}
+			return ""
+		}})
+	}
+	return ret
+}
+
+// exportFormat returns the requested export format from the format query parameter or the Accept header.
+// It returns an empty string if the request is not an export.
+func exportFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := exportContentTypes[format]; !ok {
+			return "", fmt.Errorf("unknown format %s", format)
+		}
+		return format, nil
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accept))
+		for format, contentType := range exportContentTypes {
+			if mediaType == contentType {
+				return format, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// exportQueryParameters parses the query without the format parameter
+func exportQueryParameters(r *http.Request) (*queryp.QueryParameters, error) {
+	var parts []string
+	for _, part := range strings.Split(r.URL.RawQuery, "&") {
+		if part != "" && !strings.HasPrefix(part, "format=") {
+			parts = append(parts, part)
+		}
+	}
+	return queryp.ParseRawQuery(strings.Join(parts, "&"))
+}
+
+// exportWriter writes rows in an export format
+type exportWriter interface {
+	Header(columns []string) error
+	Row(record any, values []any) error
+	// Flush sends the rows written so far to the client if the format allows it
+	Flush() error
+	// End writes the end of the export
+	End() error
+	// Close releases any resources
+	Close() error
+}
+
+// exportResponseWriter tracks if the response has been started
+type exportResponseWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *exportResponseWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *exportResponseWriter) Flush() {
+	flush(w.ResponseWriter)
+}
+
+func (w *exportResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// export streams records to the client in the format. Errors before anything has been sent to the client are
+// returned as normal error responses.
+func export[T any](s *Server, rw http.ResponseWriter, r *http.Request, method string, resource string, format string, columns []exportColumn[T], stream func(fn func(*T) error) error) {
+
+	ctx := r.Context()
+	w := &exportResponseWriter{ResponseWriter: rw}
+
+	var ew exportWriter
+	defer func() {
+		if ew != nil {
+			ew.Close()
+		}
+	}()
+	start := func() error {
+		w.Header().Set("Content-Type", exportContentTypes[format])
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, resource, format))
+		switch format {
+		case ExportFormatCSV:
+			ew = &csvExportWriter{w: csv.NewWriter(w), flusher: w}
+		case ExportFormatNDJSON:
+			ew = &ndjsonExportWriter{enc: json.NewEncoder(w), flusher: w}
+		case ExportFormatXLSX:
+			xw, err := newXLSXExportWriter(w)
+			if err != nil {
+				return err
+			}
+			ew = xw
+		}
+		names := make([]string, len(columns))
+		for i, column := range columns {
+			names[i] = column.name
+		}
+		return ew.Header(names)
+	}
+
+	var rows int
+	values := make([]any, len(columns))
+	err := stream(func(rec *T) error {
+		if ew == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for i, column := range columns {
+			values[i] = column.value(rec)
+		}
+		if err := ew.Row(rec, values); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			return ew.Flush()
+		}
+		return nil
+	})
+	if err == nil && ew == nil {
+		err = start()
+	}
+	if err == nil {
+		err = ew.End()
+	}
+	if err == nil {
+		return
+	}
+
+	requestID := middleware.GetReqID(ctx)
+	if w.started {
+		// The response has already started, all that can be done is to stop
+		s.logger.Error(method+" export error", "error", err, "request_id", requestID, "rows", rows)
+		return
+	}
+	w.Header().Del("Content-Disposition")
+	if serr, ok := err.(*store.Error); ok {
+		render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpFind))
+	} else {
+		render.ErrInternalWithID(w, requestID, nil)
+		s.logger.Error(method+" export error", "error", err, "request_id", requestID)
+	}
+
+}
+
+// exportString formats a value for text formats
+func exportString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// flush flushes the response to the client if supported
+func flush(w io.Writer) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type csvExportWriter struct {
+	w       *csv.Writer
+	flusher io.Writer
+	row     []string
+}
+
+func (cw *csvExportWriter) Header(columns []string) error {
+	cw.row = make([]string, len(columns))
+	return cw.w.Write(columns)
+}
+
+func (cw *csvExportWriter) Row(_ any, values []any) error {
+	for i, value := range values {
+		cw.row[i] = exportString(value)
+	}
+	return cw.w.Write(cw.row)
+}
+
+func (cw *csvExportWriter) Flush() error {
+	cw.w.Flush()
+	flush(cw.flusher)
+	return cw.w.Error()
+}
+
+func (cw *csvExportWriter) End() error   { return cw.Flush() }
+func (cw *csvExportWriter) Close() error { return nil }
+
+// ndjsonExportWriter writes the full records including embedded records rather than the flattened columns
+type ndjsonExportWriter struct {
+	enc     *json.Encoder
+	flusher io.Writer
+}
+
+func (nw *ndjsonExportWriter) Header(_ []string) error { return nil }
+
+func (nw *ndjsonExportWriter) Row(record any, _ []any) error {
+	return nw.enc.Encode(record)
+}
+
+func (nw *ndjsonExportWriter) Flush() error {
+	flush(nw.flusher)
+	return nil
+}
+
+func (nw *ndjsonExportWriter) End() error   { return nw.Flush() }
+func (nw *ndjsonExportWriter) Close() error { return nil }
+
+// xlsxExportWriter streams rows to a worksheet. Excelize keeps the rows in a temporary file so the workbook is
+// only written out once all rows are read.
+type xlsxExportWriter struct {
+	w    io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	sw, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not create xlsx writer: %w", err)
+	}
+	return &xlsxExportWriter{w: w, file: file, sw: sw}, nil
+}
+
+func (xw *xlsxExportWriter) Header(columns []string) error {
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		values[i] = column
+	}
+	return xw.Row(nil, values)
+}
+
+func (xw *xlsxExportWriter) Row(_ any, values []any) error {
+	xw.row++
+	cells := make([]any, len(values))
+	for i, value := range values {
+		// Zero times are left empty and times are written as UTC as spreadsheets have no time zones
+		if t, ok := value.(time.Time); ok {
+			if t.IsZero() {
+				value = ""
+			} else {
+				value = t.UTC()
+			}
+		}
+		cells[i] = value
+	}
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	return xw.sw.SetRow(cell, cells)
+}
+
+// Flush does nothing as the workbook can only be written once all the rows are written
+func (xw *xlsxExportWriter) Flush() error { return nil }
+
+func (xw *xlsxExportWriter) End() error {
+	if err := xw.sw.Flush(); err != nil {
+		return fmt.Errorf("could not flush xlsx rows: %w", err)
+	}
+	return xw.file.Write(xw.w)
+}
+
+func (xw *xlsxExportWriter) Close() error {
+	return xw.file.Close()
+}
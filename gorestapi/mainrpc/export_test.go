@@ -0,0 +1,155 @@
+package mainrpc
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+// streamRecords returns a mock run function calling the stream callback with the records
+func streamRecords[T any](records ...*T) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		fn := args.Get(2).(func(*T) error)
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestWidgetsExportCSV(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	created := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	thingID := "t1"
+	grs.On("WidgetsStream", mock.Anything, mock.MatchedBy(func(qp *queryp.QueryParameters) bool {
+		return qp.Filter.String() == "name=~red%" && qp.Limit == 0
+	}), mock.Anything).Once().Run(streamRecords(
+		&gorestapi.Widget{ID: "w1", Created: created, Name: "red, big", ThingID: &thingID, Thing: &gorestapi.Thing{ID: thingID, Name: "thing"}},
+		&gorestapi.Widget{ID: "w2", Name: "red"},
+	)).Return(nil)
+
+	// The format parameter is not used as a filter
+	e := httpexpect.New(t, server.URL)
+	resp := e.GET("/api/widgets").WithQuery("name", "~red%").WithQuery("format", "csv").Expect().Status(http.StatusOK)
+	resp.Header("Content-Type").Equal("text/csv")
+	resp.Header("Content-Disposition").Equal(`attachment; filename="widgets.csv"`)
+	resp.Body().Equal("id,created,updated,name,description,thing_id,thing.id,thing.created,thing.updated,thing.name,thing.description\n" +
+		"w1,2023-01-02T03:04:05Z,,\"red, big\",,t1,t1,,,thing,\n" +
+		"w2,,,red,,,,,,,\n")
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestThingsExportNDJSON(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	grs.On("ThingsStream", mock.Anything, mock.AnythingOfType("*queryp.QueryParameters"), mock.Anything).Once().Run(streamRecords(
+		&gorestapi.Thing{ID: "t1", Name: "one"},
+		&gorestapi.Thing{ID: "t2", Name: "two"},
+	)).Return(nil)
+
+	// The format is selected with the Accept header
+	e := httpexpect.New(t, server.URL)
+	resp := e.GET("/api/things").WithHeader("Accept", "application/x-ndjson").Expect().Status(http.StatusOK)
+	resp.Header("Content-Type").Equal("application/x-ndjson")
+	resp.Body().Equal(`{"id":"t1","created":"0001-01-01T00:00:00Z","updated":"0001-01-01T00:00:00Z","name":"one","description":""}` + "\n" +
+		`{"id":"t2","created":"0001-01-01T00:00:00Z","updated":"0001-01-01T00:00:00Z","name":"two","description":""}` + "\n")
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestThingsExportXLSX(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	grs.On("ThingsStream", mock.Anything, mock.AnythingOfType("*queryp.QueryParameters"), mock.Anything).Once().Run(streamRecords(
+		&gorestapi.Thing{ID: "t1", Name: "one"},
+	)).Return(nil)
+
+	e := httpexpect.New(t, server.URL)
+	body := e.GET("/api/things").WithQuery("format", "xlsx").Expect().Status(http.StatusOK).Body().Raw()
+
+	file, err := excelize.OpenReader(bytes.NewReader([]byte(body)))
+	assert.Nil(t, err)
+	defer file.Close()
+	rows, err := file.GetRows("Sheet1")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"id", "created", "updated", "name", "description"}, {"t1", "", "", "one"}}, rows)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestThingsExportError(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	// Errors before the export starts are normal error responses
+	grs.On("ThingsStream", mock.Anything, mock.AnythingOfType("*queryp.QueryParameters"), mock.Anything).Once().Return(&store.Error{Type: store.ErrorTypeQuery, Err: errors.New("bad field")})
+	grs.On("ThingsStream", mock.Anything, mock.AnythingOfType("*queryp.QueryParameters"), mock.Anything).Once().Return(errors.New("database down"))
+
+	e := httpexpect.New(t, server.URL)
+	resp := e.GET("/api/things").WithQuery("format", "csv").Expect().Status(http.StatusBadRequest)
+	resp.Header("Content-Disposition").Empty()
+	e.GET("/api/things").WithQuery("format", "csv").Expect().Status(http.StatusInternalServerError)
+	e.GET("/api/things").WithQuery("format", "pdf").Expect().Status(http.StatusBadRequest)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
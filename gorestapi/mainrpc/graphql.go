@@ -0,0 +1,42 @@
+package mainrpc
+
+import (
+	"net/http"
+
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// GraphQLRequest is a GraphQL request
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// GraphQL executes a GraphQL request
+//
+// @ID GraphQL
+// @Tags GraphQL
+// @Summary GraphQL
+// @Description Execute a GraphQL query or mutation against things and widgets
+// @Accept   json
+// @Produce  json
+// @Param request body GraphQLRequest true "GraphQL Request"
+// @Success 200 {object} object "GraphQL Response"
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Router /graphql [post]
+func (s *Server) GraphQL() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req = new(GraphQLRequest)
+		if err := render.Decode(r, req); err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+
+		// Errors are returned in the response body as per the GraphQL spec
+		render.Encode(w, http.StatusOK, s.graphqlSchema.Exec(r.Context(), req.Query, req.OperationName, req.Variables))
+	}
+
+}
@@ -0,0 +1,136 @@
+package mainrpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+func TestGraphQLThings(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs, WithGraphQL())
+	assert.Nil(t, err)
+
+	// The arguments are translated into query parameters
+	count := int64(2)
+	grs.On("ThingsFind", mock.Anything, mock.MatchedBy(func(qp *queryp.QueryParameters) bool {
+		return qp.Filter.String() == "name=~foo%" && len(qp.Sort) == 1 && qp.Sort[0].Field == "created" && qp.Sort[0].Desc && qp.Limit == 10 && qp.Offset == 5
+	})).Once().Return([]*gorestapi.Thing{{ID: "t1", Name: "foo1"}, {ID: "t2", Name: "foo2"}}, &count, nil)
+
+	// The widgets of both things are loaded in a single batch
+	t1, t2 := "t1", "t2"
+	grs.On("WidgetsFind", mock.Anything, mock.MatchedBy(func(qp *queryp.QueryParameters) bool {
+		return len(qp.Filter) == 1 && qp.Filter[0].Field == "widget.thing_id" && len(qp.Filter[0].Value.([]any)) == 2
+	})).Once().Return([]*gorestapi.Widget{{ID: "w1", Name: "w1", ThingID: &t1}, {ID: "w2", Name: "w2", ThingID: &t2}, {ID: "w3", Name: "w3", ThingID: &t1}}, nil, nil)
+
+	e := httpexpect.New(t, server.URL)
+	e.POST("/api/graphql").WithJSON(&GraphQLRequest{
+		Query:     `query($filter: String) { things(filter: $filter, sort: ["-created"], limit: 10, offset: 5) { count results { id name widgets { id } } } }`,
+		Variables: map[string]any{"filter": "name=~foo%"},
+	}).Expect().Status(http.StatusOK).JSON().Object().Equal(map[string]any{
+		"data": map[string]any{
+			"things": map[string]any{
+				"count": 2,
+				"results": []any{
+					map[string]any{"id": "t1", "name": "foo1", "widgets": []any{map[string]any{"id": "w1"}, map[string]any{"id": "w3"}}},
+					map[string]any{"id": "t2", "name": "foo2", "widgets": []any{map[string]any{"id": "w2"}}},
+				},
+			},
+		},
+	})
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestGraphQLWidget(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs, WithGraphQL())
+	assert.Nil(t, err)
+
+	// The thing is loaded with the widget
+	thingID := "t1"
+	grs.On("WidgetGetByID", mock.Anything, "w1").Once().Return(&gorestapi.Widget{ID: "w1", Name: "w1", ThingID: &thingID, Thing: &gorestapi.Thing{ID: thingID, Name: "thing"}}, nil)
+
+	e := httpexpect.New(t, server.URL)
+	e.POST("/api/graphql").WithJSON(&GraphQLRequest{Query: `{ widget(id: "w1") { id thingId thing { name } } }`}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object().Value("widget").Object().Equal(map[string]any{
+		"id": "w1", "thingId": "t1", "thing": map[string]any{"name": "thing"},
+	})
+
+	// Not found is null, other errors have a code
+	grs.On("WidgetGetByID", mock.Anything, "missing").Once().Return(nil, store.ErrNotFound)
+	e.POST("/api/graphql").WithJSON(&GraphQLRequest{Query: `{ widget(id: "missing") { id } }`}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object().Value("widget").Null()
+
+	grs.On("WidgetGetByID", mock.Anything, "broken").Once().Return(nil, errors.New("database down"))
+	errs := e.POST("/api/graphql").WithJSON(&GraphQLRequest{Query: `{ widget(id: "broken") { id } }`}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("errors").Array()
+	errs.Length().Equal(1)
+	errs.Element(0).Object().Value("message").Equal("internal error")
+	errs.Element(0).Object().Value("extensions").Object().Value("code").Equal("INTERNAL")
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestGraphQLMutations(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs, WithGraphQL())
+	assert.Nil(t, err)
+
+	thingID := "t1"
+	grs.On("WidgetSave", mock.Anything, &gorestapi.Widget{Name: "name", ThingID: &thingID}).Once().Run(func(args mock.Arguments) {
+		args.Get(1).(*gorestapi.Widget).ID = "w1"
+	}).Return(nil)
+	grs.On("ThingDeleteByID", mock.Anything, "t2").Once().Return(&store.Error{Type: store.ErrorTypeForeignKey, Err: errors.New("in use")})
+
+	e := httpexpect.New(t, server.URL)
+	e.POST("/api/graphql").WithJSON(&GraphQLRequest{Query: `mutation { widgetSave(input: {name: "name", thingId: "t1"}) { id name } }`}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object().Value("widgetSave").Object().Equal(map[string]any{"id": "w1", "name": "name"})
+
+	errs := e.POST("/api/graphql").WithJSON(&GraphQLRequest{Query: `mutation { thingDelete(id: "t2") }`}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("errors").Array()
+	errs.Element(0).Object().Value("extensions").Object().Value("code").Equal("INVALID_ARGUMENT")
+
+	// Invalid requests
+	e.POST("/api/graphql").WithText("{").Expect().Status(http.StatusBadRequest)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
@@ -0,0 +1,364 @@
+package mainrpc
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// Hypermedia content types
+const (
+	ContentTypeHAL     = "application/hal+json"
+	ContentTypeJSONAPI = "application/vnd.api+json"
+)
+
+// apiPath is where the API is mounted, links are relative to the host
+const apiPath = "/api"
+
+// hypermediaCodecs encode HAL and JSON:API responses. The handlers build the documents with represent and
+// representPage so the codecs only encode them.
+var hypermediaCodecs = []*render.Codec{
+	{ContentType: ContentTypeHAL, Encode: render.CodecJSON.Encode},
+	{ContentType: ContentTypeJSONAPI, Encode: jsonapiEncode},
+}
+
+// hypermediaResource describes how records of a type are linked
+type hypermediaResource[T any] struct {
+	// typ is the JSON:API type
+	typ string
+	id  func(*T) string
+	// self is the link to the record, empty if it has none
+	self func(*T) string
+	// relations are the records and collections the record links to
+	relations func(*T) []*hypermediaRelation
+}
+
+// hypermediaRelation links a record to a related record or collection. A related record that is loaded is
+// embedded with HAL and included with JSON:API.
+type hypermediaRelation struct {
+	name string
+	href string
+	// toOne relations refer to a record by typ and id, the id is empty if there is no related record
+	toOne bool
+	typ   string
+	id    string
+	// keyField and embedField are the attributes replaced by the relation, ie. thing_id and thing
+	keyField   string
+	embedField string
+	embedded   *hypermediaObject
+}
+
+// hypermediaObject is a record ready to be written as HAL or JSON:API
+type hypermediaObject struct {
+	typ        string
+	id         string
+	self       string
+	attributes map[string]json.RawMessage
+	relations  []*hypermediaRelation
+}
+
+var thingHypermedia = &hypermediaResource[gorestapi.Thing]{
+	typ:  "things",
+	id:   func(thing *gorestapi.Thing) string { return thing.ID },
+	self: func(thing *gorestapi.Thing) string { return link("things", thing.ID) },
+	relations: func(thing *gorestapi.Thing) []*hypermediaRelation {
+		return []*hypermediaRelation{
+			{name: "widgets", href: apiPath + "/widgets?thing_id=" + url.QueryEscape(thing.ID)},
+		}
+	},
+}
+
+var widgetHypermedia = &hypermediaResource[gorestapi.Widget]{
+	typ:  "widgets",
+	id:   func(widget *gorestapi.Widget) string { return widget.ID },
+	self: func(widget *gorestapi.Widget) string { return link("widgets", widget.ID) },
+	relations: func(widget *gorestapi.Widget) []*hypermediaRelation {
+		rel := &hypermediaRelation{name: "thing", toOne: true, typ: "things", keyField: "thing_id", embedField: "thing"}
+		if widget.ThingID != nil {
+			rel.id = *widget.ThingID
+			rel.href = link("things", rel.id)
+		}
+		if widget.Thing != nil {
+			rel.embedded = thingHypermedia.object(widget.Thing)
+		}
+		return []*hypermediaRelation{rel}
+	},
+}
+
+var webhookHypermedia = &hypermediaResource[gorestapi.Webhook]{
+	typ:  "webhooks",
+	id:   func(webhook *gorestapi.Webhook) string { return webhook.ID },
+	self: func(webhook *gorestapi.Webhook) string { return link("webhooks", webhook.ID) },
+	relations: func(webhook *gorestapi.Webhook) []*hypermediaRelation {
+		return []*hypermediaRelation{
+			{name: "deliveries", href: link("webhooks", webhook.ID, "deliveries")},
+		}
+	},
+}
+
+// webhookDeliveryHypermedia has no self link as deliveries are only found through their webhook
+var webhookDeliveryHypermedia = &hypermediaResource[gorestapi.WebhookDelivery]{
+	typ:  "webhook_deliveries",
+	id:   func(delivery *gorestapi.WebhookDelivery) string { return strconv.FormatInt(delivery.ID, 10) },
+	self: func(delivery *gorestapi.WebhookDelivery) string { return "" },
+	relations: func(delivery *gorestapi.WebhookDelivery) []*hypermediaRelation {
+		return []*hypermediaRelation{
+			{name: "webhook", href: link("webhooks", delivery.WebhookID), toOne: true, typ: "webhooks", id: delivery.WebhookID, keyField: "webhook_id"},
+			{name: "redeliver", href: link("webhooks", delivery.WebhookID, "deliveries", strconv.FormatInt(delivery.ID, 10), "redeliver")},
+		}
+	},
+}
+
+// link returns the link to a path under the API
+func link(parts ...string) string {
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return apiPath + "/" + strings.Join(parts, "/")
+}
+
+// object prepares a record to be written as HAL or JSON:API
+func (hr *hypermediaResource[T]) object(rec *T) *hypermediaObject {
+	return &hypermediaObject{
+		typ:        hr.typ,
+		id:         hr.id(rec),
+		self:       hr.self(rec),
+		attributes: attributes(rec),
+		relations:  hr.relations(rec),
+	}
+}
+
+// attributes returns the JSON fields of a record. The records are plain structs that always encode as JSON.
+func attributes(rec any) map[string]json.RawMessage {
+	b, _ := json.Marshal(rec)
+	ret := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(b, &ret)
+	return ret
+}
+
+// represent returns the record as a HAL or JSON:API document if one was negotiated
+func represent[T any](w http.ResponseWriter, hr *hypermediaResource[T], rec *T) any {
+	switch render.ContentType(w) {
+	case ContentTypeHAL:
+		return hr.object(rec).hal()
+	case ContentTypeJSONAPI:
+		o := hr.object(rec)
+		doc := &jsonapiDocument{Data: o.jsonapi()}
+		doc.include(o)
+		if o.self != "" {
+			doc.Links = map[string]string{"self": o.self}
+		}
+		return doc
+	}
+	return rec
+}
+
+// representPage returns a page of results as a HAL or JSON:API document with pagination links if one was negotiated
+func representPage[T any](w http.ResponseWriter, r *http.Request, qp *queryp.QueryParameters, hr *hypermediaResource[T], count *int64, records []*T) any {
+	switch render.ContentType(w) {
+	case ContentTypeHAL:
+		links := make(map[string]*halLink)
+		for name, href := range pageLinks(r, qp, count, len(records)) {
+			links[name] = &halLink{Href: href}
+		}
+		embedded := make([]map[string]any, len(records))
+		for i, rec := range records {
+			embedded[i] = hr.object(rec).hal()
+		}
+		return &halPage{Count: count, Links: links, Embedded: map[string][]map[string]any{hr.typ: embedded}}
+	case ContentTypeJSONAPI:
+		data := make([]*jsonapiResource, len(records))
+		doc := &jsonapiDocument{Links: pageLinks(r, qp, count, len(records))}
+		for i, rec := range records {
+			o := hr.object(rec)
+			data[i] = o.jsonapi()
+			doc.include(o)
+		}
+		doc.Data = data
+		if count != nil {
+			doc.Meta = map[string]any{"count": *count}
+		}
+		return doc
+	}
+	return store.Results{Count: count, Results: records}
+}
+
+// pageLinks returns the links to the current, first, previous, next and last pages. The pages other than the
+// current are only linked if there is a limit. Without a count the next page is linked if this page is full.
+func pageLinks(r *http.Request, qp *queryp.QueryParameters, count *int64, records int) map[string]string {
+
+	links := map[string]string{"self": r.URL.RequestURI()}
+	if qp.Limit <= 0 {
+		return links
+	}
+
+	page := func(offset int64) string {
+		var parts []string
+		for _, part := range strings.Split(r.URL.RawQuery, "&") {
+			if part != "" && !strings.HasPrefix(part, "offset=") && !strings.HasPrefix(part, "limit=") {
+				parts = append(parts, part)
+			}
+		}
+		parts = append(parts, "offset="+strconv.FormatInt(offset, 10), "limit="+strconv.FormatInt(qp.Limit, 10))
+		return r.URL.Path + "?" + strings.Join(parts, "&")
+	}
+
+	links["first"] = page(0)
+	if qp.Offset > 0 {
+		links["prev"] = page(max(qp.Offset-qp.Limit, 0))
+	}
+	if count != nil {
+		if qp.Offset+qp.Limit < *count {
+			links["next"] = page(qp.Offset + qp.Limit)
+		}
+		links["last"] = page(max(*count-1, 0) / qp.Limit * qp.Limit)
+	} else if int64(records) >= qp.Limit {
+		links["next"] = page(qp.Offset + qp.Limit)
+	}
+	return links
+
+}
+
+type halLink struct {
+	Href string `json:"href"`
+}
+
+type halPage struct {
+	Count    *int64                      `json:"count,omitempty"`
+	Links    map[string]*halLink         `json:"_links"`
+	Embedded map[string][]map[string]any `json:"_embedded"`
+}
+
+// hal returns the object as a HAL resource. Loaded related records are moved to _embedded.
+func (o *hypermediaObject) hal() map[string]any {
+	doc := make(map[string]any, len(o.attributes)+2)
+	for name, value := range o.attributes {
+		doc[name] = value
+	}
+	links := make(map[string]*halLink)
+	if o.self != "" {
+		links["self"] = &halLink{Href: o.self}
+	}
+	embedded := make(map[string]any)
+	for _, rel := range o.relations {
+		if rel.href != "" {
+			links[rel.name] = &halLink{Href: rel.href}
+		}
+		if rel.embedField != "" {
+			delete(doc, rel.embedField)
+		}
+		if rel.embedded != nil {
+			embedded[rel.name] = rel.embedded.hal()
+		}
+	}
+	doc["_links"] = links
+	if len(embedded) > 0 {
+		doc["_embedded"] = embedded
+	}
+	return doc
+}
+
+type jsonapiDocument struct {
+	Data     any                `json:"data"`
+	Included []*jsonapiResource `json:"included,omitempty"`
+	Meta     map[string]any     `json:"meta,omitempty"`
+	Links    map[string]string  `json:"links,omitempty"`
+
+	included map[string]bool
+}
+
+type jsonapiResource struct {
+	Type          string                          `json:"type"`
+	ID            string                          `json:"id"`
+	Attributes    map[string]json.RawMessage      `json:"attributes"`
+	Relationships map[string]*jsonapiRelationship `json:"relationships,omitempty"`
+	Links         map[string]string               `json:"links,omitempty"`
+}
+
+type jsonapiIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type jsonapiRelationship struct {
+	// Data is a *jsonapiIdentifier for to-one relations, a nil identifier is written as null
+	Data  any               `json:"data,omitempty"`
+	Links map[string]string `json:"links,omitempty"`
+}
+
+type jsonapiError struct {
+	ID     string `json:"id,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// jsonapi returns the object as a JSON:API resource. Relations replace their attributes.
+func (o *hypermediaObject) jsonapi() *jsonapiResource {
+	res := &jsonapiResource{
+		Type:       o.typ,
+		ID:         o.id,
+		Attributes: make(map[string]json.RawMessage, len(o.attributes)),
+	}
+	for name, value := range o.attributes {
+		res.Attributes[name] = value
+	}
+	delete(res.Attributes, "id")
+	if o.self != "" {
+		res.Links = map[string]string{"self": o.self}
+	}
+	for _, rel := range o.relations {
+		delete(res.Attributes, rel.keyField)
+		delete(res.Attributes, rel.embedField)
+		relationship := new(jsonapiRelationship)
+		if rel.toOne {
+			var identifier *jsonapiIdentifier
+			if rel.id != "" {
+				identifier = &jsonapiIdentifier{Type: rel.typ, ID: rel.id}
+			}
+			relationship.Data = identifier
+		}
+		if rel.href != "" {
+			relationship.Links = map[string]string{"related": rel.href}
+		}
+		if res.Relationships == nil {
+			res.Relationships = make(map[string]*jsonapiRelationship)
+		}
+		res.Relationships[rel.name] = relationship
+	}
+	return res
+}
+
+// include adds the loaded related records of the object to the included resources once each
+func (doc *jsonapiDocument) include(o *hypermediaObject) {
+	for _, rel := range o.relations {
+		if rel.embedded == nil {
+			continue
+		}
+		key := rel.embedded.typ + "/" + rel.embedded.id
+		if doc.included[key] {
+			continue
+		}
+		if doc.included == nil {
+			doc.included = make(map[string]bool)
+		}
+		doc.included[key] = true
+		doc.Included = append(doc.Included, rel.embedded.jsonapi())
+	}
+}
+
+// jsonapiEncode encodes JSON:API documents and error responses as JSON:API errors
+func jsonapiEncode(w io.Writer, v any) error {
+	if err, ok := v.(render.ErrResponse); ok {
+		v = map[string][]*jsonapiError{"errors": {{ID: err.ErrorID, Title: err.Status, Detail: err.Error}}}
+	}
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,144 @@
+package mainrpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+func TestHAL(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	thingID := "t1"
+	widget := &gorestapi.Widget{ID: "w1", Name: "widget", ThingID: &thingID, Thing: &gorestapi.Thing{ID: thingID, Name: "thing"}}
+	grs.On("WidgetGetByID", mock.Anything, "w1").Once().Return(widget, nil)
+
+	e := httpexpect.New(t, server.URL)
+	hal := httpexpect.ContentOpts{MediaType: ContentTypeHAL}
+	e.GET("/api/widgets/w1").WithHeader("Accept", ContentTypeHAL).Expect().Status(http.StatusOK).JSON(hal).Object().Equal(map[string]any{
+		"id":          "w1",
+		"name":        "widget",
+		"description": "",
+		"thing_id":    "t1",
+		"created":     "0001-01-01T00:00:00Z",
+		"updated":     "0001-01-01T00:00:00Z",
+		"_links": map[string]any{
+			"self":  map[string]any{"href": "/api/widgets/w1"},
+			"thing": map[string]any{"href": "/api/things/t1"},
+		},
+		"_embedded": map[string]any{
+			"thing": map[string]any{
+				"id":          "t1",
+				"name":        "thing",
+				"description": "",
+				"created":     "0001-01-01T00:00:00Z",
+				"updated":     "0001-01-01T00:00:00Z",
+				"_links": map[string]any{
+					"self":    map[string]any{"href": "/api/things/t1"},
+					"widgets": map[string]any{"href": "/api/widgets?thing_id=t1"},
+				},
+			},
+		},
+	})
+
+	// Pagination
+	var count int64 = 25
+	grs.On("ThingsFind", mock.Anything, mock.AnythingOfType("*queryp.QueryParameters")).Once().Return([]*gorestapi.Thing{{ID: "t1"}}, &count, nil)
+	obj := e.GET("/api/things").WithQuery("name", "thing").WithQuery("offset", 10).WithQuery("limit", 10).WithHeader("Accept", ContentTypeHAL).
+		Expect().Status(http.StatusOK).JSON(hal).Object()
+	obj.Value("count").Equal(25)
+	obj.Value("_links").Equal(map[string]any{
+		"self":  map[string]any{"href": "/api/things?limit=10&name=thing&offset=10"},
+		"first": map[string]any{"href": "/api/things?name=thing&offset=0&limit=10"},
+		"prev":  map[string]any{"href": "/api/things?name=thing&offset=0&limit=10"},
+		"next":  map[string]any{"href": "/api/things?name=thing&offset=20&limit=10"},
+		"last":  map[string]any{"href": "/api/things?name=thing&offset=20&limit=10"},
+	})
+	obj.Path("$._embedded.things[0]._links.self.href").Equal("/api/things/t1")
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestJSONAPI(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	thingID := "t1"
+	thing := &gorestapi.Thing{ID: thingID, Name: "thing"}
+	widgets := []*gorestapi.Widget{
+		{ID: "w1", Name: "one", ThingID: &thingID, Thing: thing},
+		{ID: "w2", Name: "two", ThingID: &thingID, Thing: thing},
+		{ID: "w3", Name: "three"},
+	}
+	grs.On("WidgetsFind", mock.Anything, mock.AnythingOfType("*queryp.QueryParameters")).Once().Return(widgets, nil, nil)
+
+	e := httpexpect.New(t, server.URL)
+	jsonapi := httpexpect.ContentOpts{MediaType: ContentTypeJSONAPI}
+	obj := e.GET("/api/widgets").WithQuery("limit", 3).WithHeader("Accept", ContentTypeJSONAPI).
+		Expect().Status(http.StatusOK).JSON(jsonapi).Object()
+	obj.Value("data").Array().Length().Equal(3)
+	obj.Path("$.data[0]").Equal(map[string]any{
+		"type": "widgets",
+		"id":   "w1",
+		"attributes": map[string]any{
+			"name":        "one",
+			"description": "",
+			"created":     "0001-01-01T00:00:00Z",
+			"updated":     "0001-01-01T00:00:00Z",
+		},
+		"relationships": map[string]any{
+			"thing": map[string]any{
+				"data":  map[string]any{"type": "things", "id": "t1"},
+				"links": map[string]any{"related": "/api/things/t1"},
+			},
+		},
+		"links": map[string]any{"self": "/api/widgets/w1"},
+	})
+	obj.Path("$.data[2].relationships.thing").Equal(map[string]any{"data": nil})
+	obj.Value("included").Array().Length().Equal(1)
+	obj.Path("$.included[0].type").Equal("things")
+	obj.Path("$.included[0].relationships.widgets.links.related").Equal("/api/widgets?thing_id=t1")
+	obj.NotContainsKey("meta")
+	obj.Value("links").Equal(map[string]any{
+		"self":  "/api/widgets?limit=3",
+		"first": "/api/widgets?offset=0&limit=3",
+		"next":  "/api/widgets?offset=3&limit=3",
+	})
+
+	// Errors
+	grs.On("ThingGetByID", mock.Anything, "t2").Once().Return(nil, errors.New("database down"))
+	e.GET("/api/things/t2").WithHeader("Accept", ContentTypeJSONAPI).Expect().Status(http.StatusInternalServerError).
+		JSON(jsonapi).Object().Equal(map[string]any{"errors": []any{map[string]any{"title": "internal error"}}})
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
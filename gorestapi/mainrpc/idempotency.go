@@ -0,0 +1,190 @@
+package mainrpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/snowzach/golib/log"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header holding the idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotencyReplayedHeader is set on responses that were replayed from the store
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyMaxLength = 255
+	// idempotencyStoreTimeout limits completing or releasing a key after the request
+	idempotencyStoreTimeout = 5 * time.Second
+	// defaultIdempotencyMaxBodySize is the largest request or response body stored with a key if not configured
+	defaultIdempotencyMaxBodySize = 1 << 20
+)
+
+// IdempotencyConfig configures the idempotency middleware
+type IdempotencyConfig struct {
+	TTL time.Duration `conf:"ttl"`
+	// MaxBodySize is the largest request body, in bytes, accepted with a key. Larger responses are not stored and
+	// the key is released.
+	MaxBodySize int64 `conf:"max_body_size"`
+}
+
+// IdempotencyMiddleware stores the response of write requests made with an Idempotency-Key
+// header and replays it when the request is retried with the same key.
+func IdempotencyMiddleware(idempotencyStore gorestapi.IdempotencyStore, config IdempotencyConfig) func(http.Handler) http.Handler {
+
+	logger := log.Logger.With("context", "mainrpc.idempotency")
+	maxBodySize := config.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultIdempotencyMaxBodySize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			// Only write requests with a key are handled
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > idempotencyKeyMaxLength {
+				render.ErrInvalidRequest(w, errors.New("idempotency key too long"))
+				return
+			}
+
+			ctx := r.Context()
+
+			// Read the body so it can be fingerprinted and passed on
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			if maxErr := new(http.MaxBytesError); errors.As(err, &maxErr) {
+				render.Err(w, http.StatusRequestEntityTooLarge, render.WithStatus("request too large"), render.WithError(fmt.Errorf("requests with an idempotency key are limited to %d bytes", maxBodySize)))
+				return
+			} else if err != nil {
+				render.ErrInvalidRequest(w, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := idempotencyFingerprint(r, body)
+
+			record, claimed, err := idempotencyStore.IdempotencyClaim(ctx, key, fingerprint, config.TTL)
+			if err != nil {
+				requestID := middleware.GetReqID(ctx)
+				render.ErrInternalWithID(w, requestID, nil)
+				logger.Error("IdempotencyClaim error", "error", err, "request_id", requestID)
+				return
+			}
+
+			// Someone else holds the key
+			if !claimed {
+				if record.Fingerprint != fingerprint {
+					render.Err(w, http.StatusUnprocessableEntity, render.WithStatus("invalid request"), render.WithError(errors.New("idempotency key reused with a different request")))
+				} else if !record.Completed {
+					render.Err(w, http.StatusConflict, render.WithStatus("conflict"), render.WithError(errors.New("request with idempotency key is in progress")))
+				} else {
+					if record.ContentType != "" {
+						w.Header().Set("Content-Type", record.ContentType)
+					}
+					w.Header().Set(IdempotencyReplayedHeader, "true")
+					w.WriteHeader(record.StatusCode)
+					_, _ = w.Write(record.Body)
+				}
+				return
+			}
+
+			// Capture the response
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			responseBody := &limitedBuffer{max: maxBodySize}
+			ww.Tee(responseBody)
+
+			// The key is completed or released even if the client has gone away, otherwise it would be stuck in
+			// progress until it expires
+			storeCtx := func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+			}
+
+			// Release the key if the handler fails or panics so the request can be retried
+			completed := false
+			defer func() {
+				if !completed {
+					ctx, cancel := storeCtx()
+					defer cancel()
+					if err := idempotencyStore.IdempotencyRelease(ctx, key); err != nil {
+						logger.Error("IdempotencyRelease error", "error", err, "request_id", middleware.GetReqID(ctx))
+					}
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+
+			// Server errors are not stored so the request can be retried
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+			// Responses too large to store are released so the request can be retried
+			if responseBody.overflow {
+				logger.Warn("Idempotent response too large to store", "key", key, "request_id", middleware.GetReqID(ctx))
+				return
+			}
+			completed = true
+
+			record.Completed = true
+			record.StatusCode = status
+			record.ContentType = ww.Header().Get("Content-Type")
+			record.Body = responseBody.buf.Bytes()
+			ctx, cancel := storeCtx()
+			defer cancel()
+			if err := idempotencyStore.IdempotencyComplete(ctx, record); err != nil {
+				logger.Error("IdempotencyComplete error", "error", err, "request_id", middleware.GetReqID(ctx))
+			}
+
+		})
+	}
+}
+
+// idempotencyFingerprint identifies a request by method, path and body
+func idempotencyFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{' '})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// limitedBuffer buffers up to max bytes and discards the rest, setting overflow
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	max      int64
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.overflow || int64(b.buf.Len()+len(p)) > b.max {
+		b.overflow = true
+		b.buf.Reset()
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
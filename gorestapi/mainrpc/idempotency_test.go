@@ -0,0 +1,210 @@
+package mainrpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+func TestIdempotencyFirstRequest(t *testing.T) {
+
+	// Mock Stores
+	grs := new(mocks.GRStore)
+	ids := new(mocks.IdempotencyStore)
+
+	// Create test server
+	r := chi.NewRouter()
+	r.Use(IdempotencyMiddleware(ids, IdempotencyConfig{TTL: time.Hour}))
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	// Create Item
+	i := &gorestapi.Thing{
+		ID:   "id",
+		Name: "name",
+	}
+
+	// Mock calls to the stores
+	ids.On("IdempotencyClaim", mock.Anything, "key1", mock.AnythingOfType("string"), time.Hour).Once().Return(&gorestapi.IdempotencyRecord{Key: "key1"}, true, nil)
+	grs.On("ThingSave", mock.Anything, i).Once().Return(nil)
+	ids.On("IdempotencyComplete", mock.Anything, mock.MatchedBy(func(record *gorestapi.IdempotencyRecord) bool {
+		return record.Key == "key1" && record.Completed && record.StatusCode == http.StatusOK && record.ContentType == "application/json" && len(record.Body) > 0
+	})).Once().Return(nil)
+
+	// Make request and validate we get back proper response
+	e := httpexpect.New(t, server.URL)
+	resp := e.POST("/api/things").WithHeader(IdempotencyKeyHeader, "key1").WithJSON(i).Expect()
+	resp.Status(http.StatusOK).JSON().Object().Equal(i)
+	resp.Header(IdempotencyReplayedHeader).Empty()
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+	ids.AssertExpectations(t)
+
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+
+	// Mock Stores
+	grs := new(mocks.GRStore)
+	ids := new(mocks.IdempotencyStore)
+
+	// Create test server
+	r := chi.NewRouter()
+	r.Use(IdempotencyMiddleware(ids, IdempotencyConfig{TTL: time.Hour}))
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	// Create Item
+	i := &gorestapi.Thing{
+		ID:   "id",
+		Name: "name",
+	}
+
+	// Return the stored response for the same request
+	ids.On("IdempotencyClaim", mock.Anything, "key1", mock.AnythingOfType("string"), time.Hour).Once().Return(
+		func(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*gorestapi.IdempotencyRecord, bool, error) {
+			return &gorestapi.IdempotencyRecord{
+				Key:         key,
+				Fingerprint: fingerprint,
+				Completed:   true,
+				StatusCode:  http.StatusOK,
+				ContentType: "application/json",
+				Body:        []byte(i.String()),
+			}, false, nil
+		})
+
+	// Make request and validate we get back the stored response without calling the store
+	e := httpexpect.New(t, server.URL)
+	resp := e.POST("/api/things").WithHeader(IdempotencyKeyHeader, "key1").WithJSON(i).Expect()
+	resp.Status(http.StatusOK).JSON().Object().Equal(i)
+	resp.Header(IdempotencyReplayedHeader).Equal("true")
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+	ids.AssertExpectations(t)
+
+}
+
+func TestIdempotencyMismatch(t *testing.T) {
+
+	// Mock Stores
+	grs := new(mocks.GRStore)
+	ids := new(mocks.IdempotencyStore)
+
+	// Create test server
+	r := chi.NewRouter()
+	r.Use(IdempotencyMiddleware(ids, IdempotencyConfig{TTL: time.Hour}))
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	// The key is held by a different request
+	ids.On("IdempotencyClaim", mock.Anything, "key1", mock.AnythingOfType("string"), time.Hour).Once().Return(&gorestapi.IdempotencyRecord{Key: "key1", Fingerprint: "other", Completed: true}, false, nil)
+
+	// Make request and validate we get back proper response
+	e := httpexpect.New(t, server.URL)
+	e.POST("/api/things").WithHeader(IdempotencyKeyHeader, "key1").WithJSON(&gorestapi.Thing{Name: "name"}).Expect().Status(http.StatusUnprocessableEntity)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+	ids.AssertExpectations(t)
+
+}
+
+func TestIdempotencyClientGone(t *testing.T) {
+
+	// Mock Stores
+	ids := new(mocks.IdempotencyStore)
+	notCanceled := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil })
+
+	// The client disconnects while the handler runs
+	var cancel context.CancelFunc
+	var status int
+	handler := IdempotencyMiddleware(ids, IdempotencyConfig{TTL: time.Hour})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		w.WriteHeader(status)
+	}))
+	request := func() *http.Request {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		r := httptest.NewRequest(http.MethodPost, "/api/things", nil).WithContext(ctx)
+		r.Header.Set(IdempotencyKeyHeader, "key1")
+		return r
+	}
+
+	// The response is still stored
+	ids.On("IdempotencyClaim", mock.Anything, "key1", mock.AnythingOfType("string"), time.Hour).Twice().Return(&gorestapi.IdempotencyRecord{Key: "key1"}, true, nil)
+	ids.On("IdempotencyComplete", notCanceled, mock.Anything).Once().Return(nil)
+	status = http.StatusOK
+	handler.ServeHTTP(httptest.NewRecorder(), request())
+
+	// The key is still released
+	ids.On("IdempotencyRelease", notCanceled, "key1").Once().Return(nil)
+	status = http.StatusInternalServerError
+	handler.ServeHTTP(httptest.NewRecorder(), request())
+
+	// Check remaining expectations
+	ids.AssertExpectations(t)
+
+}
+
+func TestIdempotencyMaxBodySize(t *testing.T) {
+
+	// Mock Stores
+	ids := new(mocks.IdempotencyStore)
+
+	// The handler echoes the request
+	handler := IdempotencyMiddleware(ids, IdempotencyConfig{TTL: time.Hour, MaxBodySize: 10})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+		_, _ = w.Write([]byte("!"))
+	}))
+	request := func(body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/things", strings.NewReader(body))
+		r.Header.Set(IdempotencyKeyHeader, "key1")
+		return r
+	}
+
+	// Large requests are refused before the key is claimed
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request("0123456789a"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// Large responses are returned but not stored, the key is released
+	ids.On("IdempotencyClaim", mock.Anything, "key1", mock.AnythingOfType("string"), time.Hour).Twice().Return(&gorestapi.IdempotencyRecord{Key: "key1"}, true, nil)
+	ids.On("IdempotencyRelease", mock.Anything, "key1").Once().Return(nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request("0123456789"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789!", w.Body.String())
+
+	// Responses that fit are stored
+	ids.On("IdempotencyComplete", mock.Anything, mock.MatchedBy(func(record *gorestapi.IdempotencyRecord) bool {
+		return string(record.Body) == "012345678!"
+	})).Once().Return(nil)
+	handler.ServeHTTP(httptest.NewRecorder(), request("012345678"))
+
+	// Check remaining expectations
+	ids.AssertExpectations(t)
+
+}
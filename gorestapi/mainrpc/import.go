@@ -0,0 +1,141 @@
+package mainrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/snowzach/gorestapi/gorestapi/importer"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// ThingsImport imports things
+//
+// @ID ThingsImport
+// @Tags Things
+// @Summary Import things
+// @Description Import things from a CSV or NDJSON upload. The body can be the file or a multipart form with a file field.
+// @Accept   text/csv,application/x-ndjson,multipart/form-data
+// @Produce  json,application/msgpack,application/cbor,application/yaml,text/csv
+// @Param format query string false "Format of the upload (csv, ndjson) if not given by the content type"
+// @Param dry_run query bool false "Report what would change without saving"
+// @Param report query string false "Return the per-row report as csv"
+// @Success 200 {object} importer.Result
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /things/import [post]
+func (s *Server) ThingsImport() http.HandlerFunc {
+	return s.importHandler("ThingsImport", "things", s.importer.Things)
+}
+
+// WidgetsImport imports widgets
+//
+// @ID WidgetsImport
+// @Tags Widgets
+// @Summary Import widgets
+// @Description Import widgets from a CSV or NDJSON upload. The body can be the file or a multipart form with a file field. The thing of a widget is given by thing_id or thing.name.
+// @Accept   text/csv,application/x-ndjson,multipart/form-data
+// @Produce  json,application/msgpack,application/cbor,application/yaml,text/csv
+// @Param format query string false "Format of the upload (csv, ndjson) if not given by the content type"
+// @Param dry_run query bool false "Report what would change without saving"
+// @Param report query string false "Return the per-row report as csv"
+// @Success 200 {object} importer.Result
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /widgets/import [post]
+func (s *Server) WidgetsImport() http.HandlerFunc {
+	return s.importHandler("WidgetsImport", "widgets", s.importer.Widgets)
+}
+
+// importMediaTypes are the content types of import uploads. Any other content type can be used with the format
+// query parameter as application/octet-stream.
+var importMediaTypes = []string{"text/csv", "application/x-ndjson", "multipart/form-data", "application/octet-stream"}
+
+// importHandler handles an import upload
+func (s *Server) importHandler(method string, resource string, run func(ctx context.Context, format string, r io.Reader, dryRun bool) (*importer.Result, error)) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		body, format, err := importUpload(r)
+		if err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+		result, err := run(ctx, format, body, dryRun)
+		if err != nil {
+			var ierr *importer.InputError
+			if errors.As(err, &ierr) {
+				render.ErrInvalidRequest(w, err)
+			} else {
+				requestID := middleware.GetReqID(ctx)
+				render.ErrInternalWithID(w, requestID, nil)
+				s.logger.Error(method+" error", "error", err, "request_id", requestID)
+			}
+			return
+		}
+
+		if r.URL.Query().Get("report") == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-import-report.csv"`, resource))
+			if err := result.WriteCSV(w); err != nil {
+				s.logger.Error(method+" report error", "error", err, "request_id", middleware.GetReqID(ctx))
+			}
+			return
+		}
+
+		render.Encode(w, http.StatusOK, result)
+	}
+
+}
+
+// importUpload returns the uploaded file and its format. The format comes from the format query parameter, the
+// content type or the filename of a multipart upload.
+func importUpload(r *http.Request) (io.Reader, string, error) {
+
+	format := r.URL.Query().Get("format")
+	body := io.Reader(r.Body)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, "", err
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, "", errors.New("missing file")
+			} else if err != nil {
+				return nil, "", err
+			}
+			if part.FormName() != "file" {
+				continue
+			}
+			if format == "" {
+				format = importer.FormatForContentType(part.Header.Get("Content-Type"))
+			}
+			if format == "" {
+				format = importer.FormatForFilename(part.FileName())
+			}
+			body = part
+			break
+		}
+	} else if format == "" {
+		format = importer.FormatForContentType(mediaType)
+	}
+
+	if format == "" {
+		return nil, "", errors.New("unknown format, use a content type of text/csv or application/x-ndjson")
+	}
+	return body, format, nil
+
+}
@@ -0,0 +1,63 @@
+package mainrpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/importer"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+func TestThingsImport(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	is := new(mocks.ImportStore)
+	err := Setup(r, grs, WithImport(importer.New(grs, is, importer.Config{})))
+	assert.Nil(t, err)
+
+	is.On("ThingsSave", mock.Anything, []*gorestapi.Thing{{Name: "one"}}).Once().Run(func(args mock.Arguments) {
+		args.Get(1).([]*gorestapi.Thing)[0].ID = "t1"
+	}).Return(nil)
+
+	e := httpexpect.New(t, server.URL)
+	e.POST("/api/things/import").WithHeader("Content-Type", "text/csv").WithText("name\none\n\n").
+		Expect().Status(http.StatusOK).JSON().Object().Equal(&importer.Result{
+		Rows:    1,
+		Created: 1,
+		Report:  []*importer.RowResult{{Line: 2, ID: "t1", Action: importer.ActionCreated}},
+	})
+
+	// Multipart upload with the format from the filename and a dry run with a csv report
+	resp := e.POST("/api/things/import").WithQuery("dry_run", "true").WithQuery("report", "csv").
+		WithMultipart().WithFileBytes("file", "things.ndjson", []byte(`{"name":"two"}`+"\n"+`{"name":""}`+"\n")).
+		Expect().Status(http.StatusOK)
+	resp.Header("Content-Type").Equal("text/csv")
+	resp.Header("Content-Disposition").Equal(`attachment; filename="things-import-report.csv"`)
+	resp.Body().Equal("line,id,action,error\n1,,created,\n2,,failed,name is required\n")
+
+	// Errors
+	e.POST("/api/things/import").WithText("name\n").Expect().Status(http.StatusUnsupportedMediaType)
+	e.POST("/api/things/import").WithHeader("Content-Type", "application/octet-stream").WithText("name\n").Expect().Status(http.StatusBadRequest)
+	e.POST("/api/things/import").WithHeader("Content-Type", "text/csv").WithText("nope\n").Expect().Status(http.StatusBadRequest)
+	is.On("ThingsSave", mock.Anything, mock.Anything).Once().Return(errors.New("database down"))
+	e.POST("/api/things/import").WithQuery("format", "csv").WithHeader("Content-Type", "application/octet-stream").WithText("name\none\n").Expect().Status(http.StatusInternalServerError)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+	is.AssertExpectations(t)
+
+}
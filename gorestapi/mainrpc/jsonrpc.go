@@ -0,0 +1,398 @@
+package mainrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	JSONRPCErrorParse          = -32700
+	JSONRPCErrorInvalidRequest = -32600
+	JSONRPCErrorMethodNotFound = -32601
+	JSONRPCErrorInvalidParams  = -32602
+	JSONRPCErrorInternal       = -32603
+	// Server defined errors
+	JSONRPCErrorNotFound = -32001
+)
+
+const (
+	// jsonrpcPath is where the JSON-RPC endpoint is served
+	jsonrpcPath = "/rpc"
+	// jsonrpcMaxBatch is the maximum number of requests in a batch
+	jsonrpcMaxBatch = 100
+)
+
+// JSONRPCRequest is a JSON-RPC 2.0 request. Requests without an id are notifications.
+type JSONRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty" swaggertype:"object"`
+	ID      json.RawMessage `json:"id,omitempty" swaggertype:"string"`
+}
+
+// JSONRPCResponse is a JSON-RPC 2.0 response
+type JSONRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id" swaggertype:"string"`
+}
+
+// JSONRPCError is a JSON-RPC 2.0 error
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// JSONRPCMethod describes a method in the method listing
+type JSONRPCMethod struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Params      map[string]string `json:"params"`
+}
+
+// jsonrpcMethod is a registered method. The params value is only used to describe the method.
+type jsonrpcMethod struct {
+	description string
+	params      any
+	call        func(ctx context.Context, params json.RawMessage) (any, error)
+}
+
+type jsonrpcIDParams struct {
+	ID string `json:"id"`
+}
+
+type jsonrpcFindParams struct {
+	// Query uses the same format as the url query of the find endpoints
+	Query string `json:"query"`
+}
+
+type jsonrpcThingSaveParams struct {
+	ID string `json:"id,omitempty"`
+	gorestapi.ThingExample
+}
+
+type jsonrpcWidgetSaveParams struct {
+	ID string `json:"id,omitempty"`
+	gorestapi.WidgetExample
+}
+
+// jsonrpcMethods returns the methods available over JSON-RPC
+func (s *Server) jsonrpcMethods() map[string]*jsonrpcMethod {
+
+	methods := map[string]*jsonrpcMethod{
+		"Thing.Get": {
+			description: "Get a thing",
+			params:      jsonrpcIDParams{},
+			call: func(ctx context.Context, params json.RawMessage) (any, error) {
+				var p jsonrpcIDParams
+				if err := jsonrpcDecodeParams(params, &p); err != nil {
+					return nil, err
+				}
+				return s.thingGetByID(ctx, p.ID)
+			},
+		},
+		"Thing.Save": {
+			description: "Save a thing, creating it if it has no id",
+			params:      jsonrpcThingSaveParams{},
+			call: func(ctx context.Context, params json.RawMessage) (any, error) {
+				var thing = new(gorestapi.Thing)
+				if err := jsonrpcDecodeParams(params, thing); err != nil {
+					return nil, err
+				}
+				if err := s.thingSave(ctx, thing); err != nil {
+					return nil, err
+				}
+				return thing, nil
+			},
+		},
+		"Thing.Delete": {
+			description: "Delete a thing",
+			params:      jsonrpcIDParams{},
+			call: func(ctx context.Context, params json.RawMessage) (any, error) {
+				var p jsonrpcIDParams
+				if err := jsonrpcDecodeParams(params, &p); err != nil {
+					return nil, err
+				}
+				if err := s.thingDeleteByID(ctx, p.ID); err != nil {
+					return nil, err
+				}
+				return true, nil
+			},
+		},
+		"Thing.Find": {
+			description: "Find things",
+			params:      jsonrpcFindParams{},
+			call: func(ctx context.Context, params json.RawMessage) (any, error) {
+				qp, err := jsonrpcFindQueryParameters(params)
+				if err != nil {
+					return nil, err
+				}
+				things, count, err := s.thingsFind(ctx, qp)
+				if err != nil {
+					return nil, err
+				}
+				return store.Results{Count: count, Results: things}, nil
+			},
+		},
+		"Widget.Get": {
+			description: "Get a widget",
+			params:      jsonrpcIDParams{},
+			call: func(ctx context.Context, params json.RawMessage) (any, error) {
+				var p jsonrpcIDParams
+				if err := jsonrpcDecodeParams(params, &p); err != nil {
+					return nil, err
+				}
+				return s.widgetGetByID(ctx, p.ID)
+			},
+		},
+		"Widget.Save": {
+			description: "Save a widget, creating it if it has no id",
+			params:      jsonrpcWidgetSaveParams{},
+			call: func(ctx context.Context, params json.RawMessage) (any, error) {
+				var widget = new(gorestapi.Widget)
+				if err := jsonrpcDecodeParams(params, widget); err != nil {
+					return nil, err
+				}
+				if err := s.widgetSave(ctx, widget); err != nil {
+					return nil, err
+				}
+				return widget, nil
+			},
+		},
+		"Widget.Delete": {
+			description: "Delete a widget",
+			params:      jsonrpcIDParams{},
+			call: func(ctx context.Context, params json.RawMessage) (any, error) {
+				var p jsonrpcIDParams
+				if err := jsonrpcDecodeParams(params, &p); err != nil {
+					return nil, err
+				}
+				if err := s.widgetDeleteByID(ctx, p.ID); err != nil {
+					return nil, err
+				}
+				return true, nil
+			},
+		},
+		"Widget.Find": {
+			description: "Find widgets",
+			params:      jsonrpcFindParams{},
+			call: func(ctx context.Context, params json.RawMessage) (any, error) {
+				qp, err := jsonrpcFindQueryParameters(params)
+				if err != nil {
+					return nil, err
+				}
+				widgets, count, err := s.widgetsFind(ctx, qp)
+				if err != nil {
+					return nil, err
+				}
+				return store.Results{Count: count, Results: widgets}, nil
+			},
+		},
+	}
+
+	// The method listing is generated from the registered methods
+	listing := make([]*JSONRPCMethod, 0, len(methods)+1)
+	methods["rpc.methods"] = &jsonrpcMethod{
+		description: "List the available methods",
+		params:      struct{}{},
+		call: func(ctx context.Context, params json.RawMessage) (any, error) {
+			return listing, nil
+		},
+	}
+	for name, method := range methods {
+		listing = append(listing, &JSONRPCMethod{
+			Name:        name,
+			Description: method.description,
+			Params:      jsonrpcParamsDescription(reflect.TypeOf(method.params)),
+		})
+	}
+	sort.Slice(listing, func(i, j int) bool { return listing[i].Name < listing[j].Name })
+
+	return methods
+
+}
+
+// JSONRPC handles JSON-RPC 2.0 requests for a single method or a batch of methods. It is served at /rpc, outside of
+// the REST API and its documents. Call rpc.methods for the list of methods.
+func (s *Server) JSONRPC() http.HandlerFunc {
+
+	methods := s.jsonrpcMethods()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+		body = bytes.TrimSpace(body)
+		if !json.Valid(body) {
+			render.Encode(w, http.StatusOK, jsonrpcErrorResponse(nil, &JSONRPCError{Code: JSONRPCErrorParse, Message: "parse error"}))
+			return
+		}
+
+		// Single request
+		if body[0] != '[' {
+			if resp := s.jsonrpcCall(ctx, methods, body); resp != nil {
+				render.Encode(w, http.StatusOK, resp)
+			} else {
+				render.NoContent(w)
+			}
+			return
+		}
+
+		// Batch request
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+			render.Encode(w, http.StatusOK, jsonrpcErrorResponse(nil, &JSONRPCError{Code: JSONRPCErrorInvalidRequest, Message: "invalid request"}))
+			return
+		} else if len(batch) > jsonrpcMaxBatch {
+			render.Encode(w, http.StatusOK, jsonrpcErrorResponse(nil, &JSONRPCError{Code: JSONRPCErrorInvalidRequest, Message: fmt.Sprintf("batch exceeds %d requests", jsonrpcMaxBatch)}))
+			return
+		}
+		responses := make([]*JSONRPCResponse, 0, len(batch))
+		for _, raw := range batch {
+			if resp := s.jsonrpcCall(ctx, methods, raw); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			render.NoContent(w)
+			return
+		}
+		render.Encode(w, http.StatusOK, responses)
+
+	}
+
+}
+
+// jsonrpcCall calls a single request. It returns nil for notifications.
+func (s *Server) jsonrpcCall(ctx context.Context, methods map[string]*jsonrpcMethod, raw json.RawMessage) *JSONRPCResponse {
+
+	var req JSONRPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return jsonrpcErrorResponse(req.ID, &JSONRPCError{Code: JSONRPCErrorInvalidRequest, Message: "invalid request"})
+	}
+
+	var result any
+	var err error
+	if method, ok := methods[req.Method]; !ok {
+		err = &JSONRPCError{Code: JSONRPCErrorMethodNotFound, Message: "method not found"}
+	} else {
+		result, err = method.call(ctx, req.Params)
+	}
+
+	// Notifications do not get a response
+	if req.ID == nil {
+		return nil
+	}
+
+	var b []byte
+	if err == nil {
+		b, err = json.Marshal(result)
+	}
+	if err != nil {
+		var jerr *JSONRPCError
+		var oerr *opError
+		if errors.As(err, &oerr) {
+			jerr = jsonrpcOpError(oerr)
+		} else if !errors.As(err, &jerr) {
+			requestID := middleware.GetReqID(ctx)
+			s.logger.Error(req.Method+" error", "error", err, "request_id", requestID)
+			jerr = &JSONRPCError{Code: JSONRPCErrorInternal, Message: "internal error", Data: map[string]string{"request_id": requestID}}
+		}
+		return jsonrpcErrorResponse(req.ID, jerr)
+	}
+	return &JSONRPCResponse{JSONRPC: "2.0", Result: b, ID: req.ID}
+
+}
+
+// jsonrpcOpError maps the error of an operation to a JSON-RPC error, the REST handlers map it to the HTTP status
+func jsonrpcOpError(err *opError) *JSONRPCError {
+	switch err.Status {
+	case http.StatusNotFound:
+		return &JSONRPCError{Code: JSONRPCErrorNotFound, Message: err.Error()}
+	case http.StatusBadRequest:
+		return &JSONRPCError{Code: JSONRPCErrorInvalidParams, Message: err.Error()}
+	}
+	return &JSONRPCError{Code: JSONRPCErrorInternal, Message: "internal error", Data: map[string]string{"request_id": err.RequestID}}
+}
+
+// jsonrpcErrorResponse builds an error response, the id is null if it is not known
+func jsonrpcErrorResponse(id json.RawMessage, err *JSONRPCError) *JSONRPCResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &JSONRPCResponse{JSONRPC: "2.0", Error: err, ID: id}
+}
+
+// jsonrpcDecodeParams decodes by-name params
+func jsonrpcDecodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if params[0] != '{' {
+		return &JSONRPCError{Code: JSONRPCErrorInvalidParams, Message: "params must be an object"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &JSONRPCError{Code: JSONRPCErrorInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// jsonrpcFindQueryParameters parses the query of the find params
+func jsonrpcFindQueryParameters(params json.RawMessage) (*queryp.QueryParameters, error) {
+	var p jsonrpcFindParams
+	if err := jsonrpcDecodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	return findQueryParameters(p.Query)
+}
+
+// jsonrpcParamsDescription describes the fields of a params struct by their json name and type
+func jsonrpcParamsDescription(t reflect.Type) map[string]string {
+	ret := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			for name, typ := range jsonrpcParamsDescription(field.Type) {
+				ret[name] = typ
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		typ := field.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		ret[name] = typ.Kind().String()
+	}
+	return ret
+}
@@ -0,0 +1,131 @@
+package mainrpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+func TestJSONRPC(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs, WithJSONRPC())
+	assert.Nil(t, err)
+
+	grs.On("ThingGetByID", mock.Anything, "id").Once().Return(&gorestapi.Thing{ID: "id", Name: "name"}, nil)
+
+	e := httpexpect.New(t, server.URL)
+	e.POST("/rpc").WithJSON(map[string]any{"jsonrpc": "2.0", "method": "Thing.Get", "params": map[string]any{"id": "id"}, "id": 1}).
+		Expect().Status(http.StatusOK).JSON().Object().Equal(map[string]any{
+		"jsonrpc": "2.0",
+		"result":  map[string]any{"id": "id", "created": "0001-01-01T00:00:00Z", "updated": "0001-01-01T00:00:00Z", "name": "name", "description": ""},
+		"id":      1,
+	})
+
+	// Store errors
+	grs.On("ThingGetByID", mock.Anything, "missing").Once().Return(nil, store.ErrNotFound)
+	e.POST("/rpc").WithJSON(map[string]any{"jsonrpc": "2.0", "method": "Thing.Get", "params": map[string]any{"id": "missing"}, "id": "a"}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("error").Object().Value("code").Equal(JSONRPCErrorNotFound)
+	grs.On("ThingDeleteByID", mock.Anything, "broken").Once().Return(errors.New("database down"))
+	e.POST("/rpc").WithJSON(map[string]any{"jsonrpc": "2.0", "method": "Thing.Delete", "params": map[string]any{"id": "broken"}, "id": "b"}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("error").Object().Value("code").Equal(JSONRPCErrorInternal)
+
+	// Protocol errors
+	e.POST("/rpc").WithText("{").Expect().Status(http.StatusOK).JSON().Object().Equal(map[string]any{
+		"jsonrpc": "2.0", "error": map[string]any{"code": JSONRPCErrorParse, "message": "parse error"}, "id": nil,
+	})
+	e.POST("/rpc").WithJSON(map[string]any{"method": "Thing.Get", "id": 1}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("error").Object().Value("code").Equal(JSONRPCErrorInvalidRequest)
+	e.POST("/rpc").WithJSON(map[string]any{"jsonrpc": "2.0", "method": "Thing.Nope", "id": 1}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("error").Object().Value("code").Equal(JSONRPCErrorMethodNotFound)
+	e.POST("/rpc").WithJSON(map[string]any{"jsonrpc": "2.0", "method": "Thing.Get", "params": []any{"id"}, "id": 1}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("error").Object().Value("code").Equal(JSONRPCErrorInvalidParams)
+	e.POST("/rpc").WithJSON([]any{}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("error").Object().Value("code").Equal(JSONRPCErrorInvalidRequest)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestJSONRPCBatch(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs, WithJSONRPC())
+	assert.Nil(t, err)
+
+	var count int64 = 1
+	grs.On("WidgetsFind", mock.Anything, mock.MatchedBy(func(qp *queryp.QueryParameters) bool {
+		return qp.Filter.String() == "name=red" && qp.Limit == 5
+	})).Once().Return([]*gorestapi.Widget{{ID: "w1", Name: "red"}}, &count, nil)
+	grs.On("WidgetDeleteByID", mock.Anything, "w2").Once().Return(nil)
+
+	// Notifications are called but get no response
+	e := httpexpect.New(t, server.URL)
+	batch := e.POST("/rpc").WithJSON([]any{
+		map[string]any{"jsonrpc": "2.0", "method": "Widget.Find", "params": map[string]any{"query": "name=red&limit=5"}, "id": 1},
+		map[string]any{"jsonrpc": "2.0", "method": "Widget.Delete", "params": map[string]any{"id": "w2"}},
+		"invalid",
+	}).Expect().Status(http.StatusOK).JSON().Array()
+	batch.Length().Equal(2)
+	batch.Element(0).Object().Value("result").Object().Value("count").Equal(1)
+	batch.Element(1).Object().Value("error").Object().Value("code").Equal(JSONRPCErrorInvalidRequest)
+
+	// Only notifications
+	e.POST("/rpc").WithJSON([]any{
+		map[string]any{"jsonrpc": "2.0", "method": "Widget.Nope"},
+	}).Expect().Status(http.StatusNoContent)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestJSONRPCMethods(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs, WithJSONRPC())
+	assert.Nil(t, err)
+
+	e := httpexpect.New(t, server.URL)
+	methods := e.POST("/rpc").WithJSON(map[string]any{"jsonrpc": "2.0", "method": "rpc.methods", "id": 1}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("result").Array()
+	methods.Length().Equal(9)
+	methods.Element(0).Object().Value("name").Equal("Thing.Delete")
+	methods.Element(2).Object().Equal(map[string]any{
+		"name":        "Thing.Get",
+		"description": "Get a thing",
+		"params":      map[string]any{"id": "string"},
+	})
+	methods.Element(7).Object().Value("params").Object().Equal(map[string]any{"id": "string", "name": "string", "description": "string", "thing_id": "string"})
+
+}
@@ -1,12 +1,18 @@
 package mainrpc
 
 import (
+	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/snowzach/golib/log"
 
 	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/gqlapi"
+	"github.com/snowzach/gorestapi/gorestapi/importer"
+	"github.com/snowzach/gorestapi/gorestapi/openapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
 )
 
 // Server is the API web server
@@ -14,30 +20,198 @@ type Server struct {
 	logger  *slog.Logger
 	router  chi.Router
 	grStore gorestapi.GRStore
+
+	eventStore     gorestapi.EventStore
+	eventBroker    *EventBroker
+	eventKeepalive time.Duration
+
+	wsEnabled        bool
+	wsAllowedOrigins func() []string
+
+	webhookStore gorestapi.WebhookStore
+
+	importer *importer.Importer
+
+	txStore          gorestapi.TxStore
+	batchEnabled     bool
+	batchMaxRequests int
+
+	jsonrpcEnabled bool
+
+	graphqlEnabled bool
+	graphqlSchema  *gqlapi.Schema
+
+	openapi                  *openapi.Document
+	openapiValidation        bool
+	openapiValidateResponses bool
+}
+
+// Option configures optional features of the server
+type Option func(s *Server)
+
+// WithEvents enables the event stream endpoint using the event store to resume streams
+// and the broker for live events. Keepalives are sent when the stream is idle.
+func WithEvents(eventStore gorestapi.EventStore, eventBroker *EventBroker, keepalive time.Duration) Option {
+	return func(s *Server) {
+		s.eventStore = eventStore
+		s.eventBroker = eventBroker
+		if keepalive > 0 {
+			s.eventKeepalive = keepalive
+		}
+	}
+}
+
+// WithWebSocket enables the WebSocket endpoint. It requires events to be enabled. Cross origin
+// connections are only accepted from the origins returned by allowedOrigins for each connection, "*" allows any
+// origin. If it is nil only same origin connections are accepted.
+func WithWebSocket(allowedOrigins func() []string) Option {
+	return func(s *Server) {
+		s.wsEnabled = true
+		s.wsAllowedOrigins = allowedOrigins
+	}
+}
+
+// WithWebhooks enables the webhook endpoints
+func WithWebhooks(webhookStore gorestapi.WebhookStore) Option {
+	return func(s *Server) {
+		s.webhookStore = webhookStore
+	}
+}
+
+// WithImport enables the import endpoints
+func WithImport(importer *importer.Importer) Option {
+	return func(s *Server) {
+		s.importer = importer
+	}
+}
+
+// WithBatch enables the batch endpoint. Atomic batches run in a transaction of the store and are not supported if
+// it is nil.
+func WithBatch(txStore gorestapi.TxStore, maxRequests int) Option {
+	return func(s *Server) {
+		s.txStore = txStore
+		s.batchEnabled = true
+		if maxRequests > 0 {
+			s.batchMaxRequests = maxRequests
+		}
+	}
+}
+
+// WithJSONRPC enables the JSON-RPC 2.0 endpoint
+func WithJSONRPC() Option {
+	return func(s *Server) {
+		s.jsonrpcEnabled = true
+	}
+}
+
+// WithGraphQL enables the GraphQL endpoint
+func WithGraphQL() Option {
+	return func(s *Server) {
+		s.graphqlEnabled = true
+	}
+}
+
+// WithOpenAPIValidation rejects requests that don't match the OpenAPI document. Responses that don't match
+// are logged if enabled, which is meant for development.
+func WithOpenAPIValidation(responses bool) Option {
+	return func(s *Server) {
+		s.openapiValidation = true
+		s.openapiValidateResponses = responses
+	}
 }
 
 // Setup will setup the API listener
-func Setup(router chi.Router, grStore gorestapi.GRStore) error {
+func Setup(router chi.Router, grStore gorestapi.GRStore, opts ...Option) error {
 
 	s := &Server{
 		logger:  log.Logger.With("context", "mainrpc"),
 		router:  router,
 		grStore: grStore,
+
+		eventKeepalive: 15 * time.Second,
+
+		batchMaxRequests: defaultBatchMaxRequests,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.graphqlEnabled {
+		var err error
+		if s.graphqlSchema, err = gqlapi.NewSchema(grStore); err != nil {
+			return fmt.Errorf("could not create graphql schema: %w", err)
+		}
 	}
 
 	// Base Functions
-	s.router.Route("/api", func(r chi.Router) {
-		r.Post("/things", s.ThingSave())
-		r.Get("/things/{id}", s.ThingGetByID())
-		r.Delete("/things/{id}", s.ThingDeleteByID())
-		r.Get("/things", s.ThingsFind())
-
-		r.Post("/widgets", s.WidgetSave())
-		r.Get("/widgets/{id}", s.WidgetGetByID())
-		r.Delete("/widgets/{id}", s.WidgetDeleteByID())
-		r.Get("/widgets", s.WidgetsFind())
+	s.router.Route(apiPath, func(r chi.Router) {
+
+		if s.openapiValidation {
+			r.Use(s.openAPIValidator)
+		}
+
+		// Requests and responses are in the content type negotiated with the client
+		r.Group(func(r chi.Router) {
+			r.Use(render.Negotiate(render.Represents(hypermediaCodecs...)))
+
+			r.Post("/things", s.ThingSave())
+			r.Get("/things/{id}", s.ThingGetByID())
+			r.Delete("/things/{id}", s.ThingDeleteByID())
+
+			r.Post("/widgets", s.WidgetSave())
+			r.Get("/widgets/{id}", s.WidgetGetByID())
+			r.Delete("/widgets/{id}", s.WidgetDeleteByID())
+
+			if s.webhookStore != nil {
+				r.Post("/webhooks", s.WebhookSave())
+				r.Get("/webhooks/{id}", s.WebhookGetByID())
+				r.Delete("/webhooks/{id}", s.WebhookDeleteByID())
+				r.Get("/webhooks", s.WebhooksFind())
+				r.Get("/webhooks/{id}/deliveries", s.WebhookDeliveriesFind())
+				r.Post("/webhooks/{id}/deliveries/{delivery_id}/redeliver", s.WebhookDeliveryRedeliver())
+			}
+
+			if s.batchEnabled {
+				r.Post("/batch", s.Batch())
+			}
+		})
+
+		// The find endpoints also export
+		findNegotiate := render.Negotiate(render.Represents(hypermediaCodecs...), render.Produces(exportMediaTypes()...))
+		r.With(findNegotiate).Get("/things", s.ThingsFind())
+		r.With(findNegotiate).Get("/widgets", s.WidgetsFind())
+
+		if s.importer != nil {
+			importNegotiate := render.Negotiate(render.Consumes(importMediaTypes...), render.Produces("text/csv"))
+			r.With(importNegotiate).Post("/things/import", s.ThingsImport())
+			r.With(importNegotiate).Post("/widgets/import", s.WidgetsImport())
+		}
+
+		// Streams and JSON protocols are always JSON
+		if s.eventBroker != nil {
+			r.Get("/events", s.EventsStream())
+			if s.wsEnabled {
+				r.Get("/ws", s.WebSocket())
+			}
+		}
+
+		if s.graphqlSchema != nil {
+			r.Post("/graphql", s.GraphQL())
+		}
 	})
 
+	// The OpenAPI document is generated from the routes
+	var err error
+	if s.openapi, err = s.openAPIDocument(); err != nil {
+		return fmt.Errorf("could not create openapi document: %w", err)
+	}
+	s.router.Get(openAPIPath, s.openapi.ServeHTTP)
+
+	// JSON-RPC is its own protocol beside the REST API
+	if s.jsonrpcEnabled {
+		s.router.Post(jsonrpcPath, s.JSONRPC())
+	}
+
 	return nil
 
 }
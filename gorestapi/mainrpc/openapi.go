@@ -0,0 +1,315 @@
+package mainrpc
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/snowzach/golib/version"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/importer"
+	"github.com/snowzach/gorestapi/gorestapi/openapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// openAPIPath is where the OpenAPI document is served
+const openAPIPath = "/api-docs/openapi.json"
+
+// openAPIMaxValidatedResponse is the largest response body that is validated
+const openAPIMaxValidatedResponse = 1 << 20
+
+// apiOperation describes an operation of the API in the OpenAPI document
+type apiOperation struct {
+	id          string
+	tag         string
+	summary     string
+	params      []*openapi.Parameter
+	request     *openapi.Schema // request body in the negotiated codecs, nil if there is none
+	consumes    []string        // other media types of the request body without a schema
+	response    *openapi.Schema // successful response in the negotiated codecs, nil if there is none
+	produces    []string        // other media types of a successful response without a schema, ie. exports
+	status      int             // status of a successful response if not 200
+	jsonOnly    bool            // the request and response are always JSON rather than negotiated
+	hypermedia  bool            // the response can be HAL or JSON:API
+	conditional bool            // the response supports conditional requests
+	protocol    bool            // invalid requests are reported by the protocol of the endpoint rather than rejected
+}
+
+// results documents the store.Results envelope of the find endpoints with the type of the results
+type results[T any] struct {
+	Count   *int64 `json:"count,omitempty"`
+	Results []T    `json:"results"`
+}
+
+// apiOperations describes the operations of the API by method and path relative to the API
+func apiOperations(doc *openapi.Document) map[string]*apiOperation {
+
+	exportFormats := make([]any, 0, len(exportContentTypes))
+	for format := range exportContentTypes {
+		exportFormats = append(exportFormats, format)
+	}
+	slices.SortFunc(exportFormats, func(a, b any) int { return strings.Compare(a.(string), b.(string)) })
+	exportParam := queryParam("format", openapi.TypeString, "Export all matching records in the format", exportFormats...)
+
+	importParams := []*openapi.Parameter{
+		queryParam("format", openapi.TypeString, "Format of the upload if not given by the content type", importer.FormatCSV, importer.FormatNDJSON),
+		queryParam("dry_run", openapi.TypeBoolean, "Report what would change without saving"),
+		queryParam("report", openapi.TypeString, "Return the per-row report as csv", "csv"),
+	}
+
+	deliveryIDParam := &openapi.Parameter{Name: "delivery_id", In: openapi.InPath, Required: true, Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeInteger}, Format: "int64"}}
+
+	return map[string]*apiOperation{
+		"POST /things":        {id: "ThingSave", tag: "Things", summary: "Save thing", request: doc.Schema(gorestapi.Thing{}), response: doc.Schema(gorestapi.Thing{}), hypermedia: true},
+		"GET /things/{id}":    {id: "ThingGetByID", tag: "Things", summary: "Get thing", response: doc.Schema(gorestapi.Thing{}), hypermedia: true, conditional: true},
+		"DELETE /things/{id}": {id: "ThingDeleteByID", tag: "Things", summary: "Delete thing", status: http.StatusNoContent},
+		"GET /things": {id: "ThingsFind", tag: "Things", summary: "Find things", params: append(findParams("id", "name", "description"), exportParam),
+			response: doc.Schema(results[gorestapi.Thing]{}), produces: exportMediaTypes(), hypermedia: true, conditional: true},
+		"POST /things/import": {id: "ThingsImport", tag: "Things", summary: "Import things", params: importParams, consumes: importMediaTypes,
+			response: doc.Schema(importer.Result{}), produces: []string{"text/csv"}},
+
+		"POST /widgets":        {id: "WidgetSave", tag: "Widgets", summary: "Save widget", request: doc.Schema(gorestapi.Widget{}), response: doc.Schema(gorestapi.Widget{}), hypermedia: true},
+		"GET /widgets/{id}":    {id: "WidgetGetByID", tag: "Widgets", summary: "Get widget", response: doc.Schema(gorestapi.Widget{}), hypermedia: true, conditional: true},
+		"DELETE /widgets/{id}": {id: "WidgetDeleteByID", tag: "Widgets", summary: "Delete widget", status: http.StatusNoContent},
+		"GET /widgets": {id: "WidgetsFind", tag: "Widgets", summary: "Find widgets", params: append(findParams("id", "name", "description", "thing_id"), exportParam),
+			response: doc.Schema(results[gorestapi.Widget]{}), produces: exportMediaTypes(), hypermedia: true, conditional: true},
+		"POST /widgets/import": {id: "WidgetsImport", tag: "Widgets", summary: "Import widgets", params: importParams, consumes: importMediaTypes,
+			response: doc.Schema(importer.Result{}), produces: []string{"text/csv"}},
+
+		"POST /webhooks":        {id: "WebhookSave", tag: "Webhooks", summary: "Save webhook", request: doc.Schema(gorestapi.Webhook{}), response: doc.Schema(gorestapi.Webhook{}), hypermedia: true},
+		"GET /webhooks/{id}":    {id: "WebhookGetByID", tag: "Webhooks", summary: "Get webhook", response: doc.Schema(gorestapi.Webhook{}), hypermedia: true},
+		"DELETE /webhooks/{id}": {id: "WebhookDeleteByID", tag: "Webhooks", summary: "Delete webhook", status: http.StatusNoContent},
+		"GET /webhooks": {id: "WebhooksFind", tag: "Webhooks", summary: "Find webhooks", params: findParams("id", "url"),
+			response: doc.Schema(results[gorestapi.Webhook]{}), hypermedia: true},
+		"GET /webhooks/{id}/deliveries": {id: "WebhookDeliveriesFind", tag: "Webhooks", summary: "Find webhook deliveries", params: findParams("event", "status"),
+			response: doc.Schema(results[gorestapi.WebhookDelivery]{}), hypermedia: true},
+		"POST /webhooks/{id}/deliveries/{delivery_id}/redeliver": {id: "WebhookDeliveryRedeliver", tag: "Webhooks", summary: "Redeliver webhook delivery",
+			params: []*openapi.Parameter{deliveryIDParam}, response: doc.Schema(gorestapi.WebhookDelivery{}), hypermedia: true},
+
+		"POST /batch": {id: "Batch", tag: "Batch", summary: "Batch requests", request: doc.Schema(Batch{}), response: doc.Schema([]*BatchResponse{})},
+
+		"GET /events": {id: "EventsStream", tag: "Events", summary: "Stream events", jsonOnly: true, produces: []string{"text/event-stream"},
+			params: []*openapi.Parameter{
+				queryParam("resource", openapi.TypeString, "Comma separated resources (thing, widget)"),
+				queryParam("id", openapi.TypeString, "Comma separated resource IDs"),
+				queryParam("last_event_id", openapi.TypeInteger, "Resume after this event ID"),
+				{Name: "Last-Event-ID", In: openapi.InHeader, Description: "Resume after this event ID", Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeInteger}}},
+			}},
+		"GET /ws": {id: "WebSocket", tag: "Events", summary: "Subscribe to events", jsonOnly: true, status: http.StatusSwitchingProtocols},
+
+		"POST /graphql": {id: "GraphQL", tag: "GraphQL", summary: "GraphQL", jsonOnly: true, protocol: true, request: doc.Schema(GraphQLRequest{}), response: &openapi.Schema{Type: openapi.Types{openapi.TypeObject}}},
+	}
+
+}
+
+// queryParam is an optional query parameter
+func queryParam(name string, typ string, description string, enum ...any) *openapi.Parameter {
+	return &openapi.Parameter{Name: name, In: openapi.InQuery, Description: description, Schema: &openapi.Schema{Type: openapi.Types{typ}, Enum: enum}}
+}
+
+// findParams are the parameters of the find endpoints with the fields that can be filtered
+func findParams(filters ...string) []*openapi.Parameter {
+	var params []*openapi.Parameter
+	for _, filter := range filters {
+		params = append(params, queryParam(filter, openapi.TypeString, "Filter by "+filter))
+	}
+	return append(params,
+		queryParam("offset", openapi.TypeInteger, "Skip this many records"),
+		queryParam("limit", openapi.TypeInteger, "Return at most this many records"),
+		queryParam("sort", openapi.TypeString, "Comma separated fields to sort by, prefix with - to sort descending"),
+	)
+}
+
+// pathParamRegexp matches the parameters of a route pattern, ie. {id} or {id:[0-9]+}
+var pathParamRegexp = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+
+// openAPIDocument documents the routes of the API. Every route must be described by an operation.
+func (s *Server) openAPIDocument() (*openapi.Document, error) {
+
+	doc := openapi.New(openapi.Info{
+		Title:       "gorestapi",
+		Description: "Things and widgets API",
+		Version:     version.GitVersion,
+	}, apiPath)
+
+	operations := apiOperations(doc)
+	errSchema := doc.Schema(render.ErrResponse{})
+
+	err := chi.Walk(s.router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+
+		path, ok := strings.CutPrefix(route, apiPath)
+		if !ok {
+			return nil
+		}
+		ao, ok := operations[method+" "+path]
+		if !ok {
+			return fmt.Errorf("route %s %s has no operation", method, route)
+		}
+
+		op := &openapi.Operation{
+			OperationID:    ao.id,
+			Tags:           []string{ao.tag},
+			Summary:        ao.summary,
+			Responses:      make(map[string]*openapi.Response),
+			SkipValidation: ao.protocol,
+		}
+
+		// Path parameters are strings unless the operation describes them
+		for _, match := range pathParamRegexp.FindAllStringSubmatch(path, -1) {
+			if !slices.ContainsFunc(ao.params, func(p *openapi.Parameter) bool { return p.Name == match[1] && p.In == openapi.InPath }) {
+				op.Parameters = append(op.Parameters, &openapi.Parameter{Name: match[1], In: openapi.InPath, Required: true, Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeString}}})
+			}
+		}
+		op.Parameters = append(op.Parameters, ao.params...)
+		if ao.conditional {
+			op.Parameters = append(op.Parameters,
+				&openapi.Parameter{Name: "If-None-Match", In: openapi.InHeader, Description: "ETag of the cached response", Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeString}}},
+				&openapi.Parameter{Name: "If-Modified-Since", In: openapi.InHeader, Description: "Last-Modified of the cached response", Schema: &openapi.Schema{Type: openapi.Types{openapi.TypeString}}},
+			)
+		}
+
+		codecs := []string{render.ContentTypeJSON}
+		if !ao.jsonOnly {
+			codecs = codecs[:0]
+			for _, codec := range render.Codecs {
+				codecs = append(codecs, codec.ContentType)
+			}
+		}
+		var representations []string
+		if ao.hypermedia {
+			representations = []string{ContentTypeHAL, ContentTypeJSONAPI}
+		}
+
+		if ao.request != nil || len(ao.consumes) > 0 {
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: mediaTypes(ao.request, codecs, ao.consumes)}
+		}
+
+		status := ao.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &openapi.Response{Description: http.StatusText(status)}
+		if ao.response != nil || len(ao.produces) > 0 {
+			success.Content = mediaTypes(ao.response, codecs, append(representations, ao.produces...))
+		}
+		op.Responses[fmt.Sprint(status)] = success
+		op.Responses["default"] = &openapi.Response{Description: "Error", Content: mediaTypes(errSchema, codecs, representations)}
+		if ao.conditional {
+			op.Responses[fmt.Sprint(http.StatusNotModified)] = &openapi.Response{Description: http.StatusText(http.StatusNotModified)}
+		}
+
+		doc.AddOperation(method, path, op)
+		return nil
+
+	})
+
+	return doc, err
+
+}
+
+// mediaTypes are the codecs with the schema and the other media types without a schema
+func mediaTypes(schema *openapi.Schema, codecs []string, others []string) map[string]*openapi.MediaType {
+	content := make(map[string]*openapi.MediaType)
+	if schema != nil {
+		for _, mediaType := range codecs {
+			content[mediaType] = &openapi.MediaType{Schema: schema}
+		}
+	}
+	for _, mediaType := range others {
+		content[mediaType] = &openapi.MediaType{}
+	}
+	return content
+}
+
+// openAPIValidator rejects requests that don't match their operation in the OpenAPI document. If enabled responses
+// that don't match are logged.
+func (s *Server) openAPIValidator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		rctx := chi.NewRouteContext()
+		if s.openapi == nil || !s.router.Match(rctx, r.Method, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		path := strings.TrimPrefix(rctx.RoutePattern(), apiPath)
+		op := s.openapi.Operation(r.Method, path)
+		if op == nil || op.SkipValidation {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		params := make(map[string]string)
+		for i, key := range rctx.URLParams.Keys {
+			params[key] = rctx.URLParams.Values[i]
+		}
+		if err := s.openapi.ValidateRequest(op, r, params); err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+
+		// Streams are not validated
+		if !s.openapiValidateResponses || r.Header.Get("Accept") == "text/event-stream" || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		vw := &validatingResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(vw, r)
+		if vw.status == 0 {
+			vw.status = http.StatusOK
+		}
+		if vw.truncated {
+			return
+		}
+		if err := s.openapi.ValidateResponse(op, vw.status, w.Header().Get("Content-Type"), vw.body.Bytes()); err != nil {
+			s.logger.Warn("Response does not match the OpenAPI document", "method", r.Method, "path", path, "status", vw.status, "error", err, "request_id", middleware.GetReqID(r.Context()))
+		}
+
+	})
+}
+
+// validatingResponseWriter keeps a copy of the response to validate it
+type validatingResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *validatingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *validatingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.truncated {
+		if w.body.Len()+len(b) > openAPIMaxValidatedResponse {
+			w.truncated = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *validatingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *validatingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,65 @@
+package mainrpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+func TestOpenAPI(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs, WithWebhooks(new(mocks.WebhookStore)), WithOpenAPIValidation(true))
+	assert.Nil(t, err)
+
+	e := httpexpect.New(t, server.URL)
+	doc := e.GET(openAPIPath).Expect().Status(http.StatusOK).JSON().Object()
+	doc.ValueEqual("openapi", "3.1.0")
+	doc.Path("$.servers[0].url").Equal("/api")
+	paths := doc.Value("paths").Object()
+	paths.Keys().ContainsOnly(
+		"/things", "/things/{id}", "/widgets", "/widgets/{id}",
+		"/webhooks", "/webhooks/{id}", "/webhooks/{id}/deliveries", "/webhooks/{id}/deliveries/{delivery_id}/redeliver",
+	)
+
+	// Find returns the results envelope
+	find := paths.Value("/things").Object().Value("get").Object()
+	find.ValueEqual("operationId", "ThingsFind")
+	find.Value("responses").Object().Value("200").Object().Value("content").Object().Value("application/json").Object().
+		ValueEqual("schema", map[string]any{"$ref": "#/components/schemas/ThingResults"})
+	doc.Path("$.components.schemas.ThingResults.properties.results").Equal(map[string]any{
+		"type":  []any{"array", "null"},
+		"items": map[string]any{"$ref": "#/components/schemas/Thing"},
+	})
+	paths.Value("/things/{id}").Object().Value("delete").Object().Value("responses").Object().ContainsKey("204")
+
+	// Requests are validated
+	e.POST("/api/things").WithJSON(map[string]any{"name": 1}).Expect().Status(http.StatusBadRequest).
+		JSON().Object().ValueEqual("error", "body.name: expected string, got number")
+	e.GET("/api/things").WithQuery("limit", "ten").Expect().Status(http.StatusBadRequest).
+		JSON().Object().ValueEqual("error", "query.limit: expected integer, got string")
+	e.POST("/api/webhooks/w1/deliveries/one/redeliver").Expect().Status(http.StatusBadRequest).
+		JSON().Object().ValueEqual("error", "path.delivery_id: expected integer, got string")
+
+	grs.On("ThingSave", mock.Anything, &gorestapi.Thing{Name: "thing"}).Once().Return(nil)
+	e.POST("/api/things").WithJSON(map[string]any{"name": "thing"}).Expect().Status(http.StatusOK).
+		JSON().Object().ValueEqual("name", "thing")
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
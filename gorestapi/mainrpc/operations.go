@@ -0,0 +1,114 @@
+package mainrpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// The operations on things and widgets are shared by the REST handlers and JSON-RPC. They return an *opError that each
+// protocol renders in its own way.
+
+// opError is a failed operation
+type opError struct {
+	// Status is the HTTP status of the error, StatusBadRequest, StatusNotFound or StatusInternalServerError
+	Status int
+	// Resource is the resource that was not found
+	Resource string
+	// Err is the reason the request was invalid
+	Err error
+	// RequestID identifies the logged internal error
+	RequestID string
+}
+
+func (e *opError) Error() string {
+	switch e.Status {
+	case http.StatusNotFound:
+		return e.Resource + " not found"
+	case http.StatusBadRequest:
+		return e.Err.Error()
+	}
+	return "internal error"
+}
+
+// opErr maps a store error to an *opError, logging internal errors
+func (s *Server) opErr(ctx context.Context, name string, op store.ErrorOp, resource string, err error) error {
+	if err == nil {
+		return nil
+	} else if err == store.ErrNotFound {
+		return &opError{Status: http.StatusNotFound, Resource: resource}
+	} else if serr, ok := err.(*store.Error); ok {
+		return &opError{Status: http.StatusBadRequest, Err: serr.ErrorForOp(op)}
+	}
+	requestID := middleware.GetReqID(ctx)
+	s.logger.Error(name+" error", "error", err, "request_id", requestID)
+	return &opError{Status: http.StatusInternalServerError, RequestID: requestID}
+}
+
+// renderOpErr writes the error of an operation as a response
+func renderOpErr(w http.ResponseWriter, err error) {
+	oerr, ok := err.(*opError)
+	if !ok {
+		render.ErrInternal(w, nil)
+		return
+	}
+	switch oerr.Status {
+	case http.StatusNotFound:
+		render.ErrResourceNotFound(w, oerr.Resource)
+	case http.StatusBadRequest:
+		render.ErrInvalidRequest(w, oerr.Err)
+	default:
+		render.ErrInternalWithID(w, oerr.RequestID, nil)
+	}
+}
+
+// findQueryParameters parses the query of a find
+func findQueryParameters(rawQuery string) (*queryp.QueryParameters, error) {
+	qp, err := queryp.ParseRawQuery(rawQuery)
+	if err != nil {
+		return nil, &opError{Status: http.StatusBadRequest, Err: err}
+	}
+	return qp, nil
+}
+
+func (s *Server) thingSave(ctx context.Context, thing *gorestapi.Thing) error {
+	return s.opErr(ctx, "ThingSave", store.ErrorOpSave, "thing", s.grStore.ThingSave(ctx, thing))
+}
+
+func (s *Server) thingGetByID(ctx context.Context, id string) (*gorestapi.Thing, error) {
+	thing, err := s.grStore.ThingGetByID(ctx, id)
+	return thing, s.opErr(ctx, "ThingGetByID", store.ErrorOpGet, "thing", err)
+}
+
+func (s *Server) thingDeleteByID(ctx context.Context, id string) error {
+	return s.opErr(ctx, "ThingDeleteByID", store.ErrorOpDelete, "thing", s.grStore.ThingDeleteByID(ctx, id))
+}
+
+func (s *Server) thingsFind(ctx context.Context, qp *queryp.QueryParameters) ([]*gorestapi.Thing, *int64, error) {
+	things, count, err := s.grStore.ThingsFind(ctx, qp)
+	return things, count, s.opErr(ctx, "ThingsFind", store.ErrorOpFind, "thing", err)
+}
+
+func (s *Server) widgetSave(ctx context.Context, widget *gorestapi.Widget) error {
+	return s.opErr(ctx, "WidgetSave", store.ErrorOpSave, "widget", s.grStore.WidgetSave(ctx, widget))
+}
+
+func (s *Server) widgetGetByID(ctx context.Context, id string) (*gorestapi.Widget, error) {
+	widget, err := s.grStore.WidgetGetByID(ctx, id)
+	return widget, s.opErr(ctx, "WidgetGetByID", store.ErrorOpGet, "widget", err)
+}
+
+func (s *Server) widgetDeleteByID(ctx context.Context, id string) error {
+	return s.opErr(ctx, "WidgetDeleteByID", store.ErrorOpDelete, "widget", s.grStore.WidgetDeleteByID(ctx, id))
+}
+
+func (s *Server) widgetsFind(ctx context.Context, qp *queryp.QueryParameters) ([]*gorestapi.Widget, *int64, error) {
+	widgets, count, err := s.grStore.WidgetsFind(ctx, qp)
+	return widgets, count, s.opErr(ctx, "WidgetsFind", store.ErrorOpFind, "widget", err)
+}
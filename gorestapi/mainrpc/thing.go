@@ -4,12 +4,9 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/snowzach/queryp"
 
-	"github.com/snowzach/golib/httpserver/render"
-	"github.com/snowzach/golib/store"
 	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
 )
 
 // ThingSave saves a thing
@@ -18,8 +15,8 @@ import (
 // @Tags Things
 // @Summary Save thing
 // @Description Save a thing
-// @Accept   json
-// @Produce  json
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
 // @Param thing body gorestapi.ThingExample true "Thing"
 // @Success 200 {object} gorestapi.Thing
 // @Failure 400 {object} render.ErrResponse "Invalid Argument"
@@ -32,24 +29,17 @@ func (s *Server) ThingSave() http.HandlerFunc {
 		ctx := r.Context()
 
 		var thing = new(gorestapi.Thing)
-		if err := render.DecodeJSON(r.Body, thing); err != nil {
+		if err := render.Decode(r, thing); err != nil {
 			render.ErrInvalidRequest(w, err)
 			return
 		}
 
-		err := s.grStore.ThingSave(ctx, thing)
-		if err != nil {
-			if serr, ok := err.(*store.Error); ok {
-				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpSave))
-			} else {
-				requestID := middleware.GetReqID(ctx)
-				render.ErrInternalWithID(w, requestID, nil)
-				s.logger.Error("ThingSave error", "error", err, "request_id", requestID)
-			}
+		if err := s.thingSave(ctx, thing); err != nil {
+			renderOpErr(w, err)
 			return
 		}
 
-		render.JSON(w, http.StatusOK, thing)
+		render.Encode(w, http.StatusOK, represent(w, thingHypermedia, thing))
 	}
 
 }
@@ -61,9 +51,12 @@ func (s *Server) ThingSave() http.HandlerFunc {
 // @Summary Get thing
 // @Description Get a thing
 // @Param id path string true "ID"
-// @Accept   json
-// @Produce  json
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
+// @Param If-None-Match header string false "ETag of the cached thing"
+// @Param If-Modified-Since header string false "Last-Modified of the cached thing"
 // @Success 200 {object} gorestapi.Thing
+// @Success 304 "Not Modified"
 // @Failure 400 {object} render.ErrResponse "Invalid Argument"
 // @Failure 404 {object} render.ErrResponse "Not Found"
 // @Failure 500 {object} render.ErrResponse "Internal Error"
@@ -75,21 +68,13 @@ func (s *Server) ThingGetByID() http.HandlerFunc {
 
 		id := chi.URLParam(r, "id")
 
-		thing, err := s.grStore.ThingGetByID(ctx, id)
+		thing, err := s.thingGetByID(ctx, id)
 		if err != nil {
-			if err == store.ErrNotFound {
-				render.ErrResourceNotFound(w, "thing")
-			} else if serr, ok := err.(*store.Error); ok {
-				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpGet))
-			} else {
-				requestID := middleware.GetReqID(ctx)
-				render.ErrInternalWithID(w, requestID, nil)
-				s.logger.Error("ThingGetByID error", "error", err, "request_id", requestID)
-			}
+			renderOpErr(w, err)
 			return
 		}
 
-		render.JSON(w, http.StatusOK, thing)
+		render.EncodeConditional(w, r, represent(w, thingHypermedia, thing), thingUpdated(thing))
 	}
 
 }
@@ -100,8 +85,8 @@ func (s *Server) ThingGetByID() http.HandlerFunc {
 // @Tags Things
 // @Summary Delete thing
 // @Description Delete a thing
-// @Accept   json
-// @Produce  json
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
 // @Param id path string true "ID"
 // @Success 204 "Success"
 // @Failure 400 {object} render.ErrResponse "Invalid Argument"
@@ -115,17 +100,8 @@ func (s *Server) ThingDeleteByID() http.HandlerFunc {
 
 		id := chi.URLParam(r, "id")
 
-		err := s.grStore.ThingDeleteByID(ctx, id)
-		if err != nil {
-			if err == store.ErrNotFound {
-				render.ErrResourceNotFound(w, "thing")
-			} else if serr, ok := err.(*store.Error); ok {
-				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpDelete))
-			} else {
-				requestID := middleware.GetReqID(ctx)
-				render.ErrInternalWithID(w, requestID, nil)
-				s.logger.Error("ThingDeleteByID error", "error", err, "request_id", requestID)
-			}
+		if err := s.thingDeleteByID(ctx, id); err != nil {
+			renderOpErr(w, err)
 			return
 		}
 
@@ -141,15 +117,18 @@ func (s *Server) ThingDeleteByID() http.HandlerFunc {
 // @Tags Things
 // @Summary Find things
 // @Description Find things
-// @Accept   json
-// @Produce  json
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json,text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param id query string false "id"
 // @Param name query string false "name"
 // @Param description query string false "description"
 // @Param offset query int false "offset"
 // @Param limit query int false "limit"
 // @Param sort query string false "query"
-// @Success 200 {array} gorestapi.Thing
+// @Param format query string false "Export all matching records as csv, ndjson or xlsx"
+// @Param If-None-Match header string false "ETag of the cached results"
+// @Success 200 {object} store.Results{results=[]gorestapi.Thing}
+// @Success 304 "Not Modified"
 // @Failure 400 {object} render.ErrResponse "Invalid Argument"
 // @Failure 500 {object} render.ErrResponse "Internal Error"
 // @Router /things [get]
@@ -158,25 +137,37 @@ func (s *Server) ThingsFind() http.HandlerFunc {
 
 		ctx := r.Context()
 
-		qp, err := queryp.ParseRawQuery(r.URL.RawQuery)
+		// Export all matching records in another format
+		format, err := exportFormat(r)
 		if err != nil {
 			render.ErrInvalidRequest(w, err)
 			return
+		} else if format != "" {
+			qp, err := exportQueryParameters(r)
+			if err != nil {
+				render.ErrInvalidRequest(w, err)
+				return
+			}
+			export(s, w, r, "ThingsStream", "things", format, thingExportColumns, func(fn func(*gorestapi.Thing) error) error {
+				return s.grStore.ThingsStream(ctx, qp, fn)
+			})
+			return
 		}
 
-		things, count, err := s.grStore.ThingsFind(ctx, qp)
+		qp, err := findQueryParameters(r.URL.RawQuery)
 		if err != nil {
-			if serr, ok := err.(*store.Error); ok {
-				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpFind))
-			} else {
-				requestID := middleware.GetReqID(ctx)
-				render.ErrInternalWithID(w, requestID, nil)
-				s.logger.Error("ThingsFind error", "error", err, "request_id", requestID)
-			}
+			renderOpErr(w, err)
+			return
+		}
+
+		things, count, err := s.thingsFind(ctx, qp)
+		if err != nil {
+			renderOpErr(w, err)
 			return
 		}
 
-		render.JSON(w, http.StatusOK, store.Results{Count: count, Results: things})
+		etag := collectionETag(w, count, things, func(thing *gorestapi.Thing) string { return thing.ID }, thingUpdated)
+		render.EncodeWithETag(w, r, representPage(w, r, qp, thingHypermedia, count, things), etag)
 
 	}
 
@@ -1,9 +1,11 @@
 package mainrpc
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gavv/httpexpect/v2"
 	"github.com/go-chi/chi/v5"
@@ -12,6 +14,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
 	"github.com/snowzach/gorestapi/mocks"
 )
 
@@ -45,6 +48,49 @@ func TestThingPost(t *testing.T) {
 
 }
 
+func TestThingPostMsgPack(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	// Create Item
+	i := &gorestapi.Thing{
+		ID:   "id",
+		Name: "name",
+	}
+	codec := render.CodecFor(render.ContentTypeMsgPack)
+	b := new(bytes.Buffer)
+	assert.Nil(t, codec.Encode(b, i))
+
+	// Mock call to item store
+	grs.On("ThingSave", mock.Anything, mock.MatchedBy(func(thing *gorestapi.Thing) bool { return thing.ID == i.ID && thing.Name == i.Name })).Once().Return(nil)
+
+	// Make request and validate we get back proper response
+	e := httpexpect.New(t, server.URL)
+	resp := e.POST("/api/things").WithHeader("Content-Type", render.ContentTypeMsgPack).WithHeader("Accept", render.ContentTypeMsgPack).WithBytes(b.Bytes()).
+		Expect().Status(http.StatusOK)
+	resp.Header("Content-Type").Equal(render.ContentTypeMsgPack)
+	var thing gorestapi.Thing
+	assert.Nil(t, codec.Decode(bytes.NewReader([]byte(resp.Body().Raw())), &thing))
+	assert.Equal(t, i.ID, thing.ID)
+	assert.Equal(t, i.Name, thing.Name)
+
+	// Unsupported content types
+	e.POST("/api/things").WithHeader("Content-Type", "application/xml").WithBytes([]byte("<thing/>")).Expect().Status(http.StatusUnsupportedMediaType)
+	e.GET("/api/things/id").WithHeader("Accept", "application/xml").Expect().Status(http.StatusNotAcceptable)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
 func TestThingsFind(t *testing.T) {
 
 	// Create test server
@@ -112,6 +158,49 @@ func TestThingGetByID(t *testing.T) {
 
 }
 
+func TestThingGetByIDConditional(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	// Create Item
+	updated := time.Date(2023, 1, 2, 3, 4, 5, 600, time.UTC)
+	i := &gorestapi.Thing{
+		ID:      "id",
+		Updated: updated,
+		Name:    "name",
+	}
+
+	// Mock call to item store
+	grs.On("ThingGetByID", mock.Anything, "1234").Times(5).Return(i, nil)
+
+	// The first request gets the validators
+	e := httpexpect.New(t, server.URL)
+	resp := e.GET("/api/things/1234").Expect().Status(http.StatusOK)
+	resp.Header("Last-Modified").Equal("Mon, 02 Jan 2023 03:04:05 GMT")
+	etag := resp.Header("ETag").NotEmpty().Raw()
+
+	// Unchanged
+	e.GET("/api/things/1234").WithHeader("If-None-Match", etag).Expect().Status(http.StatusNotModified).Body().Empty()
+	e.GET("/api/things/1234").WithHeader("If-Modified-Since", "Mon, 02 Jan 2023 03:04:05 GMT").Expect().Status(http.StatusNotModified)
+
+	// Changed or a different representation
+	e.GET("/api/things/1234").WithHeader("If-Modified-Since", "Mon, 02 Jan 2023 03:04:04 GMT").Expect().Status(http.StatusOK)
+	e.GET("/api/things/1234").WithHeader("If-None-Match", etag).WithHeader("Accept", render.ContentTypeCBOR).Expect().Status(http.StatusOK).
+		Header("ETag").NotEqual(etag)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
 func TestThingDeleteByID(t *testing.T) {
 
 	// Create test server
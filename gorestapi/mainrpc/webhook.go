@@ -0,0 +1,308 @@
+package mainrpc
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// WebhookSave saves a webhook
+//
+// @ID WebhookSave
+// @Tags Webhooks
+// @Summary Save webhook
+// @Description Save a webhook. The secret is only returned when saving.
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
+// @Param webhook body gorestapi.WebhookExample true "Webhook"
+// @Success 200 {object} gorestapi.Webhook
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /webhooks [post]
+func (s *Server) WebhookSave() http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		var webhook = new(gorestapi.Webhook)
+		if err := render.Decode(r, webhook); err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+
+		if err := webhook.Validate(); err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+
+		supplied := webhook.Secret != ""
+		err := s.webhookStore.WebhookSave(ctx, webhook)
+		if err != nil {
+			if serr, ok := err.(*store.Error); ok {
+				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpSave))
+			} else {
+				requestID := middleware.GetReqID(ctx)
+				render.ErrInternalWithID(w, requestID, nil)
+				s.logger.Error("WebhookSave error", "error", err, "request_id", requestID)
+			}
+			return
+		}
+
+		// Only return a secret generated for a new webhook or supplied with the request, never the stored secret
+		if !supplied && !webhook.Created.Equal(webhook.Updated) {
+			webhook.Secret = ""
+		}
+		render.Encode(w, http.StatusOK, represent(w, webhookHypermedia, webhook))
+	}
+
+}
+
+// WebhookGetByID gets a webhook
+//
+// @ID WebhookGetByID
+// @Tags Webhooks
+// @Summary Get webhook
+// @Description Get a webhook
+// @Param id path string true "ID"
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
+// @Success 200 {object} gorestapi.Webhook
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 404 {object} render.ErrResponse "Not Found"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /webhooks/{id} [get]
+func (s *Server) WebhookGetByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		id := chi.URLParam(r, "id")
+
+		webhook, err := s.webhookStore.WebhookGetByID(ctx, id)
+		if err != nil {
+			if err == store.ErrNotFound {
+				render.ErrResourceNotFound(w, "webhook")
+			} else if serr, ok := err.(*store.Error); ok {
+				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpGet))
+			} else {
+				requestID := middleware.GetReqID(ctx)
+				render.ErrInternalWithID(w, requestID, nil)
+				s.logger.Error("WebhookGetByID error", "error", err, "request_id", requestID)
+			}
+			return
+		}
+
+		webhook.Secret = ""
+		render.Encode(w, http.StatusOK, represent(w, webhookHypermedia, webhook))
+	}
+
+}
+
+// WebhookDeleteByID deletes a webhook
+//
+// @ID WebhookDeleteByID
+// @Tags Webhooks
+// @Summary Delete webhook
+// @Description Delete a webhook and its deliveries
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
+// @Param id path string true "ID"
+// @Success 204 "Success"
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 404 {object} render.ErrResponse "Not Found"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /webhooks/{id} [delete]
+func (s *Server) WebhookDeleteByID() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		id := chi.URLParam(r, "id")
+
+		err := s.webhookStore.WebhookDeleteByID(ctx, id)
+		if err != nil {
+			if err == store.ErrNotFound {
+				render.ErrResourceNotFound(w, "webhook")
+			} else if serr, ok := err.(*store.Error); ok {
+				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpDelete))
+			} else {
+				requestID := middleware.GetReqID(ctx)
+				render.ErrInternalWithID(w, requestID, nil)
+				s.logger.Error("WebhookDeleteByID error", "error", err, "request_id", requestID)
+			}
+			return
+		}
+
+		render.NoContent(w)
+
+	}
+
+}
+
+// WebhooksFind finds webhooks
+//
+// @ID WebhooksFind
+// @Tags Webhooks
+// @Summary Find webhooks
+// @Description Find webhooks
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
+// @Param id query string false "id"
+// @Param url query string false "url"
+// @Param offset query int false "offset"
+// @Param limit query int false "limit"
+// @Param sort query string false "query"
+// @Success 200 {object} store.Results{results=[]gorestapi.Webhook}
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /webhooks [get]
+func (s *Server) WebhooksFind() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		qp, err := queryp.ParseRawQuery(r.URL.RawQuery)
+		if err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+
+		webhooks, count, err := s.webhookStore.WebhooksFind(ctx, qp)
+		if err != nil {
+			if serr, ok := err.(*store.Error); ok {
+				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpFind))
+			} else {
+				requestID := middleware.GetReqID(ctx)
+				render.ErrInternalWithID(w, requestID, nil)
+				s.logger.Error("WebhooksFind error", "error", err, "request_id", requestID)
+			}
+			return
+		}
+
+		for _, webhook := range webhooks {
+			webhook.Secret = ""
+		}
+		render.Encode(w, http.StatusOK, representPage(w, r, qp, webhookHypermedia, count, webhooks))
+
+	}
+
+}
+
+// WebhookDeliveriesFind finds deliveries for a webhook
+//
+// @ID WebhookDeliveriesFind
+// @Tags Webhooks
+// @Summary Find webhook deliveries
+// @Description Find the delivery log of a webhook
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
+// @Param id path string true "Webhook ID"
+// @Param event query string false "event"
+// @Param status query string false "status"
+// @Param offset query int false "offset"
+// @Param limit query int false "limit"
+// @Param sort query string false "query"
+// @Success 200 {object} store.Results{results=[]gorestapi.WebhookDelivery}
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /webhooks/{id}/deliveries [get]
+func (s *Server) WebhookDeliveriesFind() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		qp, err := queryp.ParseRawQuery(r.URL.RawQuery)
+		if err != nil {
+			render.ErrInvalidRequest(w, err)
+			return
+		}
+
+		// Limit to this webhook
+		filter := queryp.NewFilter().Append(queryp.FilterLogicAnd, "webhook_delivery.webhook_id", queryp.FilterOpEquals, chi.URLParam(r, "id"))
+		if len(qp.Filter) > 0 {
+			filter.SubFilter(queryp.FilterLogicAnd, &qp.Filter)
+		}
+		qp.Filter = filter.Filter()
+
+		deliveries, count, err := s.webhookStore.WebhookDeliveriesFind(ctx, qp)
+		if err != nil {
+			if serr, ok := err.(*store.Error); ok {
+				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpFind))
+			} else {
+				requestID := middleware.GetReqID(ctx)
+				render.ErrInternalWithID(w, requestID, nil)
+				s.logger.Error("WebhookDeliveriesFind error", "error", err, "request_id", requestID)
+			}
+			return
+		}
+
+		render.Encode(w, http.StatusOK, representPage(w, r, qp, webhookDeliveryHypermedia, count, deliveries))
+
+	}
+
+}
+
+// WebhookDeliveryRedeliver queues a delivery to be sent again
+//
+// @ID WebhookDeliveryRedeliver
+// @Tags Webhooks
+// @Summary Redeliver webhook delivery
+// @Description Queue a delivery to be sent again immediately with a fresh set of attempts
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 200 {object} gorestapi.WebhookDelivery
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Failure 404 {object} render.ErrResponse "Not Found"
+// @Failure 500 {object} render.ErrResponse "Internal Error"
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (s *Server) WebhookDeliveryRedeliver() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		deliveryID, err := strconv.ParseInt(chi.URLParam(r, "delivery_id"), 10, 64)
+		if err != nil {
+			render.ErrInvalidRequest(w, errors.New("invalid delivery_id"))
+			return
+		}
+
+		delivery, err := s.webhookStore.WebhookDeliveryGetByID(ctx, deliveryID)
+		if err == nil && delivery.WebhookID != chi.URLParam(r, "id") {
+			err = store.ErrNotFound
+		}
+		if err == nil {
+			delivery.Status = gorestapi.WebhookDeliveryStatusPending
+			delivery.Attempts = 0
+			delivery.NextAttempt = time.Now()
+			err = s.webhookStore.WebhookDeliverySave(ctx, delivery)
+		}
+		if err != nil {
+			if err == store.ErrNotFound {
+				render.ErrResourceNotFound(w, "webhook delivery")
+			} else if serr, ok := err.(*store.Error); ok {
+				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpSave))
+			} else {
+				requestID := middleware.GetReqID(ctx)
+				render.ErrInternalWithID(w, requestID, nil)
+				s.logger.Error("WebhookDeliveryRedeliver error", "error", err, "request_id", requestID)
+			}
+			return
+		}
+
+		render.Encode(w, http.StatusOK, represent(w, webhookDeliveryHypermedia, delivery))
+
+	}
+
+}
@@ -0,0 +1,165 @@
+package mainrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/queryp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+func TestWebhookPost(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	ws := new(mocks.WebhookStore)
+	err := Setup(r, grs, WithWebhooks(ws))
+	assert.Nil(t, err)
+
+	// Create Item
+	i := &gorestapi.Webhook{
+		URL:    "https://example.com/hook",
+		Events: gorestapi.WebhookEvents{"thing.*"},
+	}
+
+	// Mock call to item store generating the secret
+	ws.On("WebhookSave", mock.Anything, i).Once().Run(func(args mock.Arguments) {
+		args.Get(1).(*gorestapi.Webhook).Secret = "secret"
+	}).Return(nil)
+
+	// Make request and validate we get back the secret
+	e := httpexpect.New(t, server.URL)
+	e.POST("/api/webhooks").WithJSON(i).Expect().Status(http.StatusOK).JSON().Object().ValueEqual("secret", "secret")
+
+	// Updating a webhook without a secret keeps the stored secret and does not return it
+	u := &gorestapi.Webhook{ID: "w1", URL: "https://example.com/hook", Events: gorestapi.WebhookEvents{"*"}}
+	ws.On("WebhookSave", mock.Anything, u).Once().Run(func(args mock.Arguments) {
+		webhook := args.Get(1).(*gorestapi.Webhook)
+		webhook.Created = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		webhook.Updated = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		webhook.Secret = "stored"
+	}).Return(nil)
+	e.POST("/api/webhooks").WithJSON(u).Expect().Status(http.StatusOK).JSON().Object().NotContainsKey("secret")
+
+	// Updating a webhook with a secret returns it
+	s := &gorestapi.Webhook{ID: "w2", URL: "https://example.com/hook", Events: gorestapi.WebhookEvents{"*"}, Secret: "new"}
+	ws.On("WebhookSave", mock.Anything, s).Once().Run(func(args mock.Arguments) {
+		webhook := args.Get(1).(*gorestapi.Webhook)
+		webhook.Created = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		webhook.Updated = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	}).Return(nil)
+	e.POST("/api/webhooks").WithJSON(s).Expect().Status(http.StatusOK).JSON().Object().ValueEqual("secret", "new")
+
+	// Invalid webhooks are rejected
+	e.POST("/api/webhooks").WithJSON(&gorestapi.Webhook{URL: "nope"}).Expect().Status(http.StatusBadRequest)
+
+	// Check remaining expectations
+	ws.AssertExpectations(t)
+
+}
+
+func TestWebhookGetByID(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	ws := new(mocks.WebhookStore)
+	err := Setup(r, grs, WithWebhooks(ws))
+	assert.Nil(t, err)
+
+	// Mock call to item store
+	ws.On("WebhookGetByID", mock.Anything, "1234").Once().Return(&gorestapi.Webhook{ID: "1234", URL: "https://example.com/hook", Secret: "secret"}, nil)
+
+	// Make request and validate the secret is not returned
+	e := httpexpect.New(t, server.URL)
+	e.GET("/api/webhooks/1234").Expect().Status(http.StatusOK).JSON().Object().NotContainsKey("secret")
+
+	// Check remaining expectations
+	ws.AssertExpectations(t)
+
+}
+
+func TestWebhookDeliveriesFind(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	ws := new(mocks.WebhookStore)
+	err := Setup(r, grs, WithWebhooks(ws))
+	assert.Nil(t, err)
+
+	// Return Item
+	i := []*gorestapi.WebhookDelivery{
+		{ID: 1, WebhookID: "1234", Status: gorestapi.WebhookDeliveryStatusDead},
+	}
+	var count int64 = 1
+
+	// Mock call to item store and validate the query is limited to the webhook
+	ws.On("WebhookDeliveriesFind", mock.Anything, mock.MatchedBy(func(qp *queryp.QueryParameters) bool {
+		return len(qp.Filter) == 2 && qp.Filter[0].Field == "webhook_delivery.webhook_id" && qp.Filter[0].Value == "1234"
+	})).Once().Return(i, &count, nil)
+
+	// Make request and validate we get back proper response
+	e := httpexpect.New(t, server.URL)
+	e.GET("/api/webhooks/1234/deliveries").WithQuery("status", "dead").Expect().Status(http.StatusOK).JSON().Object().Equal(&store.Results{Count: &count, Results: i})
+
+	// Check remaining expectations
+	ws.AssertExpectations(t)
+
+}
+
+func TestWebhookDeliveryRedeliver(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	ws := new(mocks.WebhookStore)
+	err := Setup(r, grs, WithWebhooks(ws))
+	assert.Nil(t, err)
+
+	// Mock calls to item store
+	ws.On("WebhookDeliveryGetByID", mock.Anything, int64(5)).Return(func(ctx context.Context, id int64) (*gorestapi.WebhookDelivery, error) {
+		return &gorestapi.WebhookDelivery{ID: id, WebhookID: "1234", Status: gorestapi.WebhookDeliveryStatusDead, Attempts: 10}, nil
+	})
+	ws.On("WebhookDeliverySave", mock.Anything, mock.MatchedBy(func(d *gorestapi.WebhookDelivery) bool {
+		return d.ID == 5 && d.Status == gorestapi.WebhookDeliveryStatusPending && d.Attempts == 0
+	})).Once().Return(nil)
+
+	// Make request and validate we get back proper response
+	e := httpexpect.New(t, server.URL)
+	e.POST("/api/webhooks/1234/deliveries/5/redeliver").Expect().Status(http.StatusOK).JSON().Object().ValueEqual("status", gorestapi.WebhookDeliveryStatusPending)
+
+	// Deliveries of other webhooks are not found
+	e.POST("/api/webhooks/other/deliveries/5/redeliver").Expect().Status(http.StatusNotFound)
+
+	// Check remaining expectations
+	ws.AssertExpectations(t)
+
+}
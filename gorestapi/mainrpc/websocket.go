@@ -0,0 +1,302 @@
+package mainrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+const (
+	// wsWriteTimeout is how long a write to a WebSocket may block before the client is dropped
+	wsWriteTimeout = 10 * time.Second
+	// wsMaxMessageSize is the largest message accepted from a client
+	wsMaxMessageSize = 64 * 1024
+	// wsMaxSubscriptions is how many subscriptions a client may have at once
+	wsMaxSubscriptions = 100
+)
+
+// WebSocket message types
+const (
+	WSMessageSubscribe    = "subscribe"
+	WSMessageUnsubscribe  = "unsubscribe"
+	WSMessageSubscribed   = "subscribed"
+	WSMessageUnsubscribed = "unsubscribed"
+	WSMessageEvent        = "event"
+	WSMessageRemoved      = "removed"
+	WSMessageError        = "error"
+)
+
+// WSMessage is a message sent to or received from the WebSocket endpoint
+type WSMessage struct {
+	// Type of message
+	Type string `json:"type"`
+	// ID is the client chosen ID of the subscription
+	ID string `json:"id,omitempty"`
+	// Resource to subscribe to (thing or widget)
+	Resource string `json:"resource,omitempty"`
+	// IDs limits the subscription to these resource IDs
+	IDs []string `json:"ids,omitempty"`
+	// Query limits the subscription to records matching a query in the same format as the find endpoints
+	Query string `json:"query,omitempty"`
+	// Results are the IDs of the records the client already has for a query, ie. from a find, so it is sent removed
+	// messages for them
+	Results []string `json:"results,omitempty"`
+	// Subscriptions are the IDs of the subscriptions an event matched or, for removed messages, the query
+	// subscriptions the record of the event no longer matches
+	Subscriptions []string `json:"subscriptions,omitempty"`
+	// Event is the change event
+	Event *gorestapi.Event `json:"event,omitempty"`
+	// Error is the reason a request failed
+	Error string `json:"error,omitempty"`
+}
+
+// wsSubscription is a subscription on a WebSocket connection
+type wsSubscription struct {
+	id     string
+	filter EventFilter
+	query  wsFilter
+	// results are the IDs of the records that matched the query as far as the client knows
+	results map[string]struct{}
+}
+
+// WebSocket streams change events for subscriptions over a WebSocket
+//
+// @ID WebSocket
+// @Tags Events
+// @Summary Subscribe to events
+// @Description Upgrade to a WebSocket and subscribe to create, update and delete events by sending
+// @Description {"type": "subscribe", "id": "sub1", "resource": "thing", "ids": ["..."], "query": "name=~foo%"}.
+// @Description Matching events are sent as {"type": "event", "subscriptions": ["sub1"], "event": {...}}. Records that
+// @Description were sent or listed in "results" and no longer match a query are sent as {"type": "removed", ...}.
+// @Success 101 {object} WSMessage
+// @Failure 400 {object} render.ErrResponse "Invalid Argument"
+// @Router /ws [get]
+func (s *Server) WebSocket() http.HandlerFunc {
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: s.wsCheckOrigin,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := r.Context()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader has already responded with an error
+			return
+		}
+		defer conn.Close()
+
+		// Subscribe to everything and match against the client subscriptions
+		sub := s.eventBroker.Subscribe(EventFilter{}, eventsBufferSize)
+		defer sub.Close()
+
+		// Read requests until the connection fails. The client must answer pings within the keepalive.
+		requests := make(chan *WSMessage)
+		done := make(chan struct{})
+		conn.SetReadLimit(wsMaxMessageSize)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * s.eventKeepalive))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * s.eventKeepalive))
+		})
+		go func() {
+			defer close(done)
+			for {
+				_, b, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				var request = new(WSMessage)
+				if err := json.Unmarshal(b, request); err != nil {
+					request = &WSMessage{Type: WSMessageError, Error: fmt.Sprintf("invalid message: %v", err)}
+				}
+				select {
+				case requests <- request:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		write := func(message *WSMessage) error {
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			return conn.WriteJSON(message)
+		}
+
+		subscriptions := make(map[string]*wsSubscription)
+
+		keepalive := time.NewTicker(s.eventKeepalive)
+		defer keepalive.Stop()
+
+		for {
+			select {
+			case request := <-requests:
+				if err := write(s.wsHandleRequest(request, subscriptions)); err != nil {
+					return
+				}
+
+			case event, ok := <-sub.C:
+				if !ok && s.eventBroker.Closed() {
+					// Shutting down, the client should reconnect to another server
+					_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "shutting down"), time.Now().Add(wsWriteTimeout))
+					return
+				} else if !ok {
+					// Dropped for falling behind, the client should reconnect and refresh
+					_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"), time.Now().Add(wsWriteTimeout))
+					return
+				}
+				matched, removed := wsMatch(subscriptions, event)
+				if len(matched) > 0 {
+					if err := write(&WSMessage{Type: WSMessageEvent, Subscriptions: matched, Event: event}); err != nil {
+						return
+					}
+				}
+				if len(removed) > 0 {
+					if err := write(&WSMessage{Type: WSMessageRemoved, Subscriptions: removed, Event: event}); err != nil {
+						return
+					}
+				}
+
+			case <-keepalive.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+					return
+				}
+
+			case <-done:
+				return
+
+			case <-ctx.Done():
+				return
+			}
+		}
+
+	}
+
+}
+
+// wsHandleRequest handles a request from the client and returns the response
+func (s *Server) wsHandleRequest(request *WSMessage, subscriptions map[string]*wsSubscription) *WSMessage {
+
+	fail := func(format string, args ...any) *WSMessage {
+		return &WSMessage{Type: WSMessageError, ID: request.ID, Error: fmt.Sprintf(format, args...)}
+	}
+
+	switch request.Type {
+	case WSMessageError:
+		return request
+
+	case WSMessageSubscribe:
+		if request.ID == "" {
+			return fail("id required")
+		}
+		if _, ok := subscriptions[request.ID]; !ok && len(subscriptions) >= wsMaxSubscriptions {
+			return fail("too many subscriptions")
+		}
+		switch request.Resource {
+		case gorestapi.EventResourceThing, gorestapi.EventResourceWidget:
+		default:
+			return fail("invalid resource: %s", request.Resource)
+		}
+		subscription := &wsSubscription{
+			id: request.ID,
+			filter: EventFilter{
+				Resources:   []string{request.Resource},
+				ResourceIDs: request.IDs,
+			},
+		}
+		if request.Query != "" {
+			qp, err := queryp.ParseRawQuery(request.Query)
+			if err != nil {
+				return fail("invalid query: %v", err)
+			}
+			if subscription.query, err = newWSFilter(request.Resource, qp.Filter); err != nil {
+				return fail("invalid query: %v", err)
+			}
+			subscription.results = make(map[string]struct{}, len(request.Results))
+			for _, id := range request.Results {
+				subscription.results[id] = struct{}{}
+			}
+		} else if len(request.Results) > 0 {
+			return fail("results need a query")
+		}
+		subscriptions[request.ID] = subscription
+		return &WSMessage{Type: WSMessageSubscribed, ID: request.ID}
+
+	case WSMessageUnsubscribe:
+		if _, ok := subscriptions[request.ID]; !ok {
+			return fail("subscription not found")
+		}
+		delete(subscriptions, request.ID)
+		return &WSMessage{Type: WSMessageUnsubscribed, ID: request.ID}
+
+	}
+
+	return fail("invalid type: %s", request.Type)
+
+}
+
+// wsMatch returns the IDs of the subscriptions the event matches and of the query subscriptions a record in the
+// results of no longer matches, so clients can drop it. Queries are matched against the data of the event and the
+// results of query subscriptions are updated. Deleted records have no data so deletes are sent to every query
+// subscription for the resource.
+func wsMatch(subscriptions map[string]*wsSubscription, event *gorestapi.Event) (matched []string, removed []string) {
+
+	for _, subscription := range subscriptions {
+		if !subscription.filter.Match(event) {
+			continue
+		}
+		if subscription.query != nil {
+			switch {
+			case event.Type == gorestapi.EventTypeDeleted:
+				delete(subscription.results, event.ResourceID)
+			case subscription.query.Match(event.Data):
+				subscription.results[event.ResourceID] = struct{}{}
+			default:
+				// Only records the client has are removed, others were never in the results
+				if _, ok := subscription.results[event.ResourceID]; ok {
+					delete(subscription.results, event.ResourceID)
+					removed = append(removed, subscription.id)
+				}
+				continue
+			}
+		}
+		matched = append(matched, subscription.id)
+	}
+	sort.Strings(matched)
+	sort.Strings(removed)
+	return matched, removed
+
+}
+
+// wsCheckOrigin allows same origin requests and requests from the allowed origins
+func (s *Server) wsCheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if u.Host == r.Host {
+		return true
+	}
+	if s.wsAllowedOrigins == nil {
+		return false
+	}
+	for _, allowed := range s.wsAllowedOrigins() {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package mainrpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+// readWSMessage reads the next message from a WebSocket
+func readWSMessage(t *testing.T, conn *websocket.Conn) *WSMessage {
+	var message = new(WSMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	assert.Nil(t, conn.ReadJSON(message))
+	return message
+}
+
+func TestWebSocket(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	es := new(mocks.EventStore)
+	broker := NewEventBroker()
+	err := Setup(r, grs, WithEvents(es, broker, time.Hour), WithWebSocket(nil))
+	assert.Nil(t, err)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws", nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	// Subscribe to a single thing
+	assert.Nil(t, conn.WriteJSON(&WSMessage{Type: WSMessageSubscribe, ID: "s1", Resource: gorestapi.EventResourceThing, IDs: []string{"t1"}}))
+	assert.Equal(t, &WSMessage{Type: WSMessageSubscribed, ID: "s1"}, readWSMessage(t, conn))
+
+	// Publish a filtered event and a matching event
+	broker.Publish(&gorestapi.Event{ID: 1, Type: gorestapi.EventTypeUpdated, Resource: gorestapi.EventResourceThing, ResourceID: "t2"})
+	thingEvent := &gorestapi.Event{ID: 2, Type: gorestapi.EventTypeUpdated, Resource: gorestapi.EventResourceThing, ResourceID: "t1"}
+	broker.Publish(thingEvent)
+
+	// Validate we only get the matching event
+	message := readWSMessage(t, conn)
+	assert.Equal(t, WSMessageEvent, message.Type)
+	assert.Equal(t, []string{"s1"}, message.Subscriptions)
+	assert.Equal(t, thingEvent.ID, message.Event.ID)
+
+	// Unsubscribe and validate errors
+	assert.Nil(t, conn.WriteJSON(&WSMessage{Type: WSMessageUnsubscribe, ID: "s1"}))
+	assert.Equal(t, &WSMessage{Type: WSMessageUnsubscribed, ID: "s1"}, readWSMessage(t, conn))
+	assert.Nil(t, conn.WriteJSON(&WSMessage{Type: WSMessageUnsubscribe, ID: "s1"}))
+	assert.Equal(t, WSMessageError, readWSMessage(t, conn).Type)
+	assert.Nil(t, conn.WriteJSON(&WSMessage{Type: WSMessageSubscribe, ID: "s2", Resource: "nope"}))
+	assert.Equal(t, WSMessageError, readWSMessage(t, conn).Type)
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+	assert.Equal(t, WSMessageError, readWSMessage(t, conn).Type)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
+func TestWebSocketQuery(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	es := new(mocks.EventStore)
+	broker := NewEventBroker()
+	err := Setup(r, grs, WithEvents(es, broker, time.Hour), WithWebSocket(nil))
+	assert.Nil(t, err)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws", nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	// Subscribe to widgets matching a query
+	assert.Nil(t, conn.WriteJSON(&WSMessage{Type: WSMessageSubscribe, ID: "q1", Resource: gorestapi.EventResourceWidget, Query: "name=red"}))
+	assert.Equal(t, &WSMessage{Type: WSMessageSubscribed, ID: "q1"}, readWSMessage(t, conn))
+
+	// The first widget does not match the query, the second does. Queries are matched against the event data.
+	broker.Publish(&gorestapi.Event{ID: 1, Type: gorestapi.EventTypeCreated, Resource: gorestapi.EventResourceWidget, ResourceID: "w1", Data: []byte(`{"id":"w1","name":"blue"}`)})
+	broker.Publish(&gorestapi.Event{ID: 2, Type: gorestapi.EventTypeCreated, Resource: gorestapi.EventResourceWidget, ResourceID: "w2", Data: []byte(`{"id":"w2","name":"red"}`)})
+
+	message := readWSMessage(t, conn)
+	assert.Equal(t, WSMessageEvent, message.Type)
+	assert.Equal(t, []string{"q1"}, message.Subscriptions)
+	assert.Equal(t, "w2", message.Event.ResourceID)
+
+	// An update that no longer matches removes the record from the results, records that were never in the results
+	// aren't sent at all
+	broker.Publish(&gorestapi.Event{ID: 3, Type: gorestapi.EventTypeUpdated, Resource: gorestapi.EventResourceWidget, ResourceID: "w1", Data: []byte(`{"id":"w1","name":"green"}`)})
+	broker.Publish(&gorestapi.Event{ID: 4, Type: gorestapi.EventTypeUpdated, Resource: gorestapi.EventResourceWidget, ResourceID: "w2", Data: []byte(`{"id":"w2","name":"green"}`)})
+	message = readWSMessage(t, conn)
+	assert.Equal(t, WSMessageRemoved, message.Type)
+	assert.Equal(t, []string{"q1"}, message.Subscriptions)
+	assert.Equal(t, "w2", message.Event.ResourceID)
+
+	// Deletes can't be checked against the query so they are always sent
+	broker.Publish(&gorestapi.Event{ID: 5, Type: gorestapi.EventTypeDeleted, Resource: gorestapi.EventResourceWidget, ResourceID: "w3"})
+	message = readWSMessage(t, conn)
+	assert.Equal(t, WSMessageEvent, message.Type)
+	assert.Equal(t, "w3", message.Event.ResourceID)
+
+	// Records the client already has can be listed in the results
+	assert.Nil(t, conn.WriteJSON(&WSMessage{Type: WSMessageSubscribe, ID: "q3", Resource: gorestapi.EventResourceThing, Query: "name=red", Results: []string{"t1"}}))
+	assert.Equal(t, &WSMessage{Type: WSMessageSubscribed, ID: "q3"}, readWSMessage(t, conn))
+	broker.Publish(&gorestapi.Event{ID: 6, Type: gorestapi.EventTypeUpdated, Resource: gorestapi.EventResourceThing, ResourceID: "t1", Data: []byte(`{"id":"t1","name":"blue"}`)})
+	message = readWSMessage(t, conn)
+	assert.Equal(t, WSMessageRemoved, message.Type)
+	assert.Equal(t, []string{"q3"}, message.Subscriptions)
+	assert.Equal(t, "t1", message.Event.ResourceID)
+	assert.Nil(t, conn.WriteJSON(&WSMessage{Type: WSMessageSubscribe, ID: "q4", Resource: gorestapi.EventResourceThing, Results: []string{"t1"}}))
+	assert.Equal(t, &WSMessage{Type: WSMessageError, ID: "q4", Error: "results need a query"}, readWSMessage(t, conn))
+
+	// Fields that aren't in the event data can't be queried
+	assert.Nil(t, conn.WriteJSON(&WSMessage{Type: WSMessageSubscribe, ID: "q2", Resource: gorestapi.EventResourceWidget, Query: "thing.name=red"}))
+	assert.Equal(t, &WSMessage{Type: WSMessageError, ID: "q2", Error: "invalid query: could not find field: thing.name"}, readWSMessage(t, conn))
+
+	// The store is never queried
+	grs.AssertExpectations(t)
+
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	es := new(mocks.EventStore)
+	allowedOrigins := []string{"https://allowed.example.com"}
+	err := Setup(r, grs, WithEvents(es, NewEventBroker(), time.Hour), WithWebSocket(func() []string { return allowedOrigins }))
+	assert.Nil(t, err)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws"
+
+	// Cross origin requests are rejected unless allowed
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": []string{"https://other.example.com"}})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": []string{"https://allowed.example.com"}})
+	assert.Nil(t, err)
+	conn.Close()
+
+	// The allowed origins are read for every connection so they can change while running
+	allowedOrigins = []string{"https://other.example.com"}
+	conn, _, err = websocket.DefaultDialer.Dial(url, http.Header{"Origin": []string{"https://other.example.com"}})
+	assert.Nil(t, err)
+	conn.Close()
+	_, resp, err = websocket.DefaultDialer.Dial(url, http.Header{"Origin": []string{"https://allowed.example.com"}})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+}
@@ -4,12 +4,9 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/snowzach/queryp"
 
-	"github.com/snowzach/golib/httpserver/render"
-	"github.com/snowzach/golib/store"
 	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/gorestapi/render"
 )
 
 // WidgetSave saves a widget
@@ -18,8 +15,8 @@ import (
 // @Tags Widgets
 // @Summary Save widget
 // @Description Save a widget
-// @Accept   json
-// @Produce  json
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
 // @Param widget body gorestapi.WidgetExample true "Widget"
 // @Success 200 {object} gorestapi.Widget
 // @Failure 400 {object} render.ErrResponse "Invalid Argument"
@@ -32,24 +29,17 @@ func (s *Server) WidgetSave() http.HandlerFunc {
 		ctx := r.Context()
 
 		var widget = new(gorestapi.Widget)
-		if err := render.DecodeJSON(r.Body, widget); err != nil {
+		if err := render.Decode(r, widget); err != nil {
 			render.ErrInvalidRequest(w, err)
 			return
 		}
 
-		err := s.grStore.WidgetSave(ctx, widget)
-		if err != nil {
-			if serr, ok := err.(*store.Error); ok {
-				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpSave))
-			} else {
-				requestID := middleware.GetReqID(ctx)
-				render.ErrInternalWithID(w, requestID, nil)
-				s.logger.Error("WidgetSave error", "error", err, "request_id", requestID)
-			}
+		if err := s.widgetSave(ctx, widget); err != nil {
+			renderOpErr(w, err)
 			return
 		}
 
-		render.JSON(w, http.StatusOK, widget)
+		render.Encode(w, http.StatusOK, represent(w, widgetHypermedia, widget))
 	}
 
 }
@@ -60,10 +50,13 @@ func (s *Server) WidgetSave() http.HandlerFunc {
 // @Tags Widgets
 // @Summary Get widget
 // @Description Get a widget
-// @Accept   json
-// @Produce  json
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
 // @Param id path string true "ID"
+// @Param If-None-Match header string false "ETag of the cached widget"
+// @Param If-Modified-Since header string false "Last-Modified of the cached widget"
 // @Success 200 {object} gorestapi.Widget
+// @Success 304 "Not Modified"
 // @Failure 400 {object} render.ErrResponse "Invalid Argument"
 // @Failure 404 {object} render.ErrResponse "Not Found"
 // @Failure 500 {object} render.ErrResponse "Internal Error"
@@ -75,21 +68,13 @@ func (s *Server) WidgetGetByID() http.HandlerFunc {
 
 		id := chi.URLParam(r, "id")
 
-		widget, err := s.grStore.WidgetGetByID(ctx, id)
+		widget, err := s.widgetGetByID(ctx, id)
 		if err != nil {
-			if err == store.ErrNotFound {
-				render.ErrResourceNotFound(w, "widget")
-			} else if serr, ok := err.(*store.Error); ok {
-				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpGet))
-			} else {
-				requestID := middleware.GetReqID(ctx)
-				render.ErrInternalWithID(w, requestID, nil)
-				s.logger.Error("WidgetGetByID error", "error", err, "request_id", requestID)
-			}
+			renderOpErr(w, err)
 			return
 		}
 
-		render.JSON(w, http.StatusOK, widget)
+		render.EncodeConditional(w, r, represent(w, widgetHypermedia, widget), widgetUpdated(widget))
 	}
 }
 
@@ -99,8 +84,8 @@ func (s *Server) WidgetGetByID() http.HandlerFunc {
 // @Tags Widgets
 // @Summary Delete widget
 // @Description Delete a widget
-// @Accept   json
-// @Produce  json
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json
 // @Param id path string true "ID"
 // @Success 204 "Success"
 // @Failure 400 {object} render.ErrResponse "Invalid Argument"
@@ -114,17 +99,8 @@ func (s *Server) WidgetDeleteByID() http.HandlerFunc {
 
 		id := chi.URLParam(r, "id")
 
-		err := s.grStore.WidgetDeleteByID(ctx, id)
-		if err != nil {
-			if err == store.ErrNotFound {
-				render.ErrResourceNotFound(w, "widget")
-			} else if serr, ok := err.(*store.Error); ok {
-				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpDelete))
-			} else {
-				requestID := middleware.GetReqID(ctx)
-				render.ErrInternalWithID(w, requestID, nil)
-				s.logger.Error("WidgetDeleteByID error", "error", err, "request_id", requestID)
-			}
+		if err := s.widgetDeleteByID(ctx, id); err != nil {
+			renderOpErr(w, err)
 			return
 		}
 
@@ -138,15 +114,18 @@ func (s *Server) WidgetDeleteByID() http.HandlerFunc {
 // @Tags Widgets
 // @Summary Find widgets
 // @Description Find widgets
-// @Accept   json
-// @Produce  json
+// @Accept   json,application/msgpack,application/cbor,application/yaml
+// @Produce  json,application/msgpack,application/cbor,application/yaml,application/hal+json,application/vnd.api+json,text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param id query string false "id"
 // @Param name query string false "name"
 // @Param description query string false "description"
 // @Param offset query int false "offset"
 // @Param limit query int false "limit"
 // @Param sort query string false "query"
-// @Success 200 {array} gorestapi.Widget
+// @Param format query string false "Export all matching records as csv, ndjson or xlsx"
+// @Param If-None-Match header string false "ETag of the cached results"
+// @Success 200 {object} store.Results{results=[]gorestapi.Widget}
+// @Success 304 "Not Modified"
 // @Failure 400 {object} render.ErrResponse "Invalid Argument"
 // @Failure 500 {object} render.ErrResponse "Internal Error"
 // @Router /widgets [get]
@@ -155,24 +134,36 @@ func (s *Server) WidgetsFind() http.HandlerFunc {
 
 		ctx := r.Context()
 
-		qp, err := queryp.ParseRawQuery(r.URL.RawQuery)
+		// Export all matching records in another format
+		format, err := exportFormat(r)
 		if err != nil {
 			render.ErrInvalidRequest(w, err)
 			return
+		} else if format != "" {
+			qp, err := exportQueryParameters(r)
+			if err != nil {
+				render.ErrInvalidRequest(w, err)
+				return
+			}
+			export(s, w, r, "WidgetsStream", "widgets", format, widgetExportColumns, func(fn func(*gorestapi.Widget) error) error {
+				return s.grStore.WidgetsStream(ctx, qp, fn)
+			})
+			return
 		}
 
-		widgets, count, err := s.grStore.WidgetsFind(ctx, qp)
+		qp, err := findQueryParameters(r.URL.RawQuery)
 		if err != nil {
-			if serr, ok := err.(*store.Error); ok {
-				render.ErrInvalidRequest(w, serr.ErrorForOp(store.ErrorOpFind))
-			} else {
-				requestID := middleware.GetReqID(ctx)
-				render.ErrInternalWithID(w, requestID, nil)
-				s.logger.Error("WidgetsFind error", "error", err, "request_id", requestID)
-			}
+			renderOpErr(w, err)
+			return
+		}
+
+		widgets, count, err := s.widgetsFind(ctx, qp)
+		if err != nil {
+			renderOpErr(w, err)
 			return
 		}
 
-		render.JSON(w, http.StatusOK, store.Results{Count: count, Results: widgets})
+		etag := collectionETag(w, count, widgets, func(widget *gorestapi.Widget) string { return widget.ID }, widgetUpdated)
+		render.EncodeWithETag(w, r, representPage(w, r, qp, widgetHypermedia, count, widgets), etag)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gavv/httpexpect/v2"
 	"github.com/go-chi/chi/v5"
@@ -82,6 +83,46 @@ func TestWidgetsFind(t *testing.T) {
 
 }
 
+func TestWidgetsFindConditional(t *testing.T) {
+
+	// Create test server
+	r := chi.NewRouter()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	// Mock Store and server
+	grs := new(mocks.GRStore)
+	err := Setup(r, grs)
+	assert.Nil(t, err)
+
+	// Return Items
+	updated := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	i := []*gorestapi.Widget{
+		{ID: "id1", Name: "name1", Updated: updated},
+		{ID: "id2", Name: "name2", Updated: updated, Thing: &gorestapi.Thing{ID: "thing1", Updated: updated}},
+	}
+	var count int64 = 2
+
+	// Mock call to item store
+	grs.On("WidgetsFind", mock.Anything, mock.AnythingOfType("*queryp.QueryParameters")).Twice().Return(i, &count, nil)
+
+	e := httpexpect.New(t, server.URL)
+	etag := e.GET("/api/widgets").Expect().Status(http.StatusOK).Header("ETag").NotEmpty().Raw()
+	e.GET("/api/widgets").WithHeader("If-None-Match", etag).Expect().Status(http.StatusNotModified)
+
+	// An update to an embedded thing changes the etag
+	changed := []*gorestapi.Widget{
+		{ID: "id1", Name: "name1", Updated: updated},
+		{ID: "id2", Name: "name2", Updated: updated, Thing: &gorestapi.Thing{ID: "thing1", Updated: updated.Add(time.Second)}},
+	}
+	grs.On("WidgetsFind", mock.Anything, mock.AnythingOfType("*queryp.QueryParameters")).Once().Return(changed, &count, nil)
+	e.GET("/api/widgets").WithHeader("If-None-Match", etag).Expect().Status(http.StatusOK).Header("ETag").NotEqual(etag)
+
+	// Check remaining expectations
+	grs.AssertExpectations(t)
+
+}
+
 func TestWidgetGetByID(t *testing.T) {
 
 	// Create test server
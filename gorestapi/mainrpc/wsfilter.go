@@ -0,0 +1,267 @@
+package mainrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// wsFilterFields are the fields of each resource a subscription query can use, by their JSON name in the event data
+var wsFilterFields = map[string]map[string]queryp.FilterType{
+	gorestapi.EventResourceThing: {
+		"id":          queryp.FilterTypeSimple,
+		"created":     queryp.FilterTypeTime,
+		"updated":     queryp.FilterTypeTime,
+		"name":        queryp.FilterTypeString,
+		"description": queryp.FilterTypeString,
+	},
+	gorestapi.EventResourceWidget: {
+		"id":          queryp.FilterTypeSimple,
+		"created":     queryp.FilterTypeTime,
+		"updated":     queryp.FilterTypeTime,
+		"name":        queryp.FilterTypeString,
+		"description": queryp.FilterTypeString,
+		"thing_id":    queryp.FilterTypeSimple,
+	},
+}
+
+// wsTimeLayouts are the time formats accepted in queries on time fields
+var wsTimeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// wsFilter is a query compiled to match the data of events without going to the database. Like SQL, and binds
+// tighter than or.
+type wsFilter []*wsFilterTerm
+
+type wsFilterTerm struct {
+	logic      queryp.FilterLogic
+	field      string
+	filterType queryp.FilterType
+	op         queryp.FilterOp
+	// values are the strings, times or patterns compared with the field, the term matches if any of them do
+	values    []string
+	times     []time.Time
+	patterns  []*regexp.Regexp
+	subFilter wsFilter
+}
+
+// newWSFilter compiles the filter for the resource
+func newWSFilter(resource string, filter queryp.Filter) (wsFilter, error) {
+
+	var f wsFilter
+	for _, ft := range filter {
+		term := &wsFilterTerm{logic: ft.Logic, op: ft.Op}
+		if len(ft.SubFilter) > 0 {
+			subFilter, err := newWSFilter(resource, ft.SubFilter)
+			if err != nil {
+				return nil, err
+			}
+			term.subFilter = subFilter
+			f = append(f, term)
+			continue
+		}
+
+		term.field = strings.TrimPrefix(ft.Field, resource+".")
+		filterType, ok := wsFilterFields[resource][term.field]
+		if !ok {
+			return nil, fmt.Errorf("could not find field: %s", ft.Field)
+		}
+		term.filterType = filterType
+		term.values = wsFilterValues(ft.Value)
+
+		switch ft.Op {
+		case queryp.FilterOpEquals, queryp.FilterOpNotEquals, queryp.FilterOpLessThan, queryp.FilterOpLessThanEqual,
+			queryp.FilterOpGreaterThan, queryp.FilterOpGreaterThanEqual:
+			if filterType == queryp.FilterTypeTime {
+				for _, value := range term.values {
+					t, err := wsParseTime(value)
+					if err != nil {
+						return nil, fmt.Errorf("invalid time for field %s: %s", ft.Field, value)
+					}
+					term.times = append(term.times, t)
+				}
+			}
+		case queryp.FilterOpLike, queryp.FilterOpNotLike, queryp.FilterOpILike, queryp.FilterOpNotILike,
+			queryp.FilterOpRegexp, queryp.FilterOpNotRegexp, queryp.FilterOpIRegexp, queryp.FilterOpNotIRegexp:
+			if filterType != queryp.FilterTypeString {
+				return nil, fmt.Errorf("invalid op %s for field %s", ft.Op.String(), ft.Field)
+			}
+			for _, value := range term.values {
+				pattern, err := wsPattern(ft.Op, value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid pattern for field %s: %v", ft.Field, err)
+				}
+				term.patterns = append(term.patterns, pattern)
+			}
+		default:
+			return nil, fmt.Errorf("invalid op %s for field %s", ft.Op.String(), ft.Field)
+		}
+		f = append(f, term)
+	}
+	return f, nil
+
+}
+
+// Match returns true if the event data matches the filter
+func (f wsFilter) Match(data json.RawMessage) bool {
+	var record map[string]any
+	if err := json.Unmarshal(data, &record); err != nil {
+		return false
+	}
+	return f.match(record)
+}
+
+func (f wsFilter) match(record map[string]any) bool {
+
+	if len(f) == 0 {
+		return true
+	}
+	// Or separates groups of terms that must all match
+	group := true
+	for i, term := range f {
+		if i > 0 && term.logic == queryp.FilterLogicOr {
+			if group {
+				return true
+			}
+			group = true
+		}
+		if group {
+			group = term.match(record)
+		}
+	}
+	return group
+
+}
+
+func (term *wsFilterTerm) match(record map[string]any) bool {
+
+	if term.subFilter != nil {
+		return term.subFilter.match(record)
+	}
+
+	// Like SQL nothing matches a null
+	value, ok := record[term.field].(string)
+	if !ok {
+		return false
+	}
+
+	switch term.op {
+	case queryp.FilterOpLike, queryp.FilterOpILike, queryp.FilterOpRegexp, queryp.FilterOpIRegexp:
+		for _, pattern := range term.patterns {
+			if pattern.MatchString(value) {
+				return true
+			}
+		}
+		return false
+	case queryp.FilterOpNotLike, queryp.FilterOpNotILike, queryp.FilterOpNotRegexp, queryp.FilterOpNotIRegexp:
+		for _, pattern := range term.patterns {
+			if !pattern.MatchString(value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var compare func(i int) int
+	if term.filterType == queryp.FilterTypeTime {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return false
+		}
+		compare = func(i int) int { return t.Compare(term.times[i]) }
+	} else {
+		compare = func(i int) int { return strings.Compare(value, term.values[i]) }
+	}
+	for i := range term.values {
+		c := compare(i)
+		switch term.op {
+		case queryp.FilterOpEquals:
+			ok = c == 0
+		case queryp.FilterOpNotEquals:
+			ok = c != 0
+		case queryp.FilterOpLessThan:
+			ok = c < 0
+		case queryp.FilterOpLessThanEqual:
+			ok = c <= 0
+		case queryp.FilterOpGreaterThan:
+			ok = c > 0
+		case queryp.FilterOpGreaterThanEqual:
+			ok = c >= 0
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+
+}
+
+// wsFilterValues returns the value of a filter term as strings, a list matches if any value does
+func wsFilterValues(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		values := make([]string, len(v))
+		for i := range v {
+			values[i] = fmt.Sprint(v[i])
+		}
+		return values
+	}
+	return []string{fmt.Sprint(value)}
+}
+
+// wsParseTime parses the time formats the database accepts in queries
+func wsParseTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range wsTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// wsPattern compiles a like or regexp filter value. Like patterns use % and _ as wildcards and \ to escape them.
+func wsPattern(op queryp.FilterOp, value string) (*regexp.Regexp, error) {
+
+	var expr string
+	switch op {
+	case queryp.FilterOpLike, queryp.FilterOpNotLike, queryp.FilterOpILike, queryp.FilterOpNotILike:
+		var sb strings.Builder
+		sb.WriteString(`^`)
+		escaped := false
+		for _, r := range value {
+			switch {
+			case escaped:
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '%':
+				sb.WriteString(`.*`)
+			case r == '_':
+				sb.WriteString(`.`)
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		sb.WriteString(`$`)
+		expr = `(?s)` + sb.String()
+	default:
+		expr = value
+	}
+
+	switch op {
+	case queryp.FilterOpILike, queryp.FilterOpNotILike, queryp.FilterOpIRegexp, queryp.FilterOpNotIRegexp:
+		expr = `(?i)` + expr
+	}
+	return regexp.Compile(expr)
+
+}
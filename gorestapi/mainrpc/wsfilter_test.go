@@ -0,0 +1,71 @@
+package mainrpc
+
+import (
+	"testing"
+
+	"github.com/snowzach/queryp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+func TestWSFilter(t *testing.T) {
+
+	data := []byte(`{"id":"w1","created":"2023-01-02T03:04:05Z","name":"Red Widget","description":"50% off","thing_id":null}`)
+
+	// Queries are URL encoded like the query string of the find endpoints
+	for query, match := range map[string]bool{
+		"":                                  true,
+		"name=Red Widget":                   true,
+		"widget.name=Red Widget":            true,
+		"name!=Red Widget":                  false,
+		"name=~Red%25":                      true,
+		"name=~red%25":                      false,
+		"name=~~red%25":                     true,
+		"name!=~~red%25":                    false,
+		"name=~R_d Widget":                  true,
+		`description=~50\%25%25`:            true,
+		`description=~5\%25%25`:             false,
+		"name:^Red":                         true,
+		"name:~^red":                        true,
+		"name!:Blue":                        true,
+		"name>Q":                            true,
+		"name<=Q":                           false,
+		"created>2023-01-01":                true,
+		"created<2023-01-02T03:04:05Z":      false,
+		"created>=2023-01-02 03:04:05":      true,
+		"thing_id=t1":                       false,
+		"thing_id!=t1":                      false,
+		"name=Blue|name=Red Widget":         true,
+		"name=Blue|id=w1&name=Red Widget":   true,
+		"id=w1&name=Blue|name=Green":        false,
+		"id=w1&(name=Blue|name=Red Widget)": true,
+		"id=w2&(name=Blue|name=Red Widget)": false,
+	} {
+		qp, err := queryp.ParseRawQuery(query)
+		if !assert.Nil(t, err, query) {
+			continue
+		}
+		filter, err := newWSFilter(gorestapi.EventResourceWidget, qp.Filter)
+		if assert.Nil(t, err, query) {
+			assert.Equal(t, match, filter.Match(data), query)
+		}
+	}
+
+	// Invalid queries
+	for query, message := range map[string]string{
+		"thing.name=red": "could not find field: thing.name",
+		"color=red":      "could not find field: color",
+		"id=~w%25":       "invalid op =~ for field id",
+		"created>soon":   "invalid time for field created: soon",
+		"name:a[":        "invalid pattern for field name: error parsing regexp: missing closing ]: `[`",
+	} {
+		qp, err := queryp.ParseRawQuery(query)
+		if !assert.Nil(t, err, query) {
+			continue
+		}
+		_, err = newWSFilter(gorestapi.EventResourceWidget, qp.Filter)
+		assert.EqualError(t, err, message, query)
+	}
+
+}
@@ -0,0 +1,126 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPart struct {
+	Name string `json:"name"`
+}
+
+type testRecord struct {
+	ID      string            `json:"id"`
+	Created time.Time         `json:"created"`
+	Count   *int64            `json:"count,omitempty"`
+	Part    *testPart         `json:"part,omitempty"`
+	Parts   []testPart        `json:"parts"`
+	Labels  map[string]string `json:"labels"`
+	Data    json.RawMessage   `json:"data"`
+	Skipped string            `json:"-"`
+	hidden  string
+}
+
+type testPage[T any] struct {
+	Results []T `json:"results"`
+}
+
+func TestSchema(t *testing.T) {
+
+	doc := New(Info{Title: "test", Version: "1"})
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/testRecord"}, doc.Schema(testRecord{}))
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/testRecordTestPage"}, doc.Schema(testPage[*testRecord]{}))
+	assert.Equal(t, ArrayOf(&Schema{Type: Types{TypeString}}), doc.Schema([]string{}))
+
+	b, err := json.Marshal(doc.Components.Schemas["testRecord"])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"created": {"type": "string", "format": "date-time"},
+			"count": {"type": ["integer", "null"], "format": "int64"},
+			"part": {"$ref": "#/components/schemas/testPart"},
+			"parts": {"type": ["array", "null"], "items": {"$ref": "#/components/schemas/testPart"}},
+			"labels": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+			"data": {}
+		}
+	}`, string(b))
+	assert.Contains(t, doc.Components.Schemas, "testPart")
+
+}
+
+func TestValidate(t *testing.T) {
+
+	doc := New(Info{Title: "test", Version: "1"})
+	s := doc.Schema(testRecord{})
+
+	valid := map[string]any{"id": "one", "count": 1.0, "parts": []any{map[string]any{"name": "part"}}, "labels": map[string]any{"a": "b"}, "data": []any{1.0}}
+	assert.Nil(t, doc.Validate(s, valid))
+	assert.Nil(t, doc.Validate(s, map[string]any{"count": nil, "other": true}))
+
+	assert.EqualError(t, doc.Validate(s, []any{}), "value: expected object, got array")
+	assert.EqualError(t, doc.Validate(s, map[string]any{"count": 1.5}), "value.count: expected integer or null, got number")
+	assert.EqualError(t, doc.Validate(s, map[string]any{"parts": []any{map[string]any{"name": 1.0}}}), "value.parts[0].name: expected string, got number")
+	assert.EqualError(t, doc.Validate(s, map[string]any{"labels": map[string]any{"a": false}}), "value.labels.a: expected string, got boolean")
+
+	assert.EqualError(t, doc.Validate(&Schema{Type: Types{TypeString}, Enum: []any{"a", "b"}}, "c"), "value: must be one of [a b]")
+	assert.EqualError(t, doc.Validate(&Schema{Type: Types{TypeObject}, Required: []string{"id"}}, map[string]any{}), "value.id: is required")
+	anyOf := &Schema{AnyOf: []*Schema{{Type: Types{TypeString}}, ArrayOf(&Schema{Type: Types{TypeString}})}}
+	assert.Nil(t, doc.Validate(anyOf, "a"))
+	assert.Nil(t, doc.Validate(anyOf, []any{"a"}))
+	assert.NotNil(t, doc.Validate(anyOf, 1.0))
+
+}
+
+func TestValidateRequestResponse(t *testing.T) {
+
+	doc := New(Info{Title: "test", Version: "1"})
+	op := &Operation{
+		Parameters: []*Parameter{
+			{Name: "id", In: InPath, Required: true, Schema: &Schema{Type: Types{TypeInteger}}},
+			{Name: "limit", In: InQuery, Schema: &Schema{Type: Types{TypeInteger}}},
+		},
+		RequestBody: &RequestBody{Required: true, Content: map[string]*MediaType{
+			"application/json": {Schema: doc.Schema(testPart{})},
+			"text/csv":         {},
+		}},
+		Responses: map[string]*Response{
+			"200":     {Description: "OK", Content: map[string]*MediaType{"application/json": {Schema: doc.Schema(testPart{})}}},
+			"204":     {Description: "No Content"},
+			"default": {Description: "Error", Content: map[string]*MediaType{"application/json": {Schema: &Schema{Type: Types{TypeObject}}}}},
+		},
+	}
+
+	request := func(query string, contentType string, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/?"+query, bytes.NewBufferString(body))
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+
+	r := request("limit=10", "application/json", `{"name":"one"}`)
+	assert.Nil(t, doc.ValidateRequest(op, r, map[string]string{"id": "1"}))
+	var part testPart
+	assert.Nil(t, json.NewDecoder(r.Body).Decode(&part), "the body can be read again")
+	assert.Equal(t, "one", part.Name)
+
+	assert.EqualError(t, doc.ValidateRequest(op, request("", "application/json", `{}`), nil), "path.id: is required")
+	assert.EqualError(t, doc.ValidateRequest(op, request("", "application/json", `{}`), map[string]string{"id": "x"}), "path.id: expected integer, got string")
+	assert.EqualError(t, doc.ValidateRequest(op, request("limit=ten", "application/json", `{}`), map[string]string{"id": "1"}), "query.limit: expected integer, got string")
+	assert.EqualError(t, doc.ValidateRequest(op, request("", "application/json", `{"name":true}`), map[string]string{"id": "1"}), "body.name: expected string, got boolean")
+	assert.EqualError(t, doc.ValidateRequest(op, request("", "application/json", ``), map[string]string{"id": "1"}), "body: is required")
+	assert.Nil(t, doc.ValidateRequest(op, request("", "text/csv", "name\n"), map[string]string{"id": "1"}))
+
+	assert.Nil(t, doc.ValidateResponse(op, http.StatusOK, "application/json; charset=utf-8", []byte(`{"name":"one"}`)))
+	assert.Nil(t, doc.ValidateResponse(op, http.StatusNoContent, "", nil))
+	assert.Nil(t, doc.ValidateResponse(op, http.StatusNotFound, "application/json", []byte(`{"error":"not found"}`)))
+	assert.EqualError(t, doc.ValidateResponse(op, http.StatusOK, "application/json", []byte(`[]`)), "response: expected object, got array")
+	assert.EqualError(t, doc.ValidateResponse(op, http.StatusOK, "text/html", []byte(`<html>`)), `response: undocumented content type "text/html" for status 200`)
+
+}
@@ -0,0 +1,164 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Schema returns the schema of the JSON encoding of the value. Named structs are added to the components of the
+// document and referenced. A *Schema is returned as is.
+func (d *Document) Schema(v any) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return d.schema(reflect.TypeOf(v))
+}
+
+// ArrayOf is the schema of an array of the items
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: Types{TypeArray}, Items: items}
+}
+
+func (d *Document) schema(t reflect.Type) *Schema {
+
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: Types{TypeString}, Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Implements(jsonMarshalerType):
+		return &Schema{}
+	case t.Implements(textMarshalerType):
+		return &Schema{Type: Types{TypeString}}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := d.schema(t.Elem())
+		if len(s.Type) > 0 {
+			s.Type = append(s.Type, TypeNull)
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: Types{TypeBoolean}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: Types{TypeInteger}, Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: Types{TypeInteger}, Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{TypeNumber}}
+	case reflect.String:
+		return &Schema{Type: Types{TypeString}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: Types{TypeString}, Format: "byte"}
+		}
+		return ArrayOf(d.schema(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: Types{TypeObject}, AdditionalProperties: d.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return d.ref(t)
+	}
+
+	// Interfaces can be anything
+	return &Schema{}
+
+}
+
+// ref adds the named struct to the components and references it
+func (d *Document) ref(t reflect.Type) *Schema {
+
+	name, ok := d.types[t]
+	if !ok {
+		name = schemaName(t)
+		if _, taken := d.Components.Schemas[name]; taken {
+			pkg := t.PkgPath()
+			name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+		}
+		// Register the name before building the schema so recursive types reference it
+		d.types[t] = name
+		d.Components.Schemas[name] = nil
+		d.Components.Schemas[name] = d.structSchema(t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+
+}
+
+// structSchema is the object schema of the exported fields of the struct
+func (d *Document) structSchema(t reflect.Type) *Schema {
+
+	s := &Schema{Type: Types{TypeObject}, Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a name are flattened like encoding/json does
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for name, prop := range d.structSchema(ft).Properties {
+					if _, ok := s.Properties[name]; !ok {
+						s.Properties[name] = prop
+					}
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		prop := d.schema(field.Type)
+
+		// Nil slices and maps are encoded as null unless they are omitted
+		if kind := field.Type.Kind(); (kind == reflect.Slice || kind == reflect.Map) && len(prop.Type) > 0 && !slices.Contains(strings.Split(opts, ","), "omitempty") {
+			prop.Type = append(prop.Type, TypeNull)
+		}
+		s.Properties[name] = prop
+	}
+	return s
+
+}
+
+// schemaName is the name of the type in the components. Generic types are named by their type arguments,
+// ie. results[pkg.Thing] is ThingResults.
+func schemaName(t reflect.Type) string {
+	name, args, generic := strings.Cut(t.Name(), "[")
+	if !generic {
+		return name
+	}
+	var b strings.Builder
+	for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+		b.WriteString(arg[strings.LastIndexAny(arg, "./*")+1:])
+	}
+	return b.String() + strings.ToUpper(name[:1]) + name[1:]
+}
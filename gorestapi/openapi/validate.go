@@ -0,0 +1,266 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/snowzach/gorestapi/gorestapi/render"
+)
+
+// ValidationError is a value that does not match its schema
+type ValidationError struct {
+	// Path of the value, ie. body.things[0].name
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks the value decoded from JSON matches the schema
+func (d *Document) Validate(s *Schema, v any) error {
+	return d.validate(s, v, "value")
+}
+
+func (d *Document) validate(s *Schema, v any, path string) error {
+
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		ref, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		if !ok {
+			return &ValidationError{Path: path, Message: "unknown schema " + s.Ref}
+		}
+		return d.validate(ref, v, path)
+	}
+
+	if len(s.AnyOf) > 0 {
+		var err error
+		for _, sub := range s.AnyOf {
+			if err = d.validate(sub, v, path); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(typ string) bool { return isType(typ, v) }) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), jsonType(v))}
+	}
+
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, v) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be one of %v", s.Enum)}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return &ValidationError{Path: path + "." + name, Message: "is required"}
+			}
+		}
+		for name, value := range v {
+			prop, ok := s.Properties[name]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if err := d.validate(prop, value, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, value := range v {
+			if err := d.validate(s.Items, value, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+
+}
+
+// isType checks the value decoded from JSON is of the JSON type
+func isType(typ string, v any) bool {
+	switch typ {
+	case TypeInteger:
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case TypeNumber:
+		_, ok := v.(float64)
+		return ok
+	}
+	return jsonType(v) == typ
+}
+
+// jsonType is the JSON type of the value decoded from JSON
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case float64:
+		return TypeNumber
+	case string:
+		return TypeString
+	case []any:
+		return TypeArray
+	case map[string]any:
+		return TypeObject
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// ValidateRequest checks the parameters and body of the request match the operation. The path parameters are
+// given by the router. The body is replaced so it can be read again.
+func (d *Document) ValidateRequest(op *Operation, r *http.Request, pathParams map[string]string) error {
+
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		var value string
+		var ok bool
+		switch param.In {
+		case InPath:
+			value, ok = pathParams[param.Name]
+		case InQuery:
+			ok = query.Has(param.Name)
+			value = query.Get(param.Name)
+		case InHeader:
+			value = r.Header.Get(param.Name)
+			ok = value != ""
+		}
+		if !ok {
+			if param.Required {
+				return &ValidationError{Path: param.In + "." + param.Name, Message: "is required"}
+			}
+			continue
+		}
+		if err := d.validate(param.Schema, parseParam(param.Schema, value), param.In+"."+param.Name); err != nil {
+			return err
+		}
+	}
+
+	if op.RequestBody == nil || r.Body == nil || r.Body == http.NoBody {
+		if op.RequestBody != nil && op.RequestBody.Required {
+			return &ValidationError{Path: "body", Message: "is required"}
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = render.ContentTypeJSON
+	}
+	content, ok := op.RequestBody.Content[mediaType]
+	if !ok || content.Schema == nil {
+		// Unsupported content types are rejected by the handler
+		return nil
+	}
+
+	b, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return &ValidationError{Path: "body", Message: err.Error()}
+	}
+	if len(b) == 0 {
+		if op.RequestBody.Required {
+			return &ValidationError{Path: "body", Message: "is required"}
+		}
+		return nil
+	}
+	v, err := decode(mediaType, b)
+	if err != nil {
+		return &ValidationError{Path: "body", Message: err.Error()}
+	}
+	return d.validate(content.Schema, v, "body")
+
+}
+
+// ValidateResponse checks the status, content type and body of a response match the operation
+func (d *Document) ValidateResponse(op *Operation, status int, contentType string, body []byte) error {
+
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if resp, ok = op.Responses["default"]; !ok {
+			return &ValidationError{Path: "response", Message: fmt.Sprintf("undocumented status %d", status)}
+		}
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	content, ok := resp.Content[mediaType]
+	if !ok {
+		return &ValidationError{Path: "response", Message: fmt.Sprintf("undocumented content type %q for status %d", contentType, status)}
+	}
+	if content.Schema == nil {
+		return nil
+	}
+	v, err := decode(mediaType, body)
+	if err != nil {
+		return &ValidationError{Path: "response", Message: err.Error()}
+	}
+	return d.validate(content.Schema, v, "response")
+
+}
+
+// decode decodes the body in the media type to the values decoded from JSON
+func decode(mediaType string, body []byte) (any, error) {
+
+	var v any
+	if mediaType == render.ContentTypeJSON {
+		err := json.Unmarshal(body, &v)
+		return v, err
+	}
+
+	codec := render.CodecFor(mediaType)
+	if codec == nil {
+		return nil, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+	if err := codec.Decode(bytes.NewReader(body), &v); err != nil {
+		return nil, err
+	}
+	// Other codecs decode to other types, ie. int8, so normalize them
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	v = nil
+	err = json.Unmarshal(b, &v)
+	return v, err
+
+}
+
+// parseParam parses the parameter value as the type of the schema. Values that can't be parsed are left as strings
+// to fail validation.
+func parseParam(s *Schema, value string) any {
+	if s == nil || len(s.Type) == 0 {
+		return value
+	}
+	switch s.Type[0] {
+	case TypeInteger, TypeNumber:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case TypeBoolean:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
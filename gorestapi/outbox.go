@@ -0,0 +1,46 @@
+package gorestapi
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Outbox Message Statuses
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+)
+
+// OutboxMessage is an event waiting to be published to the outbox sinks
+type OutboxMessage struct {
+	// ID (Auto-Generated, increasing)
+	ID int64 `json:"id"`
+	// Created Timestamp
+	Created time.Time `json:"created"`
+	// Updated Timestamp
+	Updated time.Time `json:"updated"`
+	// EventID is the event being published
+	EventID int64 `json:"event_id" db:"event_id"`
+	// Event is the name of the event
+	Event string `json:"event"`
+	// Payload is the JSON event
+	Payload json.RawMessage `json:"payload"`
+	// Status is pending or delivered
+	Status string `json:"status"`
+	// Attempts made so far
+	Attempts int `json:"attempts"`
+	// NextAttempt is when the next attempt will be made if pending
+	NextAttempt time.Time `json:"next_attempt" db:"next_attempt"`
+	// LastError is the error from the last attempt
+	LastError string `json:"last_error" db:"last_error"`
+}
+
+// OutboxStore is the persistent store of outbox messages
+type OutboxStore interface {
+	// OutboxClaim returns up to limit pending messages that are due in ID order and hides them
+	// from other callers for the lease duration.
+	OutboxClaim(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error)
+	// OutboxMessageSave updates the delivery state of a message
+	OutboxMessageSave(ctx context.Context, message *OutboxMessage) error
+}
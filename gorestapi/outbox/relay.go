@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/snowzach/golib/log"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// Config configures the relay
+type Config struct {
+	// How often to check for pending messages when idle
+	PollInterval time.Duration `conf:"poll_interval"`
+	// How many messages to claim at a time
+	BatchSize int `conf:"batch_size"`
+	// How long claimed messages are hidden from other relays, longer than it can take to publish a batch
+	Lease time.Duration `conf:"lease"`
+	// Delay before the first retry, doubling on each attempt up to BackoffMax
+	BackoffInitial time.Duration `conf:"backoff_initial"`
+	BackoffMax     time.Duration `conf:"backoff_max"`
+}
+
+// Relay publishes pending outbox messages to sinks
+type Relay struct {
+	logger      *slog.Logger
+	outboxStore gorestapi.OutboxStore
+	sinks       []Sink
+	config      Config
+	now         func() time.Time
+}
+
+// NewRelay creates a new relay publishing to every sink
+func NewRelay(outboxStore gorestapi.OutboxStore, config Config, sinks ...Sink) *Relay {
+	return &Relay{
+		logger:      log.Logger.With("context", "outbox"),
+		outboxStore: outboxStore,
+		sinks:       sinks,
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// Run publishes pending messages until ctx is canceled
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		count, err := r.RelayPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("Could not relay outbox", "error", err)
+		}
+		// Keep going while there is work, otherwise wait
+		if count == r.config.BatchSize && err == nil {
+			continue
+		}
+		select {
+		case <-time.After(r.config.PollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RelayPending claims and publishes a batch of pending messages. It returns how many were attempted.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+
+	messages, err := r.outboxStore.OutboxClaim(ctx, r.config.BatchSize, r.config.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("could not claim messages: %w", err)
+	}
+
+	for _, message := range messages {
+		if err := r.Publish(ctx, message); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(messages), nil
+
+}
+
+// Publish publishes a message to every sink and saves the result, scheduling a retry if any sink
+// fails. Sinks that succeeded will receive the message again on retry. If the result cannot be
+// saved the message is published again once its lease expires.
+func (r *Relay) Publish(ctx context.Context, message *gorestapi.OutboxMessage) error {
+
+	message.Attempts++
+	message.LastError = ""
+
+	var errs []error
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err == nil {
+		message.Status = gorestapi.OutboxStatusDelivered
+	} else {
+		message.Status = gorestapi.OutboxStatusPending
+		message.LastError = err.Error()
+		message.NextAttempt = r.now().Add(r.backoff(message.Attempts))
+		r.logger.Warn("Could not publish outbox message", "id", message.ID, "attempts", message.Attempts, "error", err)
+	}
+
+	if err := r.outboxStore.OutboxMessageSave(ctx, message); err != nil {
+		return fmt.Errorf("could not save message %d: %w", message.ID, err)
+	}
+	return nil
+
+}
+
+// backoff returns the delay before the next attempt
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.config.BackoffInitial
+	for i := 1; i < attempts && delay < r.config.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > r.config.BackoffMax {
+		delay = r.config.BackoffMax
+	}
+	return delay
+}
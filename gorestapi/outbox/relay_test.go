@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+var testConfig = Config{
+	PollInterval:   time.Second,
+	BatchSize:      10,
+	Lease:          time.Minute,
+	BackoffInitial: time.Second,
+	BackoffMax:     time.Minute,
+}
+
+// testSink records published messages and returns err
+type testSink struct {
+	published []int64
+	err       error
+}
+
+func (s *testSink) Publish(ctx context.Context, message *gorestapi.OutboxMessage) error {
+	s.published = append(s.published, message.ID)
+	return s.err
+}
+
+func TestRelayPending(t *testing.T) {
+
+	// Mock Store and relay
+	obs := new(mocks.OutboxStore)
+	sink1, sink2 := new(testSink), new(testSink)
+	relay := NewRelay(obs, testConfig, sink1, sink2)
+
+	messages := []*gorestapi.OutboxMessage{
+		{ID: 1, Event: "thing.created", Status: gorestapi.OutboxStatusPending},
+		{ID: 2, Event: "thing.deleted", Status: gorestapi.OutboxStatusPending},
+	}
+	obs.On("OutboxClaim", mock.Anything, testConfig.BatchSize, testConfig.Lease).Once().Return(messages, nil)
+	obs.On("OutboxMessageSave", mock.Anything, mock.MatchedBy(func(m *gorestapi.OutboxMessage) bool {
+		return m.Status == gorestapi.OutboxStatusDelivered && m.Attempts == 1 && m.LastError == ""
+	})).Twice().Return(nil)
+
+	count, err := relay.RelayPending(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	// Every sink gets every message in order
+	assert.Equal(t, []int64{1, 2}, sink1.published)
+	assert.Equal(t, []int64{1, 2}, sink2.published)
+
+	// Check remaining expectations
+	obs.AssertExpectations(t)
+
+}
+
+func TestRelayPublishFailure(t *testing.T) {
+
+	// Mock Store and relay with one failing sink
+	obs := new(mocks.OutboxStore)
+	relay := NewRelay(obs, testConfig, new(testSink), &testSink{err: errors.New("unavailable")})
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+
+	// The message stays pending and is retried with backoff
+	message := &gorestapi.OutboxMessage{ID: 1, Attempts: 2, Status: gorestapi.OutboxStatusPending}
+	obs.On("OutboxMessageSave", mock.Anything, message).Once().Return(nil)
+	assert.Nil(t, relay.Publish(context.Background(), message))
+	assert.Equal(t, gorestapi.OutboxStatusPending, message.Status)
+	assert.Equal(t, 3, message.Attempts)
+	assert.Equal(t, "unavailable", message.LastError)
+	assert.Equal(t, now.Add(4*time.Second), message.NextAttempt)
+
+	// A failure to save the result is returned
+	obs.On("OutboxMessageSave", mock.Anything, message).Once().Return(errors.New("database down"))
+	assert.NotNil(t, relay.Publish(context.Background(), message))
+
+	// Check remaining expectations
+	obs.AssertExpectations(t)
+
+}
@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// Headers sent with every message by the HTTP sink
+const (
+	HeaderMessage = "X-Outbox-Message"
+	HeaderEvent   = "X-Outbox-Event"
+)
+
+// Sink publishes outbox messages. Messages may be published more than once so consumers should
+// de-duplicate by message or event ID.
+type Sink interface {
+	Publish(ctx context.Context, message *gorestapi.OutboxMessage) error
+}
+
+// LogSink writes messages to a logger
+type LogSink struct {
+	logger *slog.Logger
+}
+
+// NewLogSink creates a sink that logs every message
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+// Publish logs the message
+func (s *LogSink) Publish(ctx context.Context, message *gorestapi.OutboxMessage) error {
+	s.logger.Info("Outbox message", "id", message.ID, "event", message.Event, "payload", string(message.Payload))
+	return nil
+}
+
+// HTTPSinkConfig configures the HTTP sink
+type HTTPSinkConfig struct {
+	// URL to POST messages to
+	URL string `conf:"url"`
+	// Timeout for each request
+	Timeout time.Duration `conf:"timeout"`
+	// Headers added to every request
+	Headers map[string]string `conf:"headers"`
+}
+
+// HTTPSink POSTs the payload of every message to a URL
+type HTTPSink struct {
+	client *http.Client
+	config HTTPSinkConfig
+}
+
+// NewHTTPSink creates a sink that POSTs messages to a URL
+func NewHTTPSink(config HTTPSinkConfig) (*HTTPSink, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("no url specified")
+	}
+	return &HTTPSink{
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}, nil
+}
+
+// Publish POSTs the message payload. Any non-2xx response is an error.
+func (s *HTTPSink) Publish(ctx context.Context, message *gorestapi.OutboxMessage) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(message.Payload))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderMessage, strconv.FormatInt(message.ID, 10))
+	req.Header.Set(HeaderEvent, message.Event)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+
+}
+
+// FileSink appends messages to a file as newline delimited JSON
+type FileSink struct {
+	sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens path for appending, creating it if needed
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Publish writes the message as a line of JSON and syncs it to disk
+func (s *FileSink) Publish(ctx context.Context, message *gorestapi.OutboxMessage) error {
+
+	b, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("could not marshal message: %w", err)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, err := s.file.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("could not write message: %w", err)
+	}
+	return s.file.Sync()
+
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
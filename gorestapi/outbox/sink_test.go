@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+func TestHTTPSink(t *testing.T) {
+
+	payload := []byte(`{"id":1,"type":"created","resource":"thing","resource_id":"t1"}`)
+
+	// Create a receiver that fails the first request
+	var received int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		body, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, payload, body)
+		assert.Equal(t, "7", r.Header.Get(HeaderMessage))
+		assert.Equal(t, "thing.created", r.Header.Get(HeaderEvent))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if received == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	sink, err := NewHTTPSink(HTTPSinkConfig{URL: receiver.URL, Timeout: time.Second, Headers: map[string]string{"Authorization": "Bearer token"}})
+	assert.Nil(t, err)
+
+	message := &gorestapi.OutboxMessage{ID: 7, Event: "thing.created", Payload: payload}
+	assert.NotNil(t, sink.Publish(context.Background(), message))
+	assert.Nil(t, sink.Publish(context.Background(), message))
+	assert.Equal(t, 2, received)
+
+	// A URL is required
+	_, err = NewHTTPSink(HTTPSinkConfig{})
+	assert.NotNil(t, err)
+
+}
+
+func TestFileSink(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "outbox.ndjson")
+	sink, err := NewFileSink(path)
+	assert.Nil(t, err)
+
+	assert.Nil(t, sink.Publish(context.Background(), &gorestapi.OutboxMessage{ID: 1, Event: "thing.created", Payload: json.RawMessage(`{"id":1}`)}))
+	assert.Nil(t, sink.Publish(context.Background(), &gorestapi.OutboxMessage{ID: 2, Event: "thing.deleted", Payload: json.RawMessage(`{"id":2}`)}))
+	assert.Nil(t, sink.Close())
+
+	// Validate one message per line
+	b, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	assert.Len(t, lines, 2)
+	var message gorestapi.OutboxMessage
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &message))
+	assert.Equal(t, int64(2), message.ID)
+	assert.Equal(t, "thing.deleted", message.Event)
+	assert.JSONEq(t, `{"id":2}`, string(message.Payload))
+
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"sigs.k8s.io/yaml"
+)
+
+// Content types
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeMsgPack = "application/msgpack"
+	ContentTypeCBOR    = "application/cbor"
+	ContentTypeYAML    = "application/yaml"
+)
+
+// Codec encodes and decodes a content type. All codecs use the json struct tags so every encoding has the same
+// field names.
+type Codec struct {
+	// ContentType is the content type of responses
+	ContentType string
+	// Aliases are other content types accepted for the codec
+	Aliases []string
+	Encode  func(w io.Writer, v any) error
+	Decode  func(r io.Reader, v any) error
+}
+
+var (
+	cborEncMode, _ = cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	cborDecMode, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))}.DecMode()
+)
+
+// Codecs are the supported codecs in order of preference
+var Codecs = []*Codec{
+	{
+		ContentType: ContentTypeJSON,
+		Encode: func(w io.Writer, v any) error {
+			return json.NewEncoder(w).Encode(v)
+		},
+		Decode: func(r io.Reader, v any) error {
+			return json.NewDecoder(r).Decode(v)
+		},
+	},
+	{
+		ContentType: ContentTypeMsgPack,
+		Aliases:     []string{"application/x-msgpack", "application/vnd.msgpack"},
+		Encode: func(w io.Writer, v any) error {
+			enc := msgpack.NewEncoder(w)
+			enc.SetCustomStructTag("json")
+			return enc.Encode(v)
+		},
+		Decode: func(r io.Reader, v any) error {
+			dec := msgpack.NewDecoder(r)
+			dec.SetCustomStructTag("json")
+			return dec.Decode(v)
+		},
+	},
+	{
+		ContentType: ContentTypeCBOR,
+		Encode: func(w io.Writer, v any) error {
+			return cborEncMode.NewEncoder(w).Encode(v)
+		},
+		Decode: func(r io.Reader, v any) error {
+			return cborDecMode.NewDecoder(r).Decode(v)
+		},
+	},
+	{
+		ContentType: ContentTypeYAML,
+		Aliases:     []string{"application/x-yaml", "text/yaml"},
+		Encode: func(w io.Writer, v any) error {
+			b, err := yaml.Marshal(v)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(b)
+			return err
+		},
+		Decode: func(r io.Reader, v any) error {
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			return yaml.Unmarshal(b, v)
+		},
+	},
+}
+
+// CodecJSON is the default codec
+var CodecJSON = Codecs[0]
+
+// CodecFor returns the codec for a media type or nil if there isn't one
+func CodecFor(mediaType string) *Codec {
+	for _, codec := range Codecs {
+		if codec.ContentType == mediaType {
+			return codec
+		}
+		for _, alias := range codec.Aliases {
+			if alias == mediaType {
+				return codec
+			}
+		}
+	}
+	return nil
+}
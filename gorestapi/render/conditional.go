@@ -0,0 +1,91 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EncodeConditional writes v like Encode with a status of 200, an ETag from a hash of the encoded response and a
+// Last-Modified header if lastModified is set. It responds 304 if the If-None-Match or If-Modified-Since headers
+// show the client already has it.
+func EncodeConditional(w http.ResponseWriter, r *http.Request, v any, lastModified time.Time) {
+	b, codec, err := marshal(w, v)
+	if err != nil {
+		encodeError(w, err)
+		return
+	}
+	sum := sha256.Sum256(b)
+	if notModified(w, r, `"`+hex.EncodeToString(sum[:16])+`"`, lastModified) {
+		return
+	}
+	write(w, http.StatusOK, codec, b)
+}
+
+// EncodeWithETag writes v like Encode with a status of 200 and the etag. It responds 304 without encoding v if the
+// If-None-Match header matches.
+func EncodeWithETag(w http.ResponseWriter, r *http.Request, v any, etag string) {
+	if notModified(w, r, etag, time.Time{}) {
+		return
+	}
+	Encode(w, http.StatusOK, v)
+}
+
+// WeakETag returns a weak ETag from a hash of the parts and the negotiated content type
+func WeakETag(w http.ResponseWriter, parts ...any) string {
+	h := sha256.New()
+	fmt.Fprint(h, responseCodec(w).ContentType)
+	for _, part := range parts {
+		fmt.Fprintf(h, "\x00%v", part)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// notModified sets the ETag and Last-Modified headers and responds 304 if the client has the current version.
+// If-Modified-Since is only used without If-None-Match as per RFC 9110.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" || !etagMatch(inm, etag) {
+			return false
+		}
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || lastModified.After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+
+}
+
+// etagMatch returns if any of the If-None-Match etags match using weak comparison
+func etagMatch(inm string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, value := range strings.Split(inm, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || strings.TrimPrefix(value, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
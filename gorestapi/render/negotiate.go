@@ -0,0 +1,173 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// negotiateConfig are the content types a route handles itself in addition to the codecs
+type negotiateConfig struct {
+	produces   []string
+	consumes   []string
+	represents []*Codec
+}
+
+// NegotiateOption configures Negotiate
+type NegotiateOption func(c *negotiateConfig)
+
+// Produces allows Accept headers for content types the handler writes itself, ie. text/csv
+func Produces(contentTypes ...string) NegotiateOption {
+	return func(c *negotiateConfig) {
+		c.produces = append(c.produces, contentTypes...)
+	}
+}
+
+// Represents allows Accept headers for codecs only used for responses, ie. hypermedia formats of JSON the handler
+// builds the documents for
+func Represents(codecs ...*Codec) NegotiateOption {
+	return func(c *negotiateConfig) {
+		c.represents = append(c.represents, codecs...)
+	}
+}
+
+// Consumes allows request bodies with content types the handler reads itself, ie. text/csv
+func Consumes(contentTypes ...string) NegotiateOption {
+	return func(c *negotiateConfig) {
+		c.consumes = append(c.consumes, contentTypes...)
+	}
+}
+
+// requestCodecKey is the context key of the request codec
+type requestCodecKey struct{}
+
+// responseWriter carries the negotiated response codec to Encode
+type responseWriter struct {
+	http.ResponseWriter
+	codec *Codec
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Negotiate selects the response codec from the Accept header and the request codec from the Content-Type header
+// for Encode and Decode. It responds 406 if no acceptable content type is supported and 415 if the request body
+// is not a supported content type.
+func Negotiate(opts ...NegotiateOption) func(http.Handler) http.Handler {
+
+	var config negotiateConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			w.Header().Add("Vary", "Accept")
+
+			codec, ok := acceptCodec(r.Header.Get("Accept"), config)
+			if !ok {
+				supported := contentTypes()
+				for _, codec := range config.represents {
+					supported = append(supported, codec.ContentType)
+				}
+				Err(w, http.StatusNotAcceptable, WithStatus("not acceptable"), WithError(fmt.Errorf("none of the accepted content types are supported, use one of %s", strings.Join(append(supported, config.produces...), ", "))))
+				return
+			}
+			if codec != nil {
+				w = &responseWriter{ResponseWriter: w, codec: codec}
+			}
+
+			if hasBody(r) {
+				mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if mediaType == "" {
+					mediaType = ContentTypeJSON
+				}
+				if requestCodec := CodecFor(mediaType); requestCodec != nil {
+					r = r.WithContext(context.WithValue(r.Context(), requestCodecKey{}, requestCodec))
+				} else if !slices.Contains(config.consumes, mediaType) {
+					Err(w, http.StatusUnsupportedMediaType, WithStatus("unsupported media type"), WithError(fmt.Errorf("content type %s is not supported, use one of %s", mediaType, strings.Join(append(contentTypes(), config.consumes...), ", "))))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+
+}
+
+// acceptCodec returns the codec for the most preferred supported content type in the Accept header. It returns
+// a nil codec if the preferred content type is one the handler produces itself and false if nothing is supported.
+func acceptCodec(accept string, config negotiateConfig) (*Codec, bool) {
+
+	if strings.TrimSpace(accept) == "" {
+		return CodecJSON, true
+	}
+
+	type acceptType struct {
+		mediaType string
+		q         float64
+	}
+	var types []acceptType
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			types = append(types, acceptType{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(types, func(i, j int) bool { return types[i].q > types[j].q })
+
+	for _, t := range types {
+		if t.mediaType == "*/*" || t.mediaType == "application/*" {
+			return CodecJSON, true
+		} else if codec := CodecFor(t.mediaType); codec != nil {
+			return codec, true
+		} else if i := slices.IndexFunc(config.represents, func(codec *Codec) bool { return codec.ContentType == t.mediaType }); i >= 0 {
+			return config.represents[i], true
+		} else if slices.Contains(config.produces, t.mediaType) {
+			return nil, true
+		}
+	}
+	return nil, false
+
+}
+
+// hasBody returns if the request has a body to decode
+func hasBody(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	}
+	return r.ContentLength > 0 || r.ContentLength == -1
+}
+
+// contentTypes returns the content types of the codecs
+func contentTypes() []string {
+	ret := make([]string, 0, len(Codecs))
+	for _, codec := range Codecs {
+		ret = append(ret, codec.ContentType)
+	}
+	return ret
+}
@@ -0,0 +1,127 @@
+// Package render writes responses and reads requests in the content type negotiated by Negotiate. It has the same
+// helpers as github.com/snowzach/golib/httpserver/render and falls back to JSON for routes without negotiation.
+package render
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/snowzach/golib/httpserver/render"
+)
+
+// ErrResponse is the body of error responses
+type ErrResponse = render.ErrResponse
+
+// ErrOption sets fields of an error response
+type ErrOption = render.ErrOption
+
+var (
+	WithStatus  = render.WithStatus
+	WithError   = render.WithError
+	WithErrorID = render.WithErrorID
+)
+
+// responseCodec returns the negotiated codec of the response writer or any writer it wraps
+func responseCodec(w http.ResponseWriter) *Codec {
+	for {
+		switch rw := w.(type) {
+		case *responseWriter:
+			return rw.codec
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return CodecJSON
+		}
+	}
+}
+
+// ContentType returns the negotiated content type of the response
+func ContentType(w http.ResponseWriter) string {
+	return responseCodec(w).ContentType
+}
+
+// Encode writes v as the response in the negotiated content type
+func Encode(w http.ResponseWriter, status int, v any) {
+	b, codec, err := marshal(w, v)
+	if err != nil {
+		encodeError(w, err)
+		return
+	}
+	write(w, status, codec, b)
+}
+
+// marshal encodes v in the negotiated content type
+func marshal(w http.ResponseWriter, v any) ([]byte, *Codec, error) {
+	codec := responseCodec(w)
+	b := new(bytes.Buffer)
+	if err := codec.Encode(b, v); err != nil {
+		return nil, nil, err
+	}
+	return b.Bytes(), codec, nil
+}
+
+// write writes an encoded response
+func write(w http.ResponseWriter, status int, codec *Codec, b []byte) {
+	w.Header().Set("Content-Type", codec.ContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+// encodeError responds with an error in JSON when the response could not be encoded
+func encodeError(w http.ResponseWriter, err error) {
+	render.JSON(w, http.StatusInternalServerError, ErrResponse{Status: "internal error", Error: "could not encode response: " + err.Error()})
+}
+
+// Decode reads the request body in the negotiated content type into v
+func Decode(r *http.Request, v any) error {
+	defer io.Copy(io.Discard, r.Body)
+	codec, ok := r.Context().Value(requestCodecKey{}).(*Codec)
+	if !ok {
+		codec = CodecJSON
+	}
+	return codec.Decode(r.Body, v)
+}
+
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func Err(w http.ResponseWriter, status int, opts ...ErrOption) {
+	var err ErrResponse
+	for _, opt := range opts {
+		opt(&err)
+	}
+	Encode(w, status, err)
+}
+
+func ErrNotFound(w http.ResponseWriter) {
+	Encode(w, http.StatusNotFound, ErrResponse{Status: "not found", Error: "not found"})
+}
+
+func ErrResourceNotFound(w http.ResponseWriter, resource string) {
+	Encode(w, http.StatusNotFound, ErrResponse{Status: resource + " not found", Error: resource + " not found"})
+}
+
+func ErrInvalidRequestWithID(w http.ResponseWriter, id string, err error) {
+	Encode(w, http.StatusBadRequest, ErrResponse{Status: "invalid request", Error: errString(err), ErrorID: id})
+}
+
+func ErrInvalidRequest(w http.ResponseWriter, err error) {
+	ErrInvalidRequestWithID(w, "", err)
+}
+
+func ErrInternalWithID(w http.ResponseWriter, id string, err error) {
+	Encode(w, http.StatusInternalServerError, ErrResponse{Status: "internal error", Error: errString(err), ErrorID: id})
+}
+
+func ErrInternal(w http.ResponseWriter, err error) {
+	ErrInternalWithID(w, "", err)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRecord struct {
+	ID    string `json:"id"`
+	Count int    `json:"count,omitempty"`
+}
+
+func TestNegotiate(t *testing.T) {
+
+	// Echo handler decodes the request and encodes it as the response
+	handler := Negotiate(Produces("text/csv"), Consumes("text/csv"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rec testRecord
+		if r.Method == http.MethodPost && r.Header.Get("Content-Type") != "text/csv" {
+			if err := Decode(r, &rec); err != nil {
+				ErrInvalidRequest(w, err)
+				return
+			}
+		}
+		Encode(w, http.StatusOK, &rec)
+	}))
+
+	request := func(method string, accept string, contentType string, body []byte) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", bytes.NewReader(body))
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	rec := &testRecord{ID: "one", Count: 2}
+	for _, codec := range Codecs {
+		b := new(bytes.Buffer)
+		assert.Nil(t, codec.Encode(b, rec))
+
+		// Round trip in every codec
+		w := request(http.MethodPost, codec.ContentType, codec.ContentType, b.Bytes())
+		assert.Equal(t, http.StatusOK, w.Code, codec.ContentType)
+		assert.Equal(t, codec.ContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		var got testRecord
+		assert.Nil(t, codec.Decode(w.Body, &got), codec.ContentType)
+		assert.Equal(t, rec, &got, codec.ContentType)
+	}
+
+	// Preference and defaults
+	assert.Equal(t, ContentTypeJSON, request(http.MethodGet, "", "", nil).Header().Get("Content-Type"))
+	assert.Equal(t, ContentTypeJSON, request(http.MethodGet, "text/html, */*;q=0.8", "", nil).Header().Get("Content-Type"))
+	assert.Equal(t, ContentTypeCBOR, request(http.MethodGet, "application/msgpack;q=0.5, application/cbor", "", nil).Header().Get("Content-Type"))
+	assert.Equal(t, ContentTypeMsgPack, request(http.MethodGet, "application/x-msgpack", "", nil).Header().Get("Content-Type"))
+	assert.Equal(t, ContentTypeJSON, request(http.MethodPost, "", "", []byte(`{"id":"one"}`)).Header().Get("Content-Type"))
+
+	// Content types the handler produces and consumes itself
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "text/csv", "", nil).Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "", "text/csv", []byte("id\n")).Code)
+
+	// Unsupported
+	w := request(http.MethodGet, "text/html", "", nil)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, ContentTypeJSON, w.Header().Get("Content-Type"))
+	w = request(http.MethodPost, ContentTypeCBOR, "application/xml", []byte("<id/>"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Equal(t, ContentTypeCBOR, w.Header().Get("Content-Type"))
+
+}
+
+func TestEncodeWithoutNegotiate(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	ErrInternalWithID(w, "req", errors.New("oops"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, ContentTypeJSON, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status":"internal error","error":"oops","error_id":"req"}`, w.Body.String())
+
+}
+
+func TestEncodeConditional(t *testing.T) {
+
+	lastModified := time.Date(2023, 1, 2, 3, 4, 5, 600, time.UTC)
+	request := func(header string, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		EncodeConditional(w, r, &testRecord{ID: "one"}, lastModified)
+		return w
+	}
+
+	w := request("", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Mon, 02 Jan 2023 03:04:05 GMT", w.Header().Get("Last-Modified"))
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+
+	assert.Equal(t, http.StatusNotModified, request("If-None-Match", etag).Code)
+	assert.Equal(t, http.StatusNotModified, request("If-None-Match", `"other", W/`+etag).Code)
+	assert.Equal(t, http.StatusNotModified, request("If-None-Match", "*").Code)
+	assert.Equal(t, http.StatusOK, request("If-None-Match", `"other"`).Code)
+	assert.Equal(t, http.StatusNotModified, request("If-Modified-Since", "Mon, 02 Jan 2023 03:04:05 GMT").Code)
+	assert.Equal(t, http.StatusOK, request("If-Modified-Since", "Mon, 02 Jan 2023 03:04:04 GMT").Code)
+	assert.Equal(t, http.StatusOK, request("If-Modified-Since", "yesterday").Code)
+	assert.Empty(t, request("If-None-Match", etag).Body.String())
+
+	// Weak etags depend on the parts and the content type
+	w = httptest.NewRecorder()
+	assert.Equal(t, WeakETag(w, 1, "a"), WeakETag(w, 1, "a"))
+	assert.NotEqual(t, WeakETag(w, 1, "a"), WeakETag(w, 1, "b"))
+	assert.NotEqual(t, WeakETag(w, 1, "a"), WeakETag(&responseWriter{ResponseWriter: w, codec: CodecFor(ContentTypeCBOR)}, 1, "a"))
+
+}
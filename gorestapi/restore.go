@@ -0,0 +1,14 @@
+package gorestapi
+
+import (
+	"context"
+)
+
+// RestoreStore saves records exactly as they are, including their timestamps. Restored records create events like
+// any other save.
+type RestoreStore interface {
+	// ThingsRestore creates or replaces the things in a single transaction
+	ThingsRestore(ctx context.Context, things []*Thing) error
+	// WidgetsRestore creates or replaces the widgets in a single transaction
+	WidgetsRestore(ctx context.Context, widgets []*Widget) error
+}
@@ -0,0 +1,168 @@
+// Package seed loads fixtures of things and widgets through the store.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// Fixtures are things and widgets keyed by their fixture name. Widgets reference their thing by its fixture name
+// so fixtures don't depend on generated IDs.
+type Fixtures struct {
+	Things  map[string]*ThingFixture  `json:"things"`
+	Widgets map[string]*WidgetFixture `json:"widgets"`
+}
+
+// ThingFixture is a thing to load
+type ThingFixture struct {
+	// ID is optional, fixtures with an ID are updated when loaded again
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// WidgetFixture is a widget to load
+type WidgetFixture struct {
+	// ID is optional, fixtures with an ID are updated when loaded again
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Thing is the fixture name of the thing of the widget
+	Thing string `json:"thing,omitempty"`
+	// ThingID is the ID of an existing thing of the widget
+	ThingID string `json:"thing_id,omitempty"`
+}
+
+// Parse reads fixtures from YAML or JSON
+func Parse(r io.Reader) (*Fixtures, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	fixtures := new(Fixtures)
+	if err := yaml.UnmarshalStrict(b, fixtures); err != nil {
+		return nil, fmt.Errorf("could not parse fixtures: %w", err)
+	}
+	return fixtures, nil
+}
+
+// IsFixtureFile returns if the file has the extension of a fixture file
+func IsFixtureFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Result is the outcome of loading fixtures
+type Result struct {
+	Things  int `json:"things"`
+	Widgets int `json:"widgets"`
+}
+
+// Seeder loads fixtures. Things loaded by earlier fixtures can be referenced by later ones.
+type Seeder struct {
+	grStore gorestapi.GRStore
+	txStore gorestapi.TxStore
+	things  map[string]*gorestapi.Thing
+}
+
+// New creates a seeder. If the transaction store is not nil each load is a single transaction.
+func New(grStore gorestapi.GRStore, txStore gorestapi.TxStore) *Seeder {
+	return &Seeder{
+		grStore: grStore,
+		txStore: txStore,
+		things:  make(map[string]*gorestapi.Thing),
+	}
+}
+
+// Load saves the things and then the widgets of the fixtures in order of their fixture names
+func (s *Seeder) Load(ctx context.Context, fixtures *Fixtures) (*Result, error) {
+
+	if s.txStore == nil {
+		return s.load(ctx, fixtures)
+	}
+
+	var result *Result
+	err := s.txStore.WithTransaction(ctx, nil, func(ctx context.Context) error {
+		var err error
+		result, err = s.load(ctx, fixtures)
+		return err
+	})
+	return result, err
+
+}
+
+func (s *Seeder) load(ctx context.Context, fixtures *Fixtures) (*Result, error) {
+
+	// References are only kept once everything is saved
+	things := make(map[string]*gorestapi.Thing)
+	result := new(Result)
+
+	for _, name := range sortedKeys(fixtures.Things) {
+		fixture := fixtures.Things[name]
+		if fixture == nil {
+			return nil, fmt.Errorf("thing %s: empty fixture", name)
+		}
+		if _, ok := s.things[name]; ok {
+			return nil, fmt.Errorf("thing %s: already loaded", name)
+		}
+		thing := &gorestapi.Thing{ID: fixture.ID, Name: fixture.Name, Description: fixture.Description}
+		if err := s.grStore.ThingSave(ctx, thing); err != nil {
+			return nil, fmt.Errorf("thing %s: %w", name, err)
+		}
+		things[name] = thing
+		result.Things++
+	}
+
+	for _, name := range sortedKeys(fixtures.Widgets) {
+		fixture := fixtures.Widgets[name]
+		if fixture == nil {
+			return nil, fmt.Errorf("widget %s: empty fixture", name)
+		}
+		widget := &gorestapi.Widget{ID: fixture.ID, Name: fixture.Name, Description: fixture.Description}
+		switch {
+		case fixture.Thing != "" && fixture.ThingID != "":
+			return nil, fmt.Errorf("widget %s: thing and thing_id can not both be set", name)
+		case fixture.Thing != "":
+			thing, ok := things[fixture.Thing]
+			if !ok {
+				if thing, ok = s.things[fixture.Thing]; !ok {
+					return nil, fmt.Errorf("widget %s: unknown thing %s", name, fixture.Thing)
+				}
+			}
+			widget.ThingID = &thing.ID
+		case fixture.ThingID != "":
+			widget.ThingID = &fixture.ThingID
+		}
+		if err := s.grStore.WidgetSave(ctx, widget); err != nil {
+			return nil, fmt.Errorf("widget %s: %w", name, err)
+		}
+		result.Widgets++
+	}
+
+	for name, thing := range things {
+		s.things[name] = thing
+	}
+	return result, nil
+
+}
+
+// sortedKeys returns the keys of the map in order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
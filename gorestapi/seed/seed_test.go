@@ -0,0 +1,125 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/embed"
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+func TestParse(t *testing.T) {
+
+	fixtures, err := Parse(strings.NewReader(`
+things:
+  one:
+    name: One
+widgets:
+  a:
+    name: A
+    thing: one
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, &Fixtures{
+		Things:  map[string]*ThingFixture{"one": {Name: "One"}},
+		Widgets: map[string]*WidgetFixture{"a": {Name: "A", Thing: "one"}},
+	}, fixtures)
+
+	fixtures, err = Parse(strings.NewReader(`{"things":{"one":{"id":"t1","name":"One"}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "t1", fixtures.Things["one"].ID)
+
+	_, err = Parse(strings.NewReader(`things: {one: {nme: One}}`))
+	assert.NotNil(t, err, "unknown fields are an error")
+
+	demo, err := embed.DemoSeedFixtures()
+	assert.Nil(t, err)
+	defer demo.Close()
+	fixtures, err = Parse(demo)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, fixtures.Things)
+	assert.NotEmpty(t, fixtures.Widgets)
+
+}
+
+func TestLoad(t *testing.T) {
+
+	grs := new(mocks.GRStore)
+	s := New(grs, nil)
+
+	grs.On("ThingSave", mock.Anything, &gorestapi.Thing{Name: "One"}).Once().Run(func(args mock.Arguments) {
+		args.Get(1).(*gorestapi.Thing).ID = "generated"
+	}).Return(nil)
+	grs.On("ThingSave", mock.Anything, &gorestapi.Thing{ID: "t2", Name: "Two"}).Once().Return(nil)
+	grs.On("WidgetSave", mock.Anything, &gorestapi.Widget{Name: "A", ThingID: stringPtr("generated")}).Once().Return(nil)
+	grs.On("WidgetSave", mock.Anything, &gorestapi.Widget{Name: "B", ThingID: stringPtr("existing")}).Once().Return(nil)
+	grs.On("WidgetSave", mock.Anything, &gorestapi.Widget{Name: "C"}).Once().Return(nil)
+
+	result, err := s.Load(context.Background(), &Fixtures{
+		Things: map[string]*ThingFixture{
+			"one": {Name: "One"},
+			"two": {ID: "t2", Name: "Two"},
+		},
+		Widgets: map[string]*WidgetFixture{
+			"a": {Name: "A", Thing: "one"},
+			"b": {Name: "B", ThingID: "existing"},
+			"c": {Name: "C"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{Things: 2, Widgets: 3}, result)
+
+	// Things of earlier loads can be referenced
+	grs.On("WidgetSave", mock.Anything, &gorestapi.Widget{Name: "D", ThingID: stringPtr("t2")}).Once().Return(nil)
+	result, err = s.Load(context.Background(), &Fixtures{Widgets: map[string]*WidgetFixture{"d": {Name: "D", Thing: "two"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, &Result{Widgets: 1}, result)
+
+	_, err = s.Load(context.Background(), &Fixtures{Things: map[string]*ThingFixture{"one": {Name: "Again"}}})
+	assert.EqualError(t, err, "thing one: already loaded")
+	_, err = s.Load(context.Background(), &Fixtures{Widgets: map[string]*WidgetFixture{"e": {Name: "E", Thing: "missing"}}})
+	assert.EqualError(t, err, "widget e: unknown thing missing")
+	_, err = s.Load(context.Background(), &Fixtures{Widgets: map[string]*WidgetFixture{"e": {Name: "E", Thing: "one", ThingID: "t1"}}})
+	assert.EqualError(t, err, "widget e: thing and thing_id can not both be set")
+
+	grs.AssertExpectations(t)
+
+}
+
+func TestLoadTransaction(t *testing.T) {
+
+	grs := new(mocks.GRStore)
+	txs := new(mocks.TxStore)
+	s := New(grs, txs)
+
+	txs.On("WithTransaction", mock.Anything, (*sql.TxOptions)(nil), mock.Anything).Return(func(ctx context.Context, opts *sql.TxOptions, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+	grs.On("ThingSave", mock.Anything, &gorestapi.Thing{ID: "t1", Name: "One"}).Once().Return(nil)
+	grs.On("WidgetSave", mock.Anything, &gorestapi.Widget{Name: "A", ThingID: stringPtr("t1")}).Once().Return(errors.New("failed"))
+
+	_, err := s.Load(context.Background(), &Fixtures{
+		Things:  map[string]*ThingFixture{"one": {ID: "t1", Name: "One"}},
+		Widgets: map[string]*WidgetFixture{"a": {Name: "A", Thing: "one"}},
+	})
+	assert.EqualError(t, err, "widget a: failed")
+
+	// The thing was rolled back so it can't be referenced
+	_, err = s.Load(context.Background(), &Fixtures{Widgets: map[string]*WidgetFixture{"b": {Name: "B", Thing: "one"}}})
+	assert.EqualError(t, err, "widget b: unknown thing one")
+
+	grs.AssertExpectations(t)
+	txs.AssertExpectations(t)
+
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,13 @@
+package gorestapi
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TxStore runs store calls in a transaction
+type TxStore interface {
+	// WithTransaction calls fn with a context that runs every store call made with it in a single transaction
+	// started with opts, the default if nil. The transaction is committed if fn returns nil and rolled back otherwise.
+	WithTransaction(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,167 @@
+package gorestapi
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/snowzach/queryp"
+)
+
+// Webhook Delivery Statuses
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusDead      = "dead"
+)
+
+// Webhook is a subscription to deliver events to a URL
+type Webhook struct {
+	// ID (Auto-Generated)
+	ID string `json:"id"`
+	// Created Timestamp
+	Created time.Time `json:"created,omitempty"`
+	// Updated Timestamp
+	Updated time.Time `json:"updated,omitempty"`
+	// URL to POST events to
+	URL string `json:"url"`
+	// Events to deliver, ie. thing.created, thing.* or *
+	Events WebhookEvents `json:"events"`
+	// Secret used to sign payloads (Auto-Generated, only returned when generated or provided)
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookExample
+type WebhookExample struct {
+	// URL to POST events to
+	URL string `json:"url"`
+	// Events to deliver, ie. thing.created, thing.* or *
+	Events []string `json:"events"`
+	// Secret used to sign payloads (Auto-Generated if not provided)
+	Secret string `json:"secret,omitempty"`
+}
+
+// String is the stringer method
+func (w *Webhook) String() string {
+	b, _ := json.Marshal(w)
+	return string(b)
+}
+
+// Validate checks the webhook can be saved
+func (w *Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid url: must be an absolute http or https url")
+	}
+	if len(w.Events) == 0 {
+		return errors.New("no events specified")
+	}
+	for _, name := range w.Events {
+		if name == "*" {
+			continue
+		}
+		resource, eventType, found := strings.Cut(name, ".")
+		if !found {
+			return fmt.Errorf("invalid event: %s", name)
+		}
+		switch resource {
+		case EventResourceThing, EventResourceWidget, "*":
+		default:
+			return fmt.Errorf("invalid event resource: %s", name)
+		}
+		switch eventType {
+		case EventTypeCreated, EventTypeUpdated, EventTypeDeleted, "*":
+		default:
+			return fmt.Errorf("invalid event type: %s", name)
+		}
+	}
+	return nil
+}
+
+// WebhookEvents is the list of event names a webhook subscribes to
+type WebhookEvents []string
+
+// Match returns true if the event name is subscribed to
+func (e WebhookEvents) Match(name string) bool {
+	resource, eventType, _ := strings.Cut(name, ".")
+	for _, match := range e {
+		if match == "*" || match == name || match == resource+".*" || match == "*."+eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Value stores the events as JSON
+func (e WebhookEvents) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(e))
+	return string(b), err
+}
+
+// Scan loads the events from JSON
+func (e *WebhookEvents) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(e))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(e))
+	}
+	return fmt.Errorf("could not scan %T into WebhookEvents", src)
+}
+
+// WebhookDelivery is an attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	// ID (Auto-Generated)
+	ID int64 `json:"id"`
+	// Created Timestamp
+	Created time.Time `json:"created,omitempty"`
+	// Updated Timestamp
+	Updated time.Time `json:"updated,omitempty"`
+	// WebhookID is the webhook being delivered to
+	WebhookID string `json:"webhook_id" db:"webhook_id"`
+	// EventID is the event being delivered
+	EventID int64 `json:"event_id" db:"event_id"`
+	// Event is the name of the event
+	Event string `json:"event"`
+	// Payload is the JSON body that is delivered
+	Payload json.RawMessage `json:"payload"`
+	// Status is pending, delivered or dead
+	Status string `json:"status"`
+	// Attempts made so far
+	Attempts int `json:"attempts"`
+	// NextAttempt is when the next attempt will be made if pending
+	NextAttempt time.Time `json:"next_attempt" db:"next_attempt"`
+	// LastStatusCode is the HTTP status code of the last attempt
+	LastStatusCode int `json:"last_status_code" db:"last_status_code"`
+	// LastError is the error from the last attempt
+	LastError string `json:"last_error" db:"last_error"`
+}
+
+// WebhookStore is the persistent store of webhooks and their deliveries
+type WebhookStore interface {
+	WebhookGetByID(ctx context.Context, id string) (*Webhook, error)
+	WebhookSave(ctx context.Context, webhook *Webhook) error
+	WebhookDeleteByID(ctx context.Context, id string) error
+	WebhooksFind(ctx context.Context, qp *queryp.QueryParameters) ([]*Webhook, *int64, error)
+
+	WebhookDeliveryGetByID(ctx context.Context, id int64) (*WebhookDelivery, error)
+	WebhookDeliverySave(ctx context.Context, delivery *WebhookDelivery) error
+	WebhookDeliveriesFind(ctx context.Context, qp *queryp.QueryParameters) ([]*WebhookDelivery, *int64, error)
+	// WebhookDeliveriesClaim returns up to limit pending deliveries that are due and hides them
+	// from other callers for the lease duration.
+	WebhookDeliveriesClaim(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+}
@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/snowzach/golib/log"
+	"github.com/snowzach/golib/store"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Config configures the delivery worker
+type Config struct {
+	// How often to check for pending deliveries when idle
+	PollInterval time.Duration `conf:"poll_interval"`
+	// How many deliveries to claim at a time
+	BatchSize int `conf:"batch_size"`
+	// Timeout for each delivery request
+	Timeout time.Duration `conf:"timeout"`
+	// Attempts before a delivery is marked dead
+	MaxAttempts int `conf:"max_attempts"`
+	// Delay before the first retry, doubling on each attempt up to BackoffMax
+	BackoffInitial time.Duration `conf:"backoff_initial"`
+	BackoffMax     time.Duration `conf:"backoff_max"`
+	// Private, loopback and link-local networks deliveries may be sent to, ie. 10.0.0.0/8. By default they are refused.
+	AllowedNetworks []string `conf:"allowed_networks"`
+}
+
+// Worker delivers pending webhook deliveries
+type Worker struct {
+	logger       *slog.Logger
+	webhookStore gorestapi.WebhookStore
+	client       *http.Client
+	config       Config
+	now          func() time.Time
+}
+
+// NewWorker creates a new delivery worker
+func NewWorker(webhookStore gorestapi.WebhookStore, config Config) *Worker {
+	return &Worker{
+		logger:       log.Logger.With("context", "webhook"),
+		webhookStore: webhookStore,
+		client:       newClient(config),
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+// newClient returns a client that refuses to connect to internal addresses so anyone that can create a webhook can't
+// use it to reach services behind the api. The address is checked when dialing, after DNS resolution and for every
+// redirect, so a public name resolving to an internal address is refused too.
+func newClient(config Config) *http.Client {
+
+	var allowed []netip.Prefix
+	for _, network := range config.AllowedNetworks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			log.Logger.Error("Invalid webhooks.allowed_networks", "network", network, "error", err)
+			continue
+		}
+		allowed = append(allowed, prefix)
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			return checkAddr(addrPort.Addr(), allowed)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// A proxy would connect to the webhook URL on our behalf without the check
+	transport.Proxy = nil
+
+	return &http.Client{Timeout: config.Timeout, Transport: transport}
+
+}
+
+// checkAddr refuses internal addresses unless they are in an allowed network
+func checkAddr(addr netip.Addr, allowed []netip.Prefix) error {
+	addr = addr.Unmap()
+	if !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() && !addr.IsUnspecified() {
+		return nil
+	}
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("address %s is not allowed, add it to webhooks.allowed_networks", addr)
+}
+
+// Run delivers pending deliveries until ctx is canceled
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		count, err := w.DeliverPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Error("Could not deliver webhooks", "error", err)
+		}
+		// Keep going while there is work, otherwise wait
+		if count == w.config.BatchSize && err == nil {
+			continue
+		}
+		select {
+		case <-time.After(w.config.PollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// DeliverPending claims and attempts a batch of pending deliveries. It returns how many were attempted.
+func (w *Worker) DeliverPending(ctx context.Context) (int, error) {
+
+	// Hide the deliveries from other workers for longer than it can take to deliver them
+	deliveries, err := w.webhookStore.WebhookDeliveriesClaim(ctx, w.config.BatchSize, time.Duration(w.config.BatchSize+1)*w.config.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("could not claim deliveries: %w", err)
+	}
+
+	webhooks := make(map[string]*gorestapi.Webhook)
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = w.webhookStore.WebhookGetByID(ctx, delivery.WebhookID)
+			if err == store.ErrNotFound {
+				continue // Deleted, the delivery will be deleted with it
+			} else if err != nil {
+				return 0, fmt.Errorf("could not get webhook %s: %w", delivery.WebhookID, err)
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+		if err := w.Deliver(ctx, webhook, delivery); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(deliveries), nil
+
+}
+
+// Deliver attempts a delivery once and saves the result, scheduling a retry or marking it dead on failure.
+func (w *Worker) Deliver(ctx context.Context, webhook *gorestapi.Webhook, delivery *gorestapi.WebhookDelivery) error {
+
+	delivery.Attempts++
+	delivery.LastStatusCode, delivery.LastError = 0, ""
+
+	statusCode, err := w.post(ctx, webhook, delivery)
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		delivery.Status = gorestapi.WebhookDeliveryStatusDelivered
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= w.config.MaxAttempts {
+			delivery.Status = gorestapi.WebhookDeliveryStatusDead
+			w.logger.Warn("Webhook delivery failed permanently", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		} else {
+			delivery.Status = gorestapi.WebhookDeliveryStatusPending
+			delivery.NextAttempt = w.now().Add(w.backoff(delivery.Attempts))
+		}
+	}
+
+	if err := w.webhookStore.WebhookDeliverySave(ctx, delivery); err != nil {
+		return fmt.Errorf("could not save delivery %d: %w", delivery.ID, err)
+	}
+	return nil
+
+}
+
+// post sends the signed payload to the webhook URL
+func (w *Worker) post(ctx context.Context, webhook *gorestapi.Webhook, delivery *gorestapi.WebhookDelivery) (int, error) {
+
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+
+}
+
+// backoff returns the delay before the next attempt
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.config.BackoffInitial
+	for i := 1; i < attempts && delay < w.config.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > w.config.BackoffMax {
+		delay = w.config.BackoffMax
+	}
+	return delay
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and payload joined by a period.
+// Receivers should compute the same value to verify the X-Webhook-Signature header.
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
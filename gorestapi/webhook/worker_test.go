@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+	"github.com/snowzach/gorestapi/mocks"
+)
+
+var testConfig = Config{
+	PollInterval:   time.Second,
+	BatchSize:      10,
+	Timeout:        time.Second,
+	MaxAttempts:    3,
+	BackoffInitial: time.Minute,
+	BackoffMax:     time.Hour,
+	// The test receivers listen on loopback
+	AllowedNetworks: []string{"127.0.0.0/8", "::1/128"},
+}
+
+func TestDeliverPending(t *testing.T) {
+
+	payload := []byte(`{"id":1,"type":"created","resource":"thing","resource_id":"t1"}`)
+
+	// Create a receiver that validates the signature
+	var received int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		body, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, payload, body)
+		assert.Equal(t, "thing.created", r.Header.Get(HeaderEvent))
+		assert.Equal(t, "5", r.Header.Get(HeaderDelivery))
+		assert.Equal(t, "sha256="+Sign("secret", r.Header.Get(HeaderTimestamp), body), r.Header.Get(HeaderSignature))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	// Mock Store and worker
+	ws := new(mocks.WebhookStore)
+	worker := NewWorker(ws, testConfig)
+
+	webhook := &gorestapi.Webhook{ID: "w1", URL: receiver.URL, Events: gorestapi.WebhookEvents{"*"}, Secret: "secret"}
+	delivery := &gorestapi.WebhookDelivery{ID: 5, WebhookID: "w1", Event: "thing.created", Payload: payload, Status: gorestapi.WebhookDeliveryStatusPending}
+
+	ws.On("WebhookDeliveriesClaim", mock.Anything, testConfig.BatchSize, mock.AnythingOfType("time.Duration")).Once().Return([]*gorestapi.WebhookDelivery{delivery}, nil)
+	ws.On("WebhookGetByID", mock.Anything, "w1").Once().Return(webhook, nil)
+	ws.On("WebhookDeliverySave", mock.Anything, mock.MatchedBy(func(d *gorestapi.WebhookDelivery) bool {
+		return d.ID == 5 && d.Status == gorestapi.WebhookDeliveryStatusDelivered && d.Attempts == 1 && d.LastStatusCode == http.StatusNoContent && d.LastError == ""
+	})).Once().Return(nil)
+
+	count, err := worker.DeliverPending(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, received)
+
+	// Check remaining expectations
+	ws.AssertExpectations(t)
+
+}
+
+func TestDeliverFailure(t *testing.T) {
+
+	// Create a failing receiver
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	// Mock Store and worker
+	ws := new(mocks.WebhookStore)
+	worker := NewWorker(ws, testConfig)
+	now := time.Now()
+	worker.now = func() time.Time { return now }
+
+	webhook := &gorestapi.Webhook{ID: "w1", URL: receiver.URL, Secret: "secret"}
+
+	// The first failure is retried with backoff
+	delivery := &gorestapi.WebhookDelivery{ID: 5, WebhookID: "w1", Status: gorestapi.WebhookDeliveryStatusPending}
+	ws.On("WebhookDeliverySave", mock.Anything, delivery).Once().Return(nil)
+	assert.Nil(t, worker.Deliver(context.Background(), webhook, delivery))
+	assert.Equal(t, gorestapi.WebhookDeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.LastStatusCode)
+	assert.NotEmpty(t, delivery.LastError)
+	assert.Equal(t, now.Add(testConfig.BackoffInitial), delivery.NextAttempt)
+
+	// The last attempt is dead lettered
+	delivery.Attempts = testConfig.MaxAttempts - 1
+	ws.On("WebhookDeliverySave", mock.Anything, delivery).Once().Return(nil)
+	assert.Nil(t, worker.Deliver(context.Background(), webhook, delivery))
+	assert.Equal(t, gorestapi.WebhookDeliveryStatusDead, delivery.Status)
+	assert.Equal(t, testConfig.MaxAttempts, delivery.Attempts)
+
+	// Check remaining expectations
+	ws.AssertExpectations(t)
+
+}
+
+func TestBackoff(t *testing.T) {
+
+	worker := NewWorker(nil, testConfig)
+
+	assert.Equal(t, time.Minute, worker.backoff(1))
+	assert.Equal(t, 2*time.Minute, worker.backoff(2))
+	assert.Equal(t, 32*time.Minute, worker.backoff(6))
+	assert.Equal(t, time.Hour, worker.backoff(7))
+	assert.Equal(t, time.Hour, worker.backoff(100))
+
+}
+
+func TestInternalAddresses(t *testing.T) {
+
+	var received int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer receiver.Close()
+
+	// Loopback is refused by default
+	ws := new(mocks.WebhookStore)
+	config := testConfig
+	config.AllowedNetworks = nil
+	worker := NewWorker(ws, config)
+	_, err := worker.post(context.Background(), &gorestapi.Webhook{URL: receiver.URL}, &gorestapi.WebhookDelivery{})
+	assert.ErrorContains(t, err, "address 127.0.0.1 is not allowed")
+	assert.Equal(t, 0, received)
+
+	for addr, refused := range map[string]bool{
+		"8.8.8.8":         false,
+		"2001:4860::8888": false,
+		"127.0.0.1":       true,
+		"::1":             true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"192.168.1.1":     true,
+		"169.254.169.254": true,
+		"fe80::1":         true,
+		"fd00::1":         true,
+		"0.0.0.0":         true,
+		"::ffff:10.0.0.1": true,
+	} {
+		err := checkAddr(netip.MustParseAddr(addr), nil)
+		assert.Equal(t, refused, err != nil, addr)
+	}
+
+	// Allowed networks are permitted
+	assert.Nil(t, checkAddr(netip.MustParseAddr("10.1.2.3"), []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}))
+	assert.NotNil(t, checkAddr(netip.MustParseAddr("192.168.1.1"), []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}))
+
+}
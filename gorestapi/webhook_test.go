@@ -0,0 +1,35 @@
+package gorestapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookEventsMatch(t *testing.T) {
+
+	assert.True(t, WebhookEvents{"*"}.Match("thing.created"))
+	assert.True(t, WebhookEvents{"thing.created"}.Match("thing.created"))
+	assert.True(t, WebhookEvents{"thing.*"}.Match("thing.deleted"))
+	assert.True(t, WebhookEvents{"*.deleted"}.Match("widget.deleted"))
+	assert.False(t, WebhookEvents{"thing.*", "widget.created"}.Match("widget.deleted"))
+	assert.False(t, WebhookEvents{}.Match("thing.created"))
+
+}
+
+func TestWebhookValidate(t *testing.T) {
+
+	webhook := &Webhook{URL: "https://example.com/hook", Events: WebhookEvents{"thing.*", "widget.created"}}
+	assert.Nil(t, webhook.Validate())
+
+	webhook.URL = "/relative"
+	assert.NotNil(t, webhook.Validate())
+
+	webhook.URL = "https://example.com/hook"
+	webhook.Events = WebhookEvents{"thing.renamed"}
+	assert.NotNil(t, webhook.Validate())
+
+	webhook.Events = nil
+	assert.NotNil(t, webhook.Validate())
+
+}
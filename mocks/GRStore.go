@@ -105,6 +105,20 @@ func (_m *GRStore) ThingsFind(ctx context.Context, qp *queryp.QueryParameters) (
 	return r0, r1, r2
 }
 
+// ThingsStream provides a mock function with given fields: ctx, qp, fn
+func (_m *GRStore) ThingsStream(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Thing) error) error {
+	ret := _m.Called(ctx, qp, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *queryp.QueryParameters, func(*gorestapi.Thing) error) error); ok {
+		r0 = rf(ctx, qp, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WidgetDeleteByID provides a mock function with given fields: ctx, id
 func (_m *GRStore) WidgetDeleteByID(ctx context.Context, id string) error {
 	ret := _m.Called(ctx, id)
@@ -194,6 +208,20 @@ func (_m *GRStore) WidgetsFind(ctx context.Context, qp *queryp.QueryParameters)
 	return r0, r1, r2
 }
 
+// WidgetsStream provides a mock function with given fields: ctx, qp, fn
+func (_m *GRStore) WidgetsStream(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Widget) error) error {
+	ret := _m.Called(ctx, qp, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *queryp.QueryParameters, func(*gorestapi.Widget) error) error); ok {
+		r0 = rf(ctx, qp, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewGRStore interface {
 	mock.TestingT
 	Cleanup(func())
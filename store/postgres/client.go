@@ -1,6 +1,8 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
@@ -10,11 +12,21 @@ import (
 
 type Config struct {
 	postgres.Config `conf:",squash"`
+	// Events records every change in the event table and notifies listeners in commit order
+	Events bool `conf:"-"`
+	// Outbox writes every event to the outbox table to be relayed
+	Outbox bool `conf:"-"`
+	// Webhooks queues deliveries of every event for the subscribed webhooks
+	Webhooks bool `conf:"-"`
 }
 
 type Client struct {
-	db    *sqlx.DB
-	newID func() string
+	db       *sqlx.DB
+	newID    func() string
+	events   bool
+	outbox   bool
+	webhooks bool
+	schema   string
 }
 
 // New returns a new database client
@@ -30,6 +42,73 @@ func New(cfg *Config) (*Client, error) {
 		newID: func() string {
 			return xid.New().String()
 		},
+		events:   cfg.Events,
+		outbox:   cfg.Outbox,
+		webhooks: cfg.Webhooks,
+		schema:   cfg.Schema,
 	}, nil
 
 }
+
+// txKey is the context key of the transaction started by WithTransaction
+type txKey struct{}
+
+// conn is the transaction started by WithTransaction if there is one, otherwise the database
+type conn interface {
+	postgres.DB
+	sqlx.QueryerContext
+}
+
+// WithTransaction calls fn with a context that runs every store call made with it in a single transaction started
+// with opts. If the context already has a transaction fn runs in it and opts are ignored.
+func (c *Client) WithTransaction(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+	return c.transactWith(ctx, opts, func(tx *sqlx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction of the context or the database
+func (c *Client) conn(ctx context.Context) conn {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return c.db
+}
+
+// transact runs f in a transaction, committing if it returns nil and rolling back otherwise. If the context
+// already has a transaction from WithTransaction f runs in it and the caller of WithTransaction commits.
+func (c *Client) transact(ctx context.Context, f func(tx *sqlx.Tx) error) error {
+	return c.transactWith(ctx, nil, f)
+}
+
+// transactWith is transact starting the transaction with opts
+func (c *Client) transactWith(ctx context.Context, opts *sql.TxOptions, f func(tx *sqlx.Tx) error) error {
+
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return f(tx)
+	}
+
+	tx, err := c.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	if err := f(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+
+}
+
+// Close closes the database connection pool
+func (c *Client) Close() error {
+	return c.db.Close()
+}
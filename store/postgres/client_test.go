@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/snowzach/golib/store/driver/postgres"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snowzach/gorestapi/embed"
+)
+
+// newTestClient connects to the database of GORESTAPI_TEST_DATABASE_HOST and migrates a new schema for the test.
+// Tests using it are skipped without a database.
+func newTestClient(t *testing.T) *Client {
+
+	host := os.Getenv("GORESTAPI_TEST_DATABASE_HOST")
+	if host == "" {
+		t.Skip("GORESTAPI_TEST_DATABASE_HOST is not set")
+	}
+
+	migrationSource, err := embed.MigrationSource()
+	assert.Nil(t, err)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	c, err := New(&Config{Config: postgres.Config{
+		Host:            host,
+		Port:            envDefault("GORESTAPI_TEST_DATABASE_PORT", "5432"),
+		Username:        envDefault("GORESTAPI_TEST_DATABASE_USERNAME", "postgres"),
+		Password:        envDefault("GORESTAPI_TEST_DATABASE_PASSWORD", "postgres"),
+		Database:        envDefault("GORESTAPI_TEST_DATABASE_DATABASE", "gorestapi"),
+		Schema:          schema,
+		SearchPath:      schema,
+		AutoCreate:      true,
+		SSLMode:         "disable",
+		Retries:         1,
+		MaxConnections:  10,
+		MigrationSource: migrationSource,
+	}, Events: true})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		_, _ = c.db.Exec(`DROP SCHEMA "` + schema + `" CASCADE`)
+		_ = c.Close()
+	})
+	return c
+
+}
+
+func envDefault(name string, value string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return value
+}
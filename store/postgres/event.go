@@ -0,0 +1,185 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4/stdlib"
+	"github.com/snowzach/golib/store/driver/postgres"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// EventChannel is the postgres notification channel used to announce new events
+const EventChannel = "gorestapi_event"
+
+// eventLockKey is the transaction advisory lock that serializes event inserts. Event IDs come from a sequence when
+// they are inserted but become visible when the transaction commits, so without it an event could commit after one
+// with a higher ID was delivered and be skipped by every reader resuming from an ID.
+const eventLockKey = 7_206_211_013_001
+
+var (
+	EventTable = postgres.Generate(postgres.Table[gorestapi.Event]{
+		Table: `"event"`,
+		Fields: []*postgres.Field[gorestapi.Event]{
+			{Name: "id", ID: true},
+			{Name: "created", Insert: "NOW()"},
+			{Name: "type", Insert: "$#", Value: func(rec *gorestapi.Event) (driver.Value, error) { return rec.Type, nil }},
+			{Name: "resource", Insert: "$#", Value: func(rec *gorestapi.Event) (driver.Value, error) { return rec.Resource, nil }},
+			{Name: "resource_id", Insert: "$#", Value: func(rec *gorestapi.Event) (driver.Value, error) { return rec.ResourceID, nil }},
+			{Name: "data", Insert: "$#", Value: func(rec *gorestapi.Event) (driver.Value, error) {
+				if len(rec.Data) == 0 {
+					return nil, nil
+				}
+				return string(rec.Data), nil
+			}},
+		},
+	})
+)
+
+// eventSave records an event for a resource, queues it for webhooks and the outbox and notifies
+// listeners when the transaction commits. Events are only recorded if something reads them.
+func (c *Client) eventSave(ctx context.Context, db postgres.DB, eventType string, resource string, resourceID string, data any) (*gorestapi.Event, error) {
+
+	event := &gorestapi.Event{
+		Type:       eventType,
+		Resource:   resource,
+		ResourceID: resourceID,
+	}
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal event data: %w", err)
+		}
+		event.Data = b
+	}
+
+	if !c.events && !c.webhooks && !c.outbox {
+		return event, nil
+	}
+
+	// Held until the transaction ends so IDs are taken in commit order for listeners. Webhooks and the outbox only
+	// need the ID of the event so they don't wait for it.
+	if c.events {
+		if _, err := db.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, eventLockKey); err != nil {
+			return nil, postgres.WrapError(err)
+		}
+	}
+
+	if err := EventTable.Insert(ctx, db, event); err != nil {
+		return nil, err
+	}
+
+	if c.webhooks {
+		if err := webhookDeliveriesEnqueue(ctx, db, event); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.outbox {
+		if err := outboxEnqueue(ctx, db, event); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.events {
+		if _, err := db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, EventChannel, strconv.FormatInt(event.ID, 10)); err != nil {
+			return nil, postgres.WrapError(err)
+		}
+	}
+
+	return event, nil
+
+}
+
+// eventTypeForSave determines if a saved record was created or updated. Both timestamps are
+// set to the transaction time on insert so they only match for new records.
+func eventTypeForSave(created time.Time, updated time.Time) string {
+	if created.Equal(updated) {
+		return gorestapi.EventTypeCreated
+	}
+	return gorestapi.EventTypeUpdated
+}
+
+// EventsSince returns up to limit events with an ID greater than id. Events are inserted in commit order so an
+// event with a lower ID can not appear after id was read.
+func (c *Client) EventsSince(ctx context.Context, id int64, limit int) ([]*gorestapi.Event, error) {
+	var records = make([]*gorestapi.Event, 0)
+	err := c.db.SelectContext(ctx, &records, `SELECT `+EventTable.SelectFields+` FROM event WHERE id > $1 ORDER BY id LIMIT $2`, id, limit)
+	if err != nil {
+		return nil, postgres.WrapError(err)
+	}
+	return records, nil
+}
+
+// EventsLastID returns the ID of the most recent event
+func (c *Client) EventsLastID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := c.db.GetContext(ctx, &id, `SELECT COALESCE(MAX(id), 0) FROM event`); err != nil {
+		return 0, postgres.WrapError(err)
+	}
+	return id, nil
+}
+
+// EventsListen calls handler for every event with an ID greater than since, including events
+// saved by other clients, until ctx is canceled or the connection fails. It returns the ID of
+// the last event handled so listening can be resumed.
+func (c *Client) EventsListen(ctx context.Context, since int64, handler func(*gorestapi.Event)) (int64, error) {
+
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return since, fmt.Errorf("could not get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `LISTEN `+EventChannel); err != nil {
+		return since, fmt.Errorf("could not listen: %w", err)
+	}
+
+	// Deliver all events since the last one handled
+	catchUp := func() error {
+		for {
+			events, err := c.EventsSince(ctx, since, 100)
+			if err != nil {
+				return err
+			}
+			for _, event := range events {
+				handler(event)
+				since = event.ID
+			}
+			if len(events) < 100 {
+				return nil
+			}
+		}
+	}
+	if err = catchUp(); err != nil {
+		return since, err
+	}
+
+	for {
+		err = conn.Raw(func(driverConn any) error {
+			_, err := driverConn.(*stdlib.Conn).Conn().WaitForNotification(ctx)
+			return err
+		})
+		if err != nil {
+			return since, err
+		}
+		if err = catchUp(); err != nil {
+			return since, err
+		}
+	}
+
+}
+
+// EventsPurge deletes events created before the given time
+func (c *Client) EventsPurge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := c.db.ExecContext(ctx, `DELETE FROM event WHERE created < $1`, before)
+	if err != nil {
+		return 0, postgres.WrapError(err)
+	}
+	return result.RowsAffected()
+}
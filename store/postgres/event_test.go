@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+func TestEventsCommitOrder(t *testing.T) {
+
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	since, err := c.EventsLastID(ctx)
+	assert.Nil(t, err)
+
+	// The first transaction takes an ID and stays open
+	tx1, err := c.db.BeginTxx(ctx, nil)
+	assert.Nil(t, err)
+	first, err := c.eventSave(ctx, tx1, gorestapi.EventTypeCreated, gorestapi.EventResourceThing, "t1", nil)
+	assert.Nil(t, err)
+
+	// The second transaction tries to commit before the first
+	second := make(chan *gorestapi.Event, 1)
+	go func() {
+		tx2, err := c.db.BeginTxx(ctx, nil)
+		assert.Nil(t, err)
+		event, err := c.eventSave(ctx, tx2, gorestapi.EventTypeCreated, gorestapi.EventResourceThing, "t2", nil)
+		assert.Nil(t, err)
+		assert.Nil(t, tx2.Commit())
+		second <- event
+	}()
+
+	select {
+	case <-second:
+		t.Fatal("the second event committed before the first")
+	case <-time.After(200 * time.Millisecond):
+	}
+	events, err := c.EventsSince(ctx, since, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+
+	// Once the first commits the second follows with a higher ID, so a reader resuming after the first sees it
+	assert.Nil(t, tx1.Commit())
+	events, err = c.EventsSince(ctx, since, 10)
+	assert.Nil(t, err)
+	if assert.NotEmpty(t, events) {
+		assert.Equal(t, first.ID, events[0].ID)
+	}
+
+	event := <-second
+	assert.Greater(t, event.ID, first.ID)
+	events, err = c.EventsSince(ctx, first.ID, 10)
+	assert.Nil(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, event.ID, events[0].ID)
+	}
+
+}
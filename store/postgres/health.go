@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// Ping checks the database can be reached
+func (c *Client) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+// SchemaVersion returns the version of the last applied migration, 0 if none have been applied, and if it failed
+func (c *Client) SchemaVersion(ctx context.Context) (version uint, dirty bool, err error) {
+
+	table := fmt.Sprintf(`"%s"."schema_migrations"`, c.schema)
+	var exists bool
+	if err := c.db.GetContext(ctx, &exists, `SELECT to_regclass($1) IS NOT NULL`, table); err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var row struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	if err := c.db.GetContext(ctx, &row, `SELECT version, dirty FROM `+table+` LIMIT 1`); errors.Is(err, sql.ErrNoRows) || (err == nil && row.Version < 0) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	return uint(row.Version), row.Dirty, nil
+
+}
+
+// LatestMigration returns the version of the last migration of the source
+func LatestMigration(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	for err == nil {
+		var next uint
+		if next, err = src.Next(version); err == nil {
+			version = next
+		}
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	return version, nil
+}
+
+// MigrationsCheck returns a health check that fails while the last migration failed or there are migrations of the
+// source that haven't been applied
+func (c *Client) MigrationsCheck(src source.Driver) (func(ctx context.Context) error, error) {
+
+	latest, err := LatestMigration(src)
+	if err != nil {
+		return nil, fmt.Errorf("could not read migrations: %w", err)
+	}
+
+	return func(ctx context.Context) error {
+		version, dirty, err := c.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed", version)
+		}
+		if version < latest {
+			return fmt.Errorf("schema version %d is behind %d", version, latest)
+		}
+		return nil
+	}, nil
+
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/snowzach/golib/store/driver/postgres"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+const idempotencyFields = `key, created, expires, fingerprint, completed, status_code, content_type, body`
+
+// IdempotencyClaim claims the key for a new request or returns the unexpired record holding it
+func (c *Client) IdempotencyClaim(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*gorestapi.IdempotencyRecord, bool, error) {
+
+	// Insert a new pending record, taking over the key if the existing record has expired.
+	var record = new(gorestapi.IdempotencyRecord)
+	err := c.db.GetContext(ctx, record, `
+		INSERT INTO idempotency (key, created, expires, fingerprint)
+		VALUES ($1, NOW(), NOW() + make_interval(secs => $3), $2)
+		ON CONFLICT (key) DO UPDATE SET
+			created = EXCLUDED.created,
+			expires = EXCLUDED.expires,
+			fingerprint = EXCLUDED.fingerprint,
+			completed = false,
+			status_code = 0,
+			content_type = '',
+			body = NULL
+		WHERE idempotency.expires < NOW()
+		RETURNING `+idempotencyFields, key, fingerprint, ttl.Seconds())
+	if err == nil {
+		return record, true, nil
+	} else if err != sql.ErrNoRows {
+		return nil, false, postgres.WrapError(err)
+	}
+
+	// The key is held by another record.
+	err = c.db.GetContext(ctx, record, `SELECT `+idempotencyFields+` FROM idempotency WHERE key = $1`, key)
+	if err != nil {
+		return nil, false, postgres.WrapError(err)
+	}
+	return record, false, nil
+
+}
+
+// IdempotencyComplete stores the response for a claimed key
+func (c *Client) IdempotencyComplete(ctx context.Context, record *gorestapi.IdempotencyRecord) error {
+	_, err := c.db.ExecContext(ctx, `UPDATE idempotency SET completed = true, status_code = $2, content_type = $3, body = $4 WHERE key = $1`,
+		record.Key, record.StatusCode, record.ContentType, record.Body)
+	return postgres.WrapError(err)
+}
+
+// IdempotencyRelease deletes a pending key so the request can be retried
+func (c *Client) IdempotencyRelease(ctx context.Context, key string) error {
+	_, err := c.db.ExecContext(ctx, `DELETE FROM idempotency WHERE key = $1 AND completed = false`, key)
+	return postgres.WrapError(err)
+}
+
+// IdempotencyPurgeExpired deletes all expired keys
+func (c *Client) IdempotencyPurgeExpired(ctx context.Context) (int64, error) {
+	result, err := c.db.ExecContext(ctx, `DELETE FROM idempotency WHERE expires < NOW()`)
+	if err != nil {
+		return 0, postgres.WrapError(err)
+	}
+	return result.RowsAffected()
+}
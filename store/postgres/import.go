@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// ThingsSave saves the records in a single transaction
+func (c *Client) ThingsSave(ctx context.Context, records []*gorestapi.Thing) error {
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		for _, record := range records {
+			if err := c.thingSave(ctx, tx, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WidgetsSave saves the records in a single transaction
+func (c *Client) WidgetsSave(ctx context.Context, records []*gorestapi.Widget) error {
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		for _, record := range records {
+			if err := c.widgetSave(ctx, tx, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepostgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/snowzach/golib/store/driver/postgres"
+)
+
+// Migration directions
+const (
+	MigrationUp   = "up"
+	MigrationDown = "down"
+)
+
+// Migration is a migration that would be run
+type Migration struct {
+	Version   uint
+	Name      string
+	Direction string
+	SQL       string
+}
+
+// MigrationStatus is a migration of the source and whether it has been applied
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Migrator manages the database schema with the migrations of the source
+type Migrator struct {
+	source  source.Driver
+	migrate *migrate.Migrate
+}
+
+// migrateLogger logs the migrations as they run
+type migrateLogger struct {
+	Logger postgres.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...any) { l.Logger.Printf(format, v...) }
+func (l migrateLogger) Verbose() bool                  { return false }
+
+// Migrator returns a migrator for the database of the config using the migration source of the config
+func (c *Client) Migrator(cfg *Config) (*Migrator, error) {
+
+	if cfg.MigrationSource == nil {
+		return nil, errors.New("no migration source")
+	}
+	databaseDriver, err := migratepostgres.WithInstance(c.db.DB, &migratepostgres.Config{
+		SchemaName:   cfg.Schema,
+		DatabaseName: cfg.Database,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create migrations database instance: %w", err)
+	}
+	m, err := migrate.NewWithInstance("source", cfg.MigrationSource, "pgx", databaseDriver)
+	if err != nil {
+		return nil, fmt.Errorf("could not create migrations instance: %w", err)
+	}
+	if cfg.Logger != nil {
+		m.Log = migrateLogger{Logger: cfg.Logger}
+	}
+
+	return &Migrator{source: cfg.MigrationSource, migrate: m}, nil
+
+}
+
+// Close closes the migrator and the database
+func (m *Migrator) Close() error {
+	sourceErr, databaseErr := m.migrate.Close()
+	return errors.Join(sourceErr, databaseErr)
+}
+
+// Version returns the current version of the schema, 0 if no migrations have been applied. Dirty is true if the
+// last migration failed and the version must be forced after fixing the schema.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.migrate.Version()
+	if err == migrate.ErrNilVersion {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Status returns every migration of the source and whether it has been applied
+func (m *Migrator) Status() ([]*MigrationStatus, error) {
+
+	current, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*MigrationStatus
+	version, err := m.source.First()
+	for err == nil {
+		var name string
+		if name, err = m.name(version); err != nil {
+			return nil, err
+		}
+		ret = append(ret, &MigrationStatus{Version: version, Name: name, Applied: version <= current})
+		version, err = m.source.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return ret, nil
+
+}
+
+// Up applies every migration that hasn't been applied
+func (m *Migrator) Up() error {
+	return noChange(m.migrate.Up())
+}
+
+// Down reverts the last n migrations
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return errors.New("number of migrations to revert must be positive")
+	}
+	return noChange(m.migrate.Steps(-n))
+}
+
+// Goto migrates up or down to the version
+func (m *Migrator) Goto(version uint) error {
+	return noChange(m.migrate.Migrate(version))
+}
+
+// Force sets the version without running any migrations and clears the dirty flag. A version of -1 means no
+// migrations have been applied.
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+// PlanUp returns the migrations Up would run
+func (m *Migrator) PlanUp() ([]*Migration, error) {
+	return m.planUp(func(version uint) bool { return true })
+}
+
+// PlanDown returns the migrations Down would run
+func (m *Migrator) PlanDown(n int) ([]*Migration, error) {
+	if n <= 0 {
+		return nil, errors.New("number of migrations to revert must be positive")
+	}
+	return m.planDown(func(version uint) bool { return true }, n)
+}
+
+// PlanGoto returns the migrations Goto would run
+func (m *Migrator) PlanGoto(target uint) ([]*Migration, error) {
+	if _, err := m.name(target); err != nil {
+		return nil, err
+	}
+	current, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	if target >= current {
+		return m.planUp(func(version uint) bool { return version <= target })
+	}
+	return m.planDown(func(version uint) bool { return version > target }, -1)
+}
+
+// planUp returns the migrations after the current version while they match
+func (m *Migrator) planUp(match func(version uint) bool) ([]*Migration, error) {
+
+	current, err := m.clean()
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*Migration
+	version, err := m.source.First()
+	if current > 0 {
+		version, err = m.source.Next(current)
+	}
+	for err == nil && match(version) {
+		var migration *Migration
+		if migration, err = m.read(version, MigrationUp); err != nil {
+			return nil, err
+		}
+		ret = append(ret, migration)
+		version, err = m.source.Next(version)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return ret, nil
+
+}
+
+// planDown returns at most n migrations (-1 for no limit) from the current version down while they match
+func (m *Migrator) planDown(match func(version uint) bool, n int) ([]*Migration, error) {
+
+	current, err := m.clean()
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*Migration
+	for version := current; version > 0 && match(version) && (n < 0 || len(ret) < n); {
+		migration, err := m.read(version, MigrationDown)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, migration)
+		if version, err = m.source.Prev(version); errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+
+}
+
+// clean returns the current version if the database isn't dirty
+func (m *Migrator) clean() (uint, error) {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d, fix the schema and force the version", current)
+	}
+	return current, nil
+}
+
+// read reads the migration in the direction from the source
+func (m *Migrator) read(version uint, direction string) (*Migration, error) {
+
+	var r io.ReadCloser
+	var name string
+	var err error
+	if direction == MigrationUp {
+		r, name, err = m.source.ReadUp(version)
+	} else {
+		r, name, err = m.source.ReadDown(version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read migration %d %s: %w", version, direction, err)
+	}
+	defer r.Close()
+
+	sql, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read migration %d %s: %w", version, direction, err)
+	}
+	return &Migration{Version: version, Name: name, Direction: direction, SQL: string(sql)}, nil
+
+}
+
+// name returns the name of the migration from its up file
+func (m *Migrator) name(version uint) (string, error) {
+	r, name, err := m.source.ReadUp(version)
+	if err != nil {
+		return "", fmt.Errorf("could not read migration %d: %w", version, err)
+	}
+	r.Close()
+	return name, nil
+}
+
+// noChange ignores the error when there are no migrations to run
+func noChange(err error) error {
+	if err == migrate.ErrNoChange {
+		return nil
+	}
+	return err
+}
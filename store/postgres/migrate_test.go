@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSource is a migration source of the versions
+type fakeSource struct {
+	versions []uint
+}
+
+func (s *fakeSource) Open(url string) (source.Driver, error) { return s, nil }
+func (s *fakeSource) Close() error                           { return nil }
+
+func (s *fakeSource) First() (uint, error) {
+	if len(s.versions) == 0 {
+		return 0, os.ErrNotExist
+	}
+	return s.versions[0], nil
+}
+
+func (s *fakeSource) Prev(version uint) (uint, error) {
+	for i := len(s.versions) - 1; i >= 0; i-- {
+		if s.versions[i] < version {
+			return s.versions[i], nil
+		}
+	}
+	return 0, os.ErrNotExist
+}
+
+func (s *fakeSource) Next(version uint) (uint, error) {
+	for _, v := range s.versions {
+		if v > version {
+			return v, nil
+		}
+	}
+	return 0, os.ErrNotExist
+}
+
+func (s *fakeSource) ReadUp(version uint) (io.ReadCloser, string, error) {
+	return s.read(version, MigrationUp)
+}
+
+func (s *fakeSource) ReadDown(version uint) (io.ReadCloser, string, error) {
+	return s.read(version, MigrationDown)
+}
+
+func (s *fakeSource) read(version uint, direction string) (io.ReadCloser, string, error) {
+	for _, v := range s.versions {
+		if v == version {
+			return io.NopCloser(strings.NewReader(fmt.Sprintf("-- %d %s", version, direction))), fmt.Sprintf("migration_%d", version), nil
+		}
+	}
+	return nil, "", os.ErrNotExist
+}
+
+// fakeDatabase is a database at a version that runs nothing
+type fakeDatabase struct {
+	version int
+	dirty   bool
+}
+
+func (d *fakeDatabase) Open(url string) (database.Driver, error) { return d, nil }
+func (d *fakeDatabase) Close() error                             { return nil }
+func (d *fakeDatabase) Lock() error                              { return nil }
+func (d *fakeDatabase) Unlock() error                            { return nil }
+func (d *fakeDatabase) Run(migration io.Reader) error            { return nil }
+func (d *fakeDatabase) Drop() error                              { return nil }
+func (d *fakeDatabase) Version() (int, bool, error)              { return d.version, d.dirty, nil }
+func (d *fakeDatabase) SetVersion(version int, dirty bool) error {
+	d.version, d.dirty = version, dirty
+	return nil
+}
+
+func newTestMigrator(t *testing.T, version int, dirty bool) *Migrator {
+	src := &fakeSource{versions: []uint{1, 2, 3, 5}}
+	m, err := migrate.NewWithInstance("fake", src, "fake", &fakeDatabase{version: version, dirty: dirty})
+	assert.Nil(t, err)
+	return &Migrator{source: src, migrate: m}
+}
+
+// migrationVersions returns the versions and directions of the migrations
+func migrationVersions(migrations []*Migration) []string {
+	ret := []string{}
+	for _, migration := range migrations {
+		ret = append(ret, fmt.Sprintf("%d %s", migration.Version, migration.Direction))
+	}
+	return ret
+}
+
+func TestMigratorPlanUp(t *testing.T) {
+
+	migrations, err := newTestMigrator(t, database.NilVersion, false).PlanUp()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1 up", "2 up", "3 up", "5 up"}, migrationVersions(migrations))
+	assert.Equal(t, "migration_1", migrations[0].Name)
+	assert.Equal(t, "-- 1 up", migrations[0].SQL)
+
+	migrations, err = newTestMigrator(t, 2, false).PlanUp()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3 up", "5 up"}, migrationVersions(migrations))
+
+	migrations, err = newTestMigrator(t, 5, false).PlanUp()
+	assert.Nil(t, err)
+	assert.Empty(t, migrations)
+
+}
+
+func TestMigratorPlanDown(t *testing.T) {
+
+	migrations, err := newTestMigrator(t, 5, false).PlanDown(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"5 down", "3 down"}, migrationVersions(migrations))
+	assert.Equal(t, "-- 5 down", migrations[0].SQL)
+
+	// More than were applied reverts them all
+	migrations, err = newTestMigrator(t, 3, false).PlanDown(10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3 down", "2 down", "1 down"}, migrationVersions(migrations))
+
+	migrations, err = newTestMigrator(t, database.NilVersion, false).PlanDown(1)
+	assert.Nil(t, err)
+	assert.Empty(t, migrations)
+
+	_, err = newTestMigrator(t, 5, false).PlanDown(0)
+	assert.NotNil(t, err)
+
+}
+
+func TestMigratorPlanGoto(t *testing.T) {
+
+	// Down stops before the target
+	migrations, err := newTestMigrator(t, 5, false).PlanGoto(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"5 down", "3 down", "2 down"}, migrationVersions(migrations))
+
+	// Up stops at the target
+	migrations, err = newTestMigrator(t, 1, false).PlanGoto(3)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2 up", "3 up"}, migrationVersions(migrations))
+
+	migrations, err = newTestMigrator(t, 3, false).PlanGoto(3)
+	assert.Nil(t, err)
+	assert.Empty(t, migrations)
+
+	// The target must be a migration of the source
+	_, err = newTestMigrator(t, 5, false).PlanGoto(4)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+}
+
+func TestMigratorPlanDirty(t *testing.T) {
+
+	m := newTestMigrator(t, 3, true)
+
+	_, err := m.PlanUp()
+	assert.ErrorContains(t, err, "database is dirty at version 3")
+
+	_, err = m.PlanDown(1)
+	assert.ErrorContains(t, err, "database is dirty at version 3")
+
+	_, err = m.PlanGoto(1)
+	assert.ErrorContains(t, err, "database is dirty at version 3")
+
+	_, err = m.PlanGoto(5)
+	assert.ErrorContains(t, err, "database is dirty at version 3")
+
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"github.com/snowzach/golib/store/driver/postgres"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+var (
+	OutboxTable = postgres.Generate(postgres.Table[gorestapi.OutboxMessage]{
+		Table: `"outbox"`,
+		Fields: []*postgres.Field[gorestapi.OutboxMessage]{
+			{Name: "id", ID: true, Value: func(rec *gorestapi.OutboxMessage) (driver.Value, error) { return rec.ID, nil }},
+			{Name: "created"},
+			{Name: "updated", Update: "NOW()"},
+			{Name: "event_id"},
+			{Name: "event"},
+			{Name: "payload"},
+			{Name: "status", Update: "$#", Value: func(rec *gorestapi.OutboxMessage) (driver.Value, error) { return rec.Status, nil }},
+			{Name: "attempts", Update: "$#", Value: func(rec *gorestapi.OutboxMessage) (driver.Value, error) { return rec.Attempts, nil }},
+			{Name: "next_attempt", Update: "$#", Value: func(rec *gorestapi.OutboxMessage) (driver.Value, error) { return rec.NextAttempt, nil }},
+			{Name: "last_error", Update: "$#", Value: func(rec *gorestapi.OutboxMessage) (driver.Value, error) { return rec.LastError, nil }},
+		},
+	})
+)
+
+// OutboxClaim returns pending messages that are due in ID order and pushes back their next attempt
+// by lease so concurrent callers do not claim the same messages.
+func (c *Client) OutboxClaim(ctx context.Context, limit int, lease time.Duration) ([]*gorestapi.OutboxMessage, error) {
+	var records = make([]*gorestapi.OutboxMessage, 0)
+	err := c.db.SelectContext(ctx, &records, `
+		WITH "outbox" AS (
+			UPDATE outbox SET next_attempt = NOW() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM outbox
+				WHERE status = 'pending' AND next_attempt <= NOW()
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		) SELECT `+OutboxTable.SelectFields+` FROM "outbox" ORDER BY id`, limit, lease.Seconds())
+	if err != nil {
+		return nil, postgres.WrapError(err)
+	}
+	return records, nil
+}
+
+// OutboxMessageSave updates the delivery state of the record
+func (c *Client) OutboxMessageSave(ctx context.Context, record *gorestapi.OutboxMessage) error {
+	return OutboxTable.Update(ctx, c.db, record)
+}
+
+// OutboxPurge deletes delivered messages last updated before the given time
+func (c *Client) OutboxPurge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := c.db.ExecContext(ctx, `DELETE FROM outbox WHERE status = 'delivered' AND updated < $1`, before)
+	if err != nil {
+		return 0, postgres.WrapError(err)
+	}
+	return result.RowsAffected()
+}
+
+// outboxEnqueue adds the event to the outbox
+func outboxEnqueue(ctx context.Context, db postgres.DB, event *gorestapi.Event) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO outbox (event_id, event, payload) VALUES ($1, $2, $3)`,
+		event.ID, event.Name(), event.String())
+	return postgres.WrapError(err)
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/snowzach/golib/store/driver/postgres"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+// ThingsRestore creates or replaces the records with their timestamps in a single transaction. Every record gets an
+// event in the same transaction like any other save.
+func (c *Client) ThingsRestore(ctx context.Context, records []*gorestapi.Thing) error {
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		for _, record := range records {
+			var inserted bool
+			if err := tx.QueryRowxContext(ctx, `
+				INSERT INTO thing (id, created, updated, name, description) VALUES ($1, COALESCE($2, NOW()), COALESCE($3, NOW()), $4, $5)
+				ON CONFLICT (id) DO UPDATE SET created = EXCLUDED.created, updated = EXCLUDED.updated, name = EXCLUDED.name, description = EXCLUDED.description
+				RETURNING created, updated, xmax = 0`,
+				record.ID, restoreTime(record.Created), restoreTime(record.Updated), record.Name, record.Description,
+			).Scan(&record.Created, &record.Updated, &inserted); err != nil {
+				return postgres.WrapError(err)
+			}
+			if _, err := c.eventSave(ctx, tx, restoreEventType(inserted), gorestapi.EventResourceThing, record.ID, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WidgetsRestore creates or replaces the records with their timestamps in a single transaction. Every record gets an
+// event in the same transaction like any other save.
+func (c *Client) WidgetsRestore(ctx context.Context, records []*gorestapi.Widget) error {
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		for _, record := range records {
+			var inserted bool
+			if err := tx.QueryRowxContext(ctx, `
+				INSERT INTO widget (id, created, updated, name, description, thing_id) VALUES ($1, COALESCE($2, NOW()), COALESCE($3, NOW()), $4, $5, $6)
+				ON CONFLICT (id) DO UPDATE SET created = EXCLUDED.created, updated = EXCLUDED.updated, name = EXCLUDED.name, description = EXCLUDED.description, thing_id = EXCLUDED.thing_id
+				RETURNING created, updated, xmax = 0`,
+				record.ID, restoreTime(record.Created), restoreTime(record.Updated), record.Name, record.Description, record.ThingID,
+			).Scan(&record.Created, &record.Updated, &inserted); err != nil {
+				return postgres.WrapError(err)
+			}
+			if _, err := c.eventSave(ctx, tx, restoreEventType(inserted), gorestapi.EventResourceWidget, record.ID, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// restoreEventType is created for new records, xmax is only zero for rows that were inserted rather than updated
+func restoreEventType(inserted bool) string {
+	if inserted {
+		return gorestapi.EventTypeCreated
+	}
+	return gorestapi.EventTypeUpdated
+}
+
+// restoreTime is nil for the zero time so the database sets it
+func restoreTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+func TestRestoreEvents(t *testing.T) {
+
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	since, err := c.EventsLastID(ctx)
+	assert.Nil(t, err)
+
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	thing := &gorestapi.Thing{ID: "t1", Created: created, Updated: created, Name: "thing"}
+	assert.Nil(t, c.ThingsRestore(ctx, []*gorestapi.Thing{thing}))
+	thingID := "t1"
+	assert.Nil(t, c.WidgetsRestore(ctx, []*gorestapi.Widget{{ID: "w1", Name: "widget", ThingID: &thingID}}))
+
+	// Restoring again replaces the records
+	thing.Name = "restored"
+	assert.Nil(t, c.ThingsRestore(ctx, []*gorestapi.Thing{thing}))
+
+	events, err := c.EventsSince(ctx, since, 10)
+	assert.Nil(t, err)
+	var names []string
+	for _, event := range events {
+		names = append(names, event.Name()+" "+event.ResourceID)
+	}
+	assert.Equal(t, []string{"thing.created t1", "widget.created w1", "thing.updated t1"}, names)
+
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/golib/store/driver/postgres"
+	"github.com/snowzach/queryp"
+	"github.com/snowzach/queryp/qppg"
+)
+
+// selectStream runs the selector query calling fn for every record as it is read from the database
+// rather than loading all the records into memory. It stops and returns the error if fn returns an error.
+func selectStream[T any](ctx context.Context, db sqlx.QueryerContext, s *postgres.Selector[T], qp *queryp.QueryParameters, fn func(*T) error) error {
+
+	var query strings.Builder
+	var queryParams []any
+
+	query.WriteString(s.Query)
+
+	sort := qp.Sort
+	if len(sort) == 0 {
+		sort = s.DefaultSort
+	}
+
+	if len(qp.Filter) > 0 {
+		query.WriteString(" WHERE ")
+	}
+	if err := qppg.FilterQuery(s.FilterFieldTypes, qp.Filter, &query, &queryParams); err != nil {
+		return &store.Error{Type: store.ErrorTypeQuery, Err: err}
+	}
+	if err := qppg.SortQuery(s.SortFields, sort, &query, &queryParams); err != nil {
+		return &store.Error{Type: store.ErrorTypeQuery, Err: err}
+	}
+	if qp.Limit > 0 {
+		query.WriteString(" LIMIT " + strconv.FormatInt(qp.Limit, 10))
+	}
+	if qp.Offset > 0 {
+		query.WriteString(" OFFSET " + strconv.FormatInt(qp.Offset, 10))
+	}
+
+	rows, err := db.QueryxContext(ctx, query.String(), queryParams...)
+	if err != nil {
+		return postgres.WrapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record = new(T)
+		if err := rows.StructScan(record); err != nil {
+			return postgres.WrapError(err)
+		}
+		if s.PostProcessRecord != nil {
+			if err := s.PostProcessRecord(record); err != nil {
+				return fmt.Errorf("post process record error: %w", err)
+			}
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	return postgres.WrapError(rows.Err())
+
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql/driver"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/xid"
 	"github.com/snowzach/golib/store/driver/postgres"
 	"github.com/snowzach/queryp"
@@ -45,23 +46,55 @@ var (
 
 // ThingSave saves the record
 func (c *Client) ThingSave(ctx context.Context, record *gorestapi.Thing) error {
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		return c.thingSave(ctx, tx, record)
+	})
+}
+
+// thingSave upserts the record and saves its event in the transaction
+func (c *Client) thingSave(ctx context.Context, tx *sqlx.Tx, record *gorestapi.Thing) error {
 	if record.ID == "" {
 		record.ID = xid.New().String()
 	}
-	return ThingTable.Upsert(ctx, c.db, record)
+	if err := ThingTable.Upsert(ctx, tx, record); err != nil {
+		return err
+	}
+	_, err := c.eventSave(ctx, tx, eventTypeForSave(record.Created, record.Updated), gorestapi.EventResourceThing, record.ID, record)
+	return err
 }
 
 // ThingGetByID returns the the record by id
 func (c *Client) ThingGetByID(ctx context.Context, id string) (*gorestapi.Thing, error) {
-	return ThingTable.GetByID(ctx, c.db, id)
+	return ThingTable.GetByID(ctx, c.conn(ctx), id)
 }
 
 // ThingDeleteByID deletes a record by id
 func (c *Client) ThingDeleteByID(ctx context.Context, id string) error {
-	return ThingTable.DeleteByID(ctx, c.db, id)
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		// Delete the widgets that belong to the thing first so they get events too
+		var widgetIDs []string
+		if err := tx.SelectContext(ctx, &widgetIDs, `DELETE FROM widget WHERE thing_id = $1 RETURNING id`, id); err != nil {
+			return postgres.WrapError(err)
+		}
+		for _, widgetID := range widgetIDs {
+			if _, err := c.eventSave(ctx, tx, gorestapi.EventTypeDeleted, gorestapi.EventResourceWidget, widgetID, nil); err != nil {
+				return err
+			}
+		}
+		if err := ThingTable.DeleteByID(ctx, tx, id); err != nil {
+			return err
+		}
+		_, err := c.eventSave(ctx, tx, gorestapi.EventTypeDeleted, gorestapi.EventResourceThing, id, nil)
+		return err
+	})
 }
 
 // ThingsFind fetches records with filter and pagination
 func (c *Client) ThingsFind(ctx context.Context, qp *queryp.QueryParameters) ([]*gorestapi.Thing, *int64, error) {
-	return ThingTable.Selector.Select(ctx, c.db, qp)
+	return ThingTable.Selector.Select(ctx, c.conn(ctx), qp)
+}
+
+// ThingsStream calls fn for every record matching the filter as it is read from the database
+func (c *Client) ThingsStream(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Thing) error) error {
+	return selectStream(ctx, c.conn(ctx), &ThingTable.Selector, qp, fn)
 }
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/xid"
+	"github.com/snowzach/golib/store"
+	"github.com/snowzach/golib/store/driver/postgres"
+	"github.com/snowzach/queryp"
+
+	"github.com/snowzach/gorestapi/gorestapi"
+)
+
+var (
+	WebhookTable = postgres.Generate(postgres.Table[gorestapi.Webhook]{
+		Table: `"webhook"`,
+		Fields: []*postgres.Field[gorestapi.Webhook]{
+			{Name: "id", ID: true, Insert: "$#", Value: func(rec *gorestapi.Webhook) (driver.Value, error) { return rec.ID, nil }},
+			{Name: "created", Insert: "NOW()", NullVal: "0001-01-01 00:00:00 UTC"},
+			{Name: "updated", Insert: "NOW()", Update: "NOW()", NullVal: "0001-01-01 00:00:00 UTC"},
+			{Name: "url", Insert: "$#", Update: "$#", Value: func(rec *gorestapi.Webhook) (driver.Value, error) { return rec.URL, nil }},
+			{Name: "events", Insert: "$#", Update: "$#", Value: func(rec *gorestapi.Webhook) (driver.Value, error) { return rec.Events.Value() }},
+			{Name: "secret", Insert: "$#", Update: "$#", Value: func(rec *gorestapi.Webhook) (driver.Value, error) { return rec.Secret, nil }},
+		},
+		Selector: postgres.Selector[gorestapi.Webhook]{
+			FilterFieldTypes: queryp.FilterFieldTypes{
+				"webhook.id":      queryp.FilterTypeSimple,
+				"webhook.created": queryp.FilterTypeTime,
+				"webhook.updated": queryp.FilterTypeTime,
+				"webhook.url":     queryp.FilterTypeString,
+			},
+			SortFields: queryp.SortFields{
+				"webhook.id":      "",
+				"webhook.created": "",
+				"webhook.updated": "",
+				"webhook.url":     "",
+			},
+			DefaultSort: queryp.Sort{
+				&queryp.SortTerm{Field: "webhook.created", Desc: false},
+			},
+		},
+	})
+
+	WebhookDeliveryTable = postgres.Generate(postgres.Table[gorestapi.WebhookDelivery]{
+		Table: `"webhook_delivery"`,
+		Fields: []*postgres.Field[gorestapi.WebhookDelivery]{
+			{Name: "id", ID: true, Value: func(rec *gorestapi.WebhookDelivery) (driver.Value, error) { return rec.ID, nil }},
+			{Name: "created"},
+			{Name: "updated", Update: "NOW()"},
+			{Name: "webhook_id"},
+			{Name: "event_id"},
+			{Name: "event"},
+			{Name: "payload"},
+			{Name: "status", Update: "$#", Value: func(rec *gorestapi.WebhookDelivery) (driver.Value, error) { return rec.Status, nil }},
+			{Name: "attempts", Update: "$#", Value: func(rec *gorestapi.WebhookDelivery) (driver.Value, error) { return rec.Attempts, nil }},
+			{Name: "next_attempt", Update: "$#", Value: func(rec *gorestapi.WebhookDelivery) (driver.Value, error) { return rec.NextAttempt, nil }},
+			{Name: "last_status_code", Update: "$#", Value: func(rec *gorestapi.WebhookDelivery) (driver.Value, error) { return rec.LastStatusCode, nil }},
+			{Name: "last_error", Update: "$#", Value: func(rec *gorestapi.WebhookDelivery) (driver.Value, error) { return rec.LastError, nil }},
+		},
+		Selector: postgres.Selector[gorestapi.WebhookDelivery]{
+			FilterFieldTypes: queryp.FilterFieldTypes{
+				"webhook_delivery.id":           queryp.FilterTypeNumeric,
+				"webhook_delivery.created":      queryp.FilterTypeTime,
+				"webhook_delivery.updated":      queryp.FilterTypeTime,
+				"webhook_delivery.webhook_id":   queryp.FilterTypeSimple,
+				"webhook_delivery.event_id":     queryp.FilterTypeNumeric,
+				"webhook_delivery.event":        queryp.FilterTypeString,
+				"webhook_delivery.status":       queryp.FilterTypeString,
+				"webhook_delivery.attempts":     queryp.FilterTypeNumeric,
+				"webhook_delivery.next_attempt": queryp.FilterTypeTime,
+			},
+			SortFields: queryp.SortFields{
+				"webhook_delivery.id":           "",
+				"webhook_delivery.created":      "",
+				"webhook_delivery.updated":      "",
+				"webhook_delivery.event":        "",
+				"webhook_delivery.status":       "",
+				"webhook_delivery.attempts":     "",
+				"webhook_delivery.next_attempt": "",
+			},
+			DefaultSort: queryp.Sort{
+				&queryp.SortTerm{Field: "webhook_delivery.id", Desc: true},
+			},
+		},
+	})
+)
+
+// WebhookSave saves the record. A secret is generated for new webhooks if one is not provided.
+func (c *Client) WebhookSave(ctx context.Context, record *gorestapi.Webhook) error {
+	if record.ID == "" {
+		record.ID = xid.New().String()
+	}
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		if record.Secret == "" {
+			existing, err := WebhookTable.GetByID(ctx, tx, record.ID)
+			if err == nil {
+				record.Secret = existing.Secret
+			} else if err == store.ErrNotFound {
+				if record.Secret, err = newWebhookSecret(); err != nil {
+					return err
+				}
+			} else {
+				return err
+			}
+		}
+		return WebhookTable.Upsert(ctx, tx, record)
+	})
+}
+
+// WebhookGetByID returns the the record by id
+func (c *Client) WebhookGetByID(ctx context.Context, id string) (*gorestapi.Webhook, error) {
+	return WebhookTable.GetByID(ctx, c.conn(ctx), id)
+}
+
+// WebhookDeleteByID deletes a record by id
+func (c *Client) WebhookDeleteByID(ctx context.Context, id string) error {
+	return WebhookTable.DeleteByID(ctx, c.conn(ctx), id)
+}
+
+// WebhooksFind fetches records with filter and pagination
+func (c *Client) WebhooksFind(ctx context.Context, qp *queryp.QueryParameters) ([]*gorestapi.Webhook, *int64, error) {
+	return WebhookTable.Selector.Select(ctx, c.conn(ctx), qp)
+}
+
+// WebhookDeliveryGetByID returns the the record by id
+func (c *Client) WebhookDeliveryGetByID(ctx context.Context, id int64) (*gorestapi.WebhookDelivery, error) {
+	return WebhookDeliveryTable.GetByID(ctx, c.conn(ctx), id)
+}
+
+// WebhookDeliverySave updates the delivery state of the record
+func (c *Client) WebhookDeliverySave(ctx context.Context, record *gorestapi.WebhookDelivery) error {
+	return WebhookDeliveryTable.Update(ctx, c.conn(ctx), record)
+}
+
+// WebhookDeliveriesFind fetches records with filter and pagination
+func (c *Client) WebhookDeliveriesFind(ctx context.Context, qp *queryp.QueryParameters) ([]*gorestapi.WebhookDelivery, *int64, error) {
+	return WebhookDeliveryTable.Selector.Select(ctx, c.conn(ctx), qp)
+}
+
+// WebhookDeliveriesPurge deletes delivered and dead deliveries last updated before the given time
+func (c *Client) WebhookDeliveriesPurge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := c.db.ExecContext(ctx, `DELETE FROM webhook_delivery WHERE status IN ('delivered', 'dead') AND updated < $1`, before)
+	if err != nil {
+		return 0, postgres.WrapError(err)
+	}
+	return result.RowsAffected()
+}
+
+// WebhookDeliveriesClaim returns pending deliveries that are due and pushes back their next attempt
+// by lease so concurrent callers do not claim the same deliveries.
+func (c *Client) WebhookDeliveriesClaim(ctx context.Context, limit int, lease time.Duration) ([]*gorestapi.WebhookDelivery, error) {
+	var records = make([]*gorestapi.WebhookDelivery, 0)
+	err := c.db.SelectContext(ctx, &records, `
+		WITH "webhook_delivery" AS (
+			UPDATE webhook_delivery SET next_attempt = NOW() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM webhook_delivery
+				WHERE status = 'pending' AND next_attempt <= NOW()
+				ORDER BY next_attempt
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		) SELECT `+WebhookDeliveryTable.SelectFields+` FROM "webhook_delivery" ORDER BY id`, limit, lease.Seconds())
+	if err != nil {
+		return nil, postgres.WrapError(err)
+	}
+	return records, nil
+}
+
+// webhookDeliveriesEnqueue creates pending deliveries of the event for every webhook subscribed to it
+func webhookDeliveriesEnqueue(ctx context.Context, db postgres.DB, event *gorestapi.Event) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO webhook_delivery (webhook_id, event_id, event, payload)
+		SELECT id, $1, $2, $3 FROM webhook
+		WHERE events ? $2 OR events ? '*' OR events ? $4 OR events ? $5`,
+		event.ID, event.Name(), event.String(), event.Resource+".*", "*."+event.Type)
+	return postgres.WrapError(err)
+}
+
+// newWebhookSecret generates a random secret for signing payloads
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql/driver"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/rs/xid"
 	"github.com/snowzach/queryp"
 
@@ -32,6 +33,7 @@ var (
 				"widget.updated":     queryp.FilterTypeTime,
 				"widget.name":        queryp.FilterTypeString,
 				"widget.description": queryp.FilterTypeString,
+				"widget.thing_id":    queryp.FilterTypeSimple,
 				"thing.name":         queryp.FilterTypeString,
 				"thing.description":  queryp.FilterTypeString,
 			},
@@ -41,6 +43,7 @@ var (
 				"widget.updated":     "",
 				"widget.name":        "",
 				"widget.description": "",
+				"widget.thing_id":    "",
 				"thing.name":         "",
 				"thing.description":  "",
 			},
@@ -66,23 +69,45 @@ var (
 
 // WidgetSave saves the record
 func (c *Client) WidgetSave(ctx context.Context, record *gorestapi.Widget) error {
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		return c.widgetSave(ctx, tx, record)
+	})
+}
+
+// widgetSave upserts the record and saves its event in the transaction
+func (c *Client) widgetSave(ctx context.Context, tx *sqlx.Tx, record *gorestapi.Widget) error {
 	if record.ID == "" {
 		record.ID = xid.New().String()
 	}
-	return WidgetTable.Upsert(ctx, c.db, record)
+	if err := WidgetTable.Upsert(ctx, tx, record); err != nil {
+		return err
+	}
+	_, err := c.eventSave(ctx, tx, eventTypeForSave(record.Created, record.Updated), gorestapi.EventResourceWidget, record.ID, record)
+	return err
 }
 
 // WidgetGetByID returns the the record by id
 func (c *Client) WidgetGetByID(ctx context.Context, id string) (*gorestapi.Widget, error) {
-	return WidgetTable.GetByID(ctx, c.db, id)
+	return WidgetTable.GetByID(ctx, c.conn(ctx), id)
 }
 
 // WidgetDeleteByID deletes a record by id
 func (c *Client) WidgetDeleteByID(ctx context.Context, id string) error {
-	return WidgetTable.DeleteByID(ctx, c.db, id)
+	return c.transact(ctx, func(tx *sqlx.Tx) error {
+		if err := WidgetTable.DeleteByID(ctx, tx, id); err != nil {
+			return err
+		}
+		_, err := c.eventSave(ctx, tx, gorestapi.EventTypeDeleted, gorestapi.EventResourceWidget, id, nil)
+		return err
+	})
 }
 
 // WidgetsFind fetches records with filter and pagination
 func (c *Client) WidgetsFind(ctx context.Context, qp *queryp.QueryParameters) ([]*gorestapi.Widget, *int64, error) {
-	return WidgetTable.Selector.Select(ctx, c.db, qp)
+	return WidgetTable.Selector.Select(ctx, c.conn(ctx), qp)
+}
+
+// WidgetsStream calls fn for every record matching the filter as it is read from the database
+func (c *Client) WidgetsStream(ctx context.Context, qp *queryp.QueryParameters, fn func(*gorestapi.Widget) error) error {
+	return selectStream(ctx, c.conn(ctx), &WidgetTable.Selector, qp, fn)
 }
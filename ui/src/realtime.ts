@@ -0,0 +1,143 @@
+import { useEffect } from 'react';
+import { useRefresh } from 'react-admin';
+import { apiUrl } from './dataProvider';
+
+// Realtime updates from the /ws endpoint. A single WebSocket is shared by every subscription and
+// reconnected with backoff if it is closed. Subscribers are notified after a reconnect as events
+// may have been missed while disconnected.
+
+type RealtimeEvent = {
+    id: number
+    created: string
+    type: 'created' | 'updated' | 'deleted'
+    resource: string
+    resource_id: string
+    data?: any
+};
+
+type Subscription = {
+    resource: string
+    ids?: string[]
+    query?: string
+    callback: (event?: RealtimeEvent) => void
+};
+
+// eventResources maps react-admin resources to event resources
+const eventResources: { [index: string]: string } = {
+    things: 'thing',
+    widgets: 'widget',
+};
+
+// wsUrl builds the WebSocket URL relative to the API URL
+const wsUrl = (): string => {
+    const url = new URL(`${apiUrl}/ws`, window.location.href);
+    url.protocol = url.protocol === 'https:' ? 'wss:' : 'ws:';
+    return url.toString();
+}
+
+let socket: WebSocket | null = null;
+let nextId = 1;
+let reconnectDelay = 1000;
+let reconnectTimer: ReturnType<typeof setTimeout> | null = null;
+let reconnecting = false;
+const subscriptions = new Map<string, Subscription>();
+
+// send sends a message if the socket is open, otherwise it will be sent on connect
+const send = (message: any) => {
+    if (socket && socket.readyState === WebSocket.OPEN) {
+        socket.send(JSON.stringify(message));
+    }
+}
+
+const subscribeMessage = (id: string, sub: Subscription) => ({
+    type: 'subscribe',
+    id: id,
+    resource: sub.resource,
+    ids: sub.ids,
+    query: sub.query,
+});
+
+const connect = () => {
+    const ws = new WebSocket(wsUrl());
+    socket = ws;
+
+    ws.onopen = () => {
+        const reconnected = reconnecting;
+        reconnecting = false;
+        reconnectDelay = 1000;
+        subscriptions.forEach((sub, id) => {
+            send(subscribeMessage(id, sub));
+            if (reconnected) sub.callback();
+        });
+    };
+
+    ws.onmessage = (msg) => {
+        const message = JSON.parse(msg.data);
+        // Removed records no longer match the query of the subscription, the list refreshes either way
+        if (message.type === 'event' || message.type === 'removed') {
+            message.subscriptions.forEach((id: string) => {
+                subscriptions.get(id)?.callback(message.event);
+            });
+        } else if (message.type === 'error') {
+            console.warn('realtime error', message.id, message.error);
+        }
+    };
+
+    ws.onclose = () => {
+        if (socket !== ws) return;
+        socket = null;
+        if (subscriptions.size === 0) return;
+        reconnecting = true;
+        reconnectTimer = setTimeout(() => {
+            reconnectTimer = null;
+            connect();
+        }, reconnectDelay);
+        reconnectDelay = Math.min(reconnectDelay * 2, 30000);
+    };
+}
+
+// subscribe calls callback for every event on the react-admin resource, optionally limited to ids
+// and/or records matching a query. It returns a function to unsubscribe.
+export const subscribe = (resource: string, callback: (event?: RealtimeEvent) => void, ids?: string[], query?: string): (() => void) => {
+    const id = `s${nextId++}`;
+    const sub = { resource: eventResources[resource] || resource, ids, query, callback };
+    subscriptions.set(id, sub);
+    if (socket) {
+        send(subscribeMessage(id, sub));
+    } else if (!reconnectTimer) {
+        connect();
+    }
+
+    return () => {
+        subscriptions.delete(id);
+        send({ type: 'unsubscribe', id: id });
+        if (subscriptions.size === 0 && socket) {
+            const ws = socket;
+            socket = null;
+            ws.close();
+        }
+    };
+}
+
+// useRealtime refreshes the view when records of the resource change. Refreshes are batched so a
+// burst of changes only refreshes once.
+export const useRealtime = (resource: string, ids?: string[], query?: string) => {
+    const refresh = useRefresh();
+    const key = JSON.stringify([resource, ids, query]);
+
+    useEffect(() => {
+        let timer: ReturnType<typeof setTimeout> | null = null;
+        const unsubscribe = subscribe(resource, () => {
+            if (timer) return;
+            timer = setTimeout(() => {
+                timer = null;
+                refresh();
+            }, 250);
+        }, ids, query);
+
+        return () => {
+            if (timer) clearTimeout(timer);
+            unsubscribe();
+        };
+    }, [key, refresh]);
+}
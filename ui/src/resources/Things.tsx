@@ -13,6 +13,7 @@ import {
     SelectInput,
     Pagination
 } from 'react-admin';
+import { useRealtime } from '../realtime';
 
 const ListPagination = () => <Pagination rowsPerPageOptions={[10, 25, 50, 100]} />;
 
@@ -23,17 +24,20 @@ const listFilters = [
     </ReferenceInput>
 ];
 
-export const ThingList = () => (
-    <List sort={{ field: 'id', order: 'DESC'}} pagination={<ListPagination />} filters={listFilters} >
-       <Datagrid>
-           <TextField source="name" />
-           <TextField source="description" />
-           <DateField source="created" showTime={true} />
-           <DateField source="updated" showTime={true} />
-           <EditButton />
-        </Datagrid>
-    </List>
-);
+export const ThingList = () => {
+    useRealtime('things');
+    return (
+        <List sort={{ field: 'id', order: 'DESC'}} pagination={<ListPagination />} filters={listFilters} >
+           <Datagrid>
+               <TextField source="name" />
+               <TextField source="description" />
+               <DateField source="created" showTime={true} />
+               <DateField source="updated" showTime={true} />
+               <EditButton />
+            </Datagrid>
+        </List>
+    );
+};
 
 export const ThingEdit = () => (
     <Edit>
@@ -13,6 +13,7 @@ import {
     SelectInput,
     TextInput
 } from 'react-admin';
+import { useRealtime } from '../realtime';
 
 const WidgetFilter = () => (
     <Filter>
@@ -23,20 +24,23 @@ const WidgetFilter = () => (
     </Filter>
 );
 
-export const WidgetList = () => (
-    <List filters={<WidgetFilter />}>
-        <Datagrid rowClick="edit">
-            <TextField source="name" />
-            <TextField source="description" />
-            <ReferenceField source="thing_id" reference="things">
+export const WidgetList = () => {
+    useRealtime('widgets');
+    return (
+        <List filters={<WidgetFilter />}>
+            <Datagrid rowClick="edit">
                 <TextField source="name" />
-            </ReferenceField>
-            <DateField source="created" showTime={true} />
-            <DateField source="updated" showTime={true} />
-            <EditButton />
-        </Datagrid>
-    </List>
-);
+                <TextField source="description" />
+                <ReferenceField source="thing_id" reference="things">
+                    <TextField source="name" />
+                </ReferenceField>
+                <DateField source="created" showTime={true} />
+                <DateField source="updated" showTime={true} />
+                <EditButton />
+            </Datagrid>
+        </List>
+    );
+};
 
 export const WidgetEdit = () => (
     <Edit>